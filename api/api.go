@@ -2,15 +2,25 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
 
 	"github.com/pandusatrianura/kasir_api_service/api/middleware"
 	route "github.com/pandusatrianura/kasir_api_service/api/router"
+	auditHandler "github.com/pandusatrianura/kasir_api_service/internal/audit/delivery/http"
+	auditRepository "github.com/pandusatrianura/kasir_api_service/internal/audit/repository"
+	auditService "github.com/pandusatrianura/kasir_api_service/internal/audit/service"
+	cartHandler "github.com/pandusatrianura/kasir_api_service/internal/carts/delivery/http"
+	cartRepository "github.com/pandusatrianura/kasir_api_service/internal/carts/repository"
+	cartService "github.com/pandusatrianura/kasir_api_service/internal/carts/service"
 	categoryHandler "github.com/pandusatrianura/kasir_api_service/internal/categories/delivery/http"
 	categoryRepository "github.com/pandusatrianura/kasir_api_service/internal/categories/repository"
 	categoryService "github.com/pandusatrianura/kasir_api_service/internal/categories/service"
+	customerHandler "github.com/pandusatrianura/kasir_api_service/internal/customers/delivery/http"
+	customerRepository "github.com/pandusatrianura/kasir_api_service/internal/customers/repository"
+	customerService "github.com/pandusatrianura/kasir_api_service/internal/customers/service"
 	healthHandler "github.com/pandusatrianura/kasir_api_service/internal/health/delivery/http"
 	healthRepository "github.com/pandusatrianura/kasir_api_service/internal/health/repository"
 	healthService "github.com/pandusatrianura/kasir_api_service/internal/health/service"
@@ -18,15 +28,27 @@ import (
 	productHandler "github.com/pandusatrianura/kasir_api_service/internal/products/delivery/http"
 	productRepository "github.com/pandusatrianura/kasir_api_service/internal/products/repository"
 	productService "github.com/pandusatrianura/kasir_api_service/internal/products/service"
+	promotionHandler "github.com/pandusatrianura/kasir_api_service/internal/promotions/delivery/http"
+	promotionRepository "github.com/pandusatrianura/kasir_api_service/internal/promotions/repository"
+	promotionService "github.com/pandusatrianura/kasir_api_service/internal/promotions/service"
 	reportHandler "github.com/pandusatrianura/kasir_api_service/internal/reports/delivery/http"
 	reportRepository "github.com/pandusatrianura/kasir_api_service/internal/reports/repository"
 	reportService "github.com/pandusatrianura/kasir_api_service/internal/reports/service"
+	returnHandler "github.com/pandusatrianura/kasir_api_service/internal/returns/delivery/http"
+	returnRepository "github.com/pandusatrianura/kasir_api_service/internal/returns/repository"
+	returnService "github.com/pandusatrianura/kasir_api_service/internal/returns/service"
+	shiftHandler "github.com/pandusatrianura/kasir_api_service/internal/shifts/delivery/http"
+	shiftRepository "github.com/pandusatrianura/kasir_api_service/internal/shifts/repository"
+	shiftService "github.com/pandusatrianura/kasir_api_service/internal/shifts/service"
 	transactionsHandler "github.com/pandusatrianura/kasir_api_service/internal/transactions/delivery/http"
 	transactionsRepository "github.com/pandusatrianura/kasir_api_service/internal/transactions/repository"
 	transactionsService "github.com/pandusatrianura/kasir_api_service/internal/transactions/service"
 	userHandler "github.com/pandusatrianura/kasir_api_service/internal/users/delivery/http"
 	userRepository "github.com/pandusatrianura/kasir_api_service/internal/users/repository"
 	userService "github.com/pandusatrianura/kasir_api_service/internal/users/service"
+	webhookHandler "github.com/pandusatrianura/kasir_api_service/internal/webhooks/delivery/http"
+	webhookRepository "github.com/pandusatrianura/kasir_api_service/internal/webhooks/repository"
+	webhookService "github.com/pandusatrianura/kasir_api_service/internal/webhooks/service"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/pandusatrianura/kasir_api_service/pkg/database"
@@ -60,14 +82,43 @@ func (s *Server) Run() error {
 	healthSvc := healthService.NewHealthService(healthRepo)
 	healthHandle := healthHandler.NewHealthHandler(healthSvc)
 
+	promotionsRepo := promotionRepository.NewPromotionRepository(s.db)
+	promotionsSvc := promotionService.NewPromotionService(promotionsRepo)
+	promotionsHandle := promotionHandler.NewPromotionHandler(promotionsSvc)
+
 	transactionsRepo := transactionsRepository.NewTransactionsRepository(s.db)
-	transactionsSvc := transactionsService.NewTransactionsService(transactionsRepo)
+	transactionsSvc := transactionsService.NewTransactionsService(transactionsRepo, promotionsRepo)
 	transactionsHandle := transactionsHandler.NewTransactionsHandler(transactionsSvc)
 
+	cartsRepo := cartRepository.NewCartRepository(s.db)
+	cartsSvc := cartService.NewCartService(cartsRepo, transactionsSvc)
+	cartsHandle := cartHandler.NewCartHandler(cartsSvc)
+
+	customersRepo := customerRepository.NewCustomerRepository(s.db)
+	customersSvc := customerService.NewCustomerService(customersRepo)
+	customersHandle := customerHandler.NewCustomerHandler(customersSvc)
+
+	shiftsRepo := shiftRepository.NewShiftRepository(s.db)
+	shiftsSvc := shiftService.NewShiftService(shiftsRepo)
+	shiftsHandle := shiftHandler.NewShiftHandler(shiftsSvc)
+
 	reportsRepo := reportRepository.NewReportsRepository(s.db)
 	reportsService := reportService.NewReportService(reportsRepo)
 	reportsHandle := reportHandler.NewReportHandler(reportsService)
 
+	returnsRepo := returnRepository.NewReturnRepository(s.db)
+	returnsSvc := returnService.NewReturnService(returnsRepo)
+	returnsHandle := returnHandler.NewReturnHandler(returnsSvc)
+
+	webhooksRepo := webhookRepository.NewWebhookRepository(s.db)
+	webhooksSvc := webhookService.NewWebhookService(webhooksRepo)
+	webhooksHandle := webhookHandler.NewWebhookHandler(webhooksSvc)
+	webhooksDispatcher := webhookService.NewDispatcher(webhooksRepo)
+
+	auditRepo := auditRepository.NewAuditRepository(s.db)
+	auditSvc := auditService.NewAuditService(auditRepo)
+	auditHandle := auditHandler.NewAuditHandler(auditSvc)
+
 	indexHandle := indexHandler.NewIndexHandler()
 
 	usrRepo := userRepository.NewUserRepository(s.db)
@@ -75,7 +126,7 @@ func (s *Server) Run() error {
 	usrHandle := userHandler.NewUserHandler(usrSvc)
 
 	r := chi.NewRouter()
-	routers := route.NewRouter(categoriesHandle, productsHandle, healthHandle, transactionsHandle, indexHandle, reportsHandle, usrHandle)
+	routers := route.NewRouter(categoriesHandle, productsHandle, healthHandle, transactionsHandle, indexHandle, reportsHandle, usrHandle, returnsHandle, promotionsHandle, cartsHandle, customersHandle, shiftsHandle, webhooksHandle, auditHandle)
 	productRoute := routers.RegisterProductRoutes()
 	indexRoutes := routers.RegisterIndexRoutes()
 	docsRoutes := routers.RegisterDocsRoutes()
@@ -83,6 +134,12 @@ func (s *Server) Run() error {
 	transactionRoutes := routers.RegisterTransactionRoutes()
 	reportRoutes := routers.RegisterReportRoutes()
 	userRoutes := routers.RegisterUserRoutes()
+	returnRoutes := routers.RegisterReturnRoutes()
+	promotionRoutes := routers.RegisterPromotionRoutes()
+	customerRoutes := routers.RegisterCustomerRoutes()
+	shiftRoutes := routers.RegisterShiftRoutes()
+	webhookRoutes := routers.RegisterWebhookRoutes()
+	auditRoutes := routers.RegisterAuditRoutes()
 
 	r.Use(middleware.LoggingMiddleware, middleware.ErrorHandlingMiddleware, middleware.CORS)
 	r.Route("/api", func(r chi.Router) {
@@ -90,6 +147,12 @@ func (s *Server) Run() error {
 		r.Mount("/categories", categoryRoutes)
 		r.Mount("/transactions", transactionRoutes)
 		r.Mount("/reports", reportRoutes)
+		r.Mount("/returns", returnRoutes)
+		r.Mount("/promotions", promotionRoutes)
+		r.Mount("/customers", customerRoutes)
+		r.Mount("/shifts", shiftRoutes)
+		r.Mount("/webhooks", webhookRoutes)
+		r.Mount("/audit", auditRoutes)
 		r.Mount("/auth", userRoutes)
 		r.Mount("/docs", docsRoutes)
 	})
@@ -100,6 +163,9 @@ func (s *Server) Run() error {
 	r.Handle("/static/*", http.StripPrefix("/static/", http.FileServer(http.Dir("static"))))
 	r.Handle("/assets/*", http.StripPrefix("/assets/", http.FileServer(http.Dir("assets"))))
 
+	// Events written to the outbox are delivered to the registered webhooks in the background.
+	go webhooksDispatcher.Run(context.Background())
+
 	addr := fmt.Sprintf("%s%s", "0.0.0.0", s.addr)
 	log.Println("Starting server on", addr)
 	return http.ListenAndServe(s.addr, r)
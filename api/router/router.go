@@ -3,13 +3,20 @@ package router
 import (
 	"github.com/go-chi/chi/v5"
 	"github.com/pandusatrianura/kasir_api_service/api/middleware"
+	auditHandler "github.com/pandusatrianura/kasir_api_service/internal/audit/delivery/http"
+	cartHandler "github.com/pandusatrianura/kasir_api_service/internal/carts/delivery/http"
 	categoriesHandler "github.com/pandusatrianura/kasir_api_service/internal/categories/delivery/http"
+	customerHandler "github.com/pandusatrianura/kasir_api_service/internal/customers/delivery/http"
 	healthHandler "github.com/pandusatrianura/kasir_api_service/internal/health/delivery/http"
 	indexHandler "github.com/pandusatrianura/kasir_api_service/internal/index/delivery/http"
 	productsHandler "github.com/pandusatrianura/kasir_api_service/internal/products/delivery/http"
+	promotionHandler "github.com/pandusatrianura/kasir_api_service/internal/promotions/delivery/http"
 	reportHandler "github.com/pandusatrianura/kasir_api_service/internal/reports/delivery/http"
+	returnHandler "github.com/pandusatrianura/kasir_api_service/internal/returns/delivery/http"
+	shiftHandler "github.com/pandusatrianura/kasir_api_service/internal/shifts/delivery/http"
 	transactionsHandler "github.com/pandusatrianura/kasir_api_service/internal/transactions/delivery/http"
 	userHandler "github.com/pandusatrianura/kasir_api_service/internal/users/delivery/http"
+	webhookHandler "github.com/pandusatrianura/kasir_api_service/internal/webhooks/delivery/http"
 )
 
 type Router struct {
@@ -20,11 +27,22 @@ type Router struct {
 	index        *indexHandler.IndexHandler
 	report       *reportHandler.ReportHandler
 	user         *userHandler.UserHandler
+	returns      *returnHandler.ReturnHandler
+	promotions   *promotionHandler.PromotionHandler
+	carts        *cartHandler.CartHandler
+	customers    *customerHandler.CustomerHandler
+	shifts       *shiftHandler.ShiftHandler
+	webhooks     *webhookHandler.WebhookHandler
+	audit        *auditHandler.AuditHandler
 }
 
 func NewRouter(categoriesHandler *categoriesHandler.CategoryHandler, productHandler *productsHandler.ProductHandler,
 	healthHandler *healthHandler.HealthHandler, transactionHandler *transactionsHandler.TransactionHandler,
-	indexHandler *indexHandler.IndexHandler, reportHandler *reportHandler.ReportHandler, userHandler *userHandler.UserHandler) *Router {
+	indexHandler *indexHandler.IndexHandler, reportHandler *reportHandler.ReportHandler, userHandler *userHandler.UserHandler,
+	returnHandler *returnHandler.ReturnHandler, promotionHandler *promotionHandler.PromotionHandler,
+	cartHandler *cartHandler.CartHandler, customerHandler *customerHandler.CustomerHandler,
+	shiftHandler *shiftHandler.ShiftHandler, webhookHandler *webhookHandler.WebhookHandler,
+	auditHandler *auditHandler.AuditHandler) *Router {
 	return &Router{
 		categories:   categoriesHandler,
 		products:     productHandler,
@@ -33,6 +51,13 @@ func NewRouter(categoriesHandler *categoriesHandler.CategoryHandler, productHand
 		index:        indexHandler,
 		report:       reportHandler,
 		user:         userHandler,
+		returns:      returnHandler,
+		promotions:   promotionHandler,
+		carts:        cartHandler,
+		customers:    customerHandler,
+		shifts:       shiftHandler,
+		webhooks:     webhookHandler,
+		audit:        auditHandler,
 	}
 }
 
@@ -45,8 +70,15 @@ func (h *Router) RegisterProductRoutes() chi.Router {
 		r.Put("/{id}", products.UpdateProduct)
 		r.Delete("/{id}", products.DeleteProduct)
 		r.Get("/{id}", products.GetProductByID)
+		r.Get("/barcode/{code}", products.GetProductByBarcode)
+		r.Post("/{id}/variants", products.CreateVariant)
+		r.Put("/{id}/variants/{variant_id}", products.UpdateVariant)
+		r.Delete("/{id}/variants/{variant_id}", products.DeleteVariant)
+		r.Post("/{id}/units", products.CreateUnit)
+		r.Put("/{id}/units/{unit_id}", products.UpdateUnit)
+		r.Delete("/{id}/units/{unit_id}", products.DeleteUnit)
 	})
-	r.Get("/", products.GetAllProducts)
+	r.Get("/", products.GetProducts)
 	r.Get("/health", products.API)
 	return r
 }
@@ -70,11 +102,119 @@ func (h *Router) RegisterCategoriesRoutes() chi.Router {
 func (h *Router) RegisterTransactionRoutes() chi.Router {
 	r := chi.NewRouter()
 	transactions := h.transactions
+	carts := h.carts
 	r.Group(func(r chi.Router) {
 		r.Use(middleware.Auth, middleware.JWTAuthMiddleware)
 		r.Post("/checkout", transactions.Checkout)
+		r.Post("/quote", transactions.Quote)
+		r.Post("/sync", transactions.Sync)
+		r.Post("/carts", carts.CreateCart)
+		r.Get("/carts", carts.GetHeldCarts)
+		r.Get("/carts/{id}", carts.GetCartByID)
+		r.Post("/carts/{id}/items", carts.AddItem)
+		r.Delete("/carts/{id}/items/{product_id}", carts.RemoveItem)
+		r.Post("/carts/{id}/hold", carts.HoldCart)
+		r.Post("/carts/{id}/resume", carts.ResumeCart)
+		r.Post("/carts/{id}/checkout", carts.CheckoutCart)
+		r.Get("/", transactions.GetTransactions)
+		r.Get("/{id}", transactions.GetTransactionByID)
+		r.Post("/{id}/void", transactions.VoidTransaction)
+		r.Post("/{id}/review", transactions.MarkReviewed)
+		r.Get("/{id}/receipt", transactions.GetReceipt)
 	})
 	r.Get("/health", transactions.API)
+	r.Get("/carts/health", carts.API)
+	return r
+}
+
+func (h *Router) RegisterReturnRoutes() chi.Router {
+	r := chi.NewRouter()
+	returns := h.returns
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.Auth, middleware.JWTAuthMiddleware)
+		r.Post("/", returns.CreateReturn)
+		r.Get("/", returns.GetReturnsByTransactionID)
+		r.Get("/{id}", returns.GetReturnByID)
+	})
+	r.Get("/health", returns.API)
+	return r
+}
+
+func (h *Router) RegisterPromotionRoutes() chi.Router {
+	r := chi.NewRouter()
+	promotions := h.promotions
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.Auth, middleware.JWTAuthMiddleware)
+		r.Post("/", promotions.CreatePromotion)
+		r.Get("/", promotions.GetAllPromotions)
+		r.Get("/{id}", promotions.GetPromotionByID)
+		r.Put("/{id}", promotions.UpdatePromotion)
+		r.Delete("/{id}", promotions.DeletePromotion)
+	})
+	r.Get("/health", promotions.API)
+	return r
+}
+
+func (h *Router) RegisterCustomerRoutes() chi.Router {
+	r := chi.NewRouter()
+	customers := h.customers
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.Auth, middleware.JWTAuthMiddleware)
+		r.Post("/", customers.CreateCustomer)
+		r.Get("/", customers.GetCustomers)
+		r.Get("/{id}", customers.GetCustomerByID)
+		r.Put("/{id}", customers.UpdateCustomer)
+		r.Delete("/{id}", customers.DeleteCustomer)
+		r.Get("/{id}/points", customers.GetPointsLedger)
+		r.Get("/{id}/transactions", customers.GetPurchases)
+		r.Get("/{id}/receivables", customers.GetReceivablesLedger)
+		r.Post("/{id}/repayments", customers.RecordRepayment)
+	})
+	r.Get("/health", customers.API)
+	return r
+}
+
+func (h *Router) RegisterShiftRoutes() chi.Router {
+	r := chi.NewRouter()
+	shifts := h.shifts
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.Auth, middleware.JWTAuthMiddleware)
+		r.Post("/open", shifts.OpenShift)
+		r.Get("/current", shifts.GetCurrentShift)
+		r.Get("/", shifts.GetShifts)
+		r.Get("/{id}", shifts.GetShiftReport)
+		r.Post("/{id}/cash-movements", shifts.AddCashMovement)
+		r.Post("/{id}/close", shifts.CloseShift)
+	})
+	r.Get("/health", shifts.API)
+	return r
+}
+
+func (h *Router) RegisterWebhookRoutes() chi.Router {
+	r := chi.NewRouter()
+	webhooks := h.webhooks
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.Auth, middleware.JWTAuthMiddleware)
+		r.Post("/", webhooks.CreateWebhook)
+		r.Get("/", webhooks.GetWebhooks)
+		r.Get("/{id}", webhooks.GetWebhookByID)
+		r.Put("/{id}", webhooks.UpdateWebhook)
+		r.Delete("/{id}", webhooks.DeleteWebhook)
+		r.Get("/{id}/deliveries", webhooks.GetDeliveries)
+		r.Post("/deliveries/{id}/retry", webhooks.RetryDelivery)
+	})
+	r.Get("/health", webhooks.API)
+	return r
+}
+
+func (h *Router) RegisterAuditRoutes() chi.Router {
+	r := chi.NewRouter()
+	audit := h.audit
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.Auth, middleware.JWTAuthMiddleware)
+		r.Get("/verify", audit.VerifyChain)
+	})
+	r.Get("/health", audit.API)
 	return r
 }
 
@@ -84,6 +224,8 @@ func (h *Router) RegisterReportRoutes() chi.Router {
 	r.Group(func(r chi.Router) {
 		r.Use(middleware.Auth, middleware.JWTAuthMiddleware)
 		r.Get("/hari-ini", report.Today)
+		r.Get("/cashiers", report.Cashier)
+		r.Get("/receivables-aging", report.ReceivablesAging)
 		r.Get("/", report.Report)
 	})
 	r.Get("/health", report.API)
@@ -0,0 +1,7 @@
+package constants
+
+const (
+	ChainRecordSale   = "sale"
+	ChainRecordVoid   = "void"
+	ChainRecordReturn = "return"
+)
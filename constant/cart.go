@@ -0,0 +1,8 @@
+package constants
+
+const (
+	CartStatusOpen       = "open"
+	CartStatusHeld       = "held"
+	CartStatusCheckedOut = "checked_out"
+	CartStatusExpired    = "expired"
+)
@@ -0,0 +1,14 @@
+package constants
+
+const (
+	PointsTypeEarn   = "earn"
+	PointsTypeRedeem = "redeem"
+	PointsTypeVoid   = "void"
+)
+
+const (
+	ReceivableTypeCharge    = "charge"
+	ReceivableTypeRepayment = "repayment"
+	ReceivableTypeVoid      = "void"
+	ReceivableTypeReturn    = "return"
+)
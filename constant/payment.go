@@ -0,0 +1,10 @@
+package constants
+
+const (
+	PaymentMethodCash    = "cash"
+	PaymentMethodDebit   = "debit"
+	PaymentMethodQRIS    = "qris"
+	PaymentMethodEWallet = "e-wallet"
+	PaymentMethodVoucher = "voucher"
+	PaymentMethodCredit  = "credit"
+)
@@ -0,0 +1,14 @@
+package constants
+
+const (
+	ProductSortName      = "name"
+	ProductSortPrice     = "price"
+	ProductSortStock     = "stock"
+	ProductSortUpdatedAt = "updated_at"
+
+	SortOrderAsc  = "asc"
+	SortOrderDesc = "desc"
+
+	DefaultBaseUnit = "pcs"
+	MaxUnitLength   = 20
+)
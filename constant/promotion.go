@@ -0,0 +1,10 @@
+package constants
+
+const (
+	PromotionTypePercentage          = "percentage"
+	PromotionTypeFixed               = "fixed"
+	PromotionTypeBuyXGetY            = "buy_x_get_y"
+	PromotionTypeBundle              = "bundle"
+	PromotionTypeMinBasketPercentage = "min_basket_percentage"
+	PromotionTypeMinBasketFixed      = "min_basket_fixed"
+)
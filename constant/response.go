@@ -10,6 +10,7 @@ const (
 	ErrInvalidProductID       = "invalid product id"
 	ErrInvalidProductRequest  = "invalid product request"
 	ErrInvalidCheckoutRequest = "invalid checkout request"
+	ErrInvalidQuantity        = "quantity must be greater than zero"
 	ErrTransactionNotFound    = "transactions not found"
 	ErrRequiredDate           = "start date and end date are required"
 	ErrStarDate               = "start date cannot be greater than end date"
@@ -19,4 +20,104 @@ const (
 	ErrStockEmpty             = "stock is empty"
 	ErrCategoryNotFound       = "category not found"
 	ErrRoleNotAuthorized      = "role not authorized"
+	ErrInvalidTransactionID   = "invalid transaction id"
+	ErrInvalidTransactionList = "invalid transaction list request"
+	ErrInvalidVoidRequest     = "invalid void request"
+	ErrVoidReasonRequired     = "void reason is required"
+	ErrTransactionVoided      = "transaction already voided"
+	ErrInvalidReturnID        = "invalid return id"
+	ErrInvalidReturnRequest   = "invalid return request"
+	ErrReturnNotFound         = "return not found"
+	ErrReturnQuantityInvalid  = "return quantity must be greater than zero"
+	ErrReturnQuantityExceeded = "return quantity exceeds quantity sold minus previous returns"
+	ErrDetailNotFound         = "transaction detail not found"
+	ErrPaymentRequired        = "at least one payment is required"
+	ErrInvalidPaymentMethod   = "invalid payment method"
+	ErrInvalidPaymentAmount   = "payment amount must be greater than zero"
+	ErrUnderpayment           = "payment amount is less than total amount"
+	ErrNonCashOverpayment     = "non-cash payments cannot exceed the amount due"
+	ErrInvalidPromotionID     = "invalid promotion id"
+	ErrInvalidPromotionReq    = "invalid promotion request"
+	ErrPromotionNotFound      = "promotion not found"
+	ErrInvalidCartID          = "invalid cart id"
+	ErrInvalidCartRequest     = "invalid cart request"
+	ErrCartNotFound           = "cart not found"
+	ErrCartEmpty              = "cart has no items"
+	ErrCartNotOpen            = "cart is not open"
+	ErrCartNotHeld            = "cart is not held"
+	ErrCartClosed             = "cart is already checked out or expired"
+	ErrInvalidCartQuantity    = "cart item quantity must be greater than zero"
+	ErrInvalidIdempotencyKey  = "idempotency key must be at most 255 characters"
+	ErrIdempotencyKeyReused   = "idempotency key was already used with a different request"
+	ErrIdempotencyInProgress  = "a request with this idempotency key is still being processed"
+	ErrInvalidReceiptFormat   = "receipt format must be text, escpos, html or pdf"
+	ErrInvalidReceiptWidth    = "receipt width must be 32 or 48"
+	ErrInvalidCustomerID      = "invalid customer id"
+	ErrInvalidCustomerRequest = "invalid customer request"
+	ErrCustomerNotFound       = "customer not found"
+	ErrCustomerNameRequired   = "customer name is required"
+	ErrCustomerExists         = "a customer with this phone or email already exists"
+	ErrInvalidRedeemPoints    = "redeem points must not be negative"
+	ErrRedeemNeedsCustomer    = "a customer is required to redeem points"
+	ErrPointsNotEnough        = "customer does not have enough points"
+	ErrRedeemExceedsTotal     = "redeemed points exceed the amount due"
+	ErrInvalidShiftID         = "invalid shift id"
+	ErrInvalidShiftRequest    = "invalid shift request"
+	ErrShiftNotFound          = "shift not found"
+	ErrShiftAlreadyOpen       = "cashier already has an open shift"
+	ErrShiftNotOpen           = "no open shift, open a shift first"
+	ErrShiftClosed            = "shift is already closed"
+	ErrInvalidOpeningFloat    = "opening float must not be negative"
+	ErrInvalidCountedCash     = "counted cash must not be negative"
+	ErrInvalidCashMovement    = "cash movement type must be in or out"
+	ErrInvalidMovementAmount  = "cash movement amount must be greater than zero"
+	ErrMovementReasonRequired = "cash movement reason is required"
+	ErrInvalidSyncRequest     = "invalid sync request"
+	ErrInvalidSyncBatch       = "sync batch must contain between 1 and 100 transactions"
+	ErrInvalidClientID        = "client_id must be a UUID"
+	ErrInvalidSaleTime        = "created_at must be an RFC3339 time that is not in the future"
+	ErrDuplicateClientID      = "a transaction with this client_id already exists"
+	ErrInvalidWebhookID       = "invalid webhook id"
+	ErrInvalidWebhookRequest  = "invalid webhook request"
+	ErrWebhookNotFound        = "webhook not found"
+	ErrInvalidWebhookURL      = "webhook url must be an absolute http or https url"
+	ErrInvalidEventType       = "unknown event type"
+	ErrInvalidDeliveryID      = "invalid delivery id"
+	ErrDeliveryNotFound       = "delivery not found"
+	ErrInvalidDeliveryStatus  = "delivery status must be pending, delivered or dead"
+	ErrDeliveryNotDead        = "only dead deliveries can be retried"
+	ErrInvalidProductList     = "invalid product list request"
+	ErrInvalidProductSort     = "sort must be name, price, stock or updated_at"
+	ErrInvalidSortOrder       = "order must be asc or desc"
+	ErrInvalidPriceRange      = "min_price must not be greater than max_price"
+	ErrCreditNeedsCustomer    = "a customer is required to pay on credit"
+	ErrCreditOverpayment      = "a sale paid on credit cannot be overpaid"
+	ErrCreditLimitExceeded    = "sale exceeds the credit limit of the customer"
+	ErrInvalidCreditLimit     = "credit limit must not be negative"
+	ErrInvalidRepayment       = "invalid repayment request"
+	ErrInvalidRepaymentAmount = "repayment amount must be greater than zero"
+	ErrRepaymentExceedsDebt   = "repayment exceeds the receivable balance"
+	ErrInvalidRepaymentMethod = "repayment method must be cash, debit, qris or e-wallet"
+	ErrCustomerHasReceivable  = "customer still has an outstanding receivable balance"
+	ErrProductExists          = "a product with this sku or barcode already exists"
+	ErrInvalidSKU             = "sku must not be longer than 64 characters"
+	ErrInvalidBarcode         = "barcode must be a valid EAN-13, UPC-A or EAN-8 code"
+	ErrDuplicateBarcode       = "a barcode is listed more than once"
+	ErrInvalidVariantID       = "invalid variant id"
+	ErrInvalidVariantRequest  = "invalid variant request"
+	ErrVariantNotFound        = "variant not found"
+	ErrVariantExists          = "a variant with this sku, barcode or these options already exists"
+	ErrVariantRequired        = "variant required"
+	ErrInvalidVariantAxes     = "variant axes must not be empty or listed twice"
+	ErrVariantAxesInUse       = "variant axes cannot change while the product has variants"
+	ErrProductHasNoAxes       = "set the variant axes of the product before adding variants"
+	ErrInvalidVariantOptions  = "options must have one value for every variant axis of the product"
+	ErrInvalidVariantPrice    = "variant price must not be negative"
+	ErrInvalidUnitID          = "invalid unit id"
+	ErrInvalidUnitRequest     = "invalid unit request"
+	ErrUnitNotFound           = "unit not found"
+	ErrUnitExists             = "the product already has a unit with this name"
+	ErrInvalidUnitName        = "unit name must not be empty or longer than 20 characters"
+	ErrInvalidUnitFactor      = "unit factor must be greater than one"
+	ErrInvalidUnitPrice       = "unit price must not be negative"
 )
@@ -0,0 +1,9 @@
+package constants
+
+const (
+	ShiftStatusOpen   = "open"
+	ShiftStatusClosed = "closed"
+
+	CashMovementIn  = "in"
+	CashMovementOut = "out"
+)
@@ -0,0 +1,17 @@
+package constants
+
+const (
+	TransactionStatusCompleted = "completed"
+	TransactionStatusVoided    = "voided"
+)
+
+const (
+	SyncStatusAccepted  = "accepted"
+	SyncStatusDuplicate = "duplicate"
+	SyncStatusRejected  = "rejected"
+)
+
+const (
+	IdempotencyStatusProcessing = "processing"
+	IdempotencyStatusCompleted  = "completed"
+)
@@ -0,0 +1,14 @@
+package constants
+
+const (
+	EventTransactionCreated = "transaction.created"
+	EventTransactionVoided  = "transaction.voided"
+	EventProductStockLow    = "product.stock_low"
+	EventProductUpdated     = "product.updated"
+)
+
+const (
+	DeliveryStatusPending   = "pending"
+	DeliveryStatusDelivered = "delivered"
+	DeliveryStatusDead      = "dead"
+)
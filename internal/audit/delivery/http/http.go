@@ -0,0 +1,78 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	constants "github.com/pandusatrianura/kasir_api_service/constant"
+	"github.com/pandusatrianura/kasir_api_service/internal/audit/service"
+	"github.com/pandusatrianura/kasir_api_service/pkg/response"
+)
+
+type AuditHandler struct {
+	service service.IAuditService
+}
+
+func NewAuditHandler(service service.IAuditService) *AuditHandler {
+	return &AuditHandler{service: service}
+}
+
+// API godoc
+// @Summary Get health status of audit API
+// @Description Get health status of audit API
+// @Tags audit
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 503 {object} map[string]string
+// @Router /api/audit/health [get]
+func (h *AuditHandler) API(w http.ResponseWriter, r *http.Request) {
+	var result response.APIResponse
+	svcHealthCheckResult := h.service.API()
+
+	if svcHealthCheckResult.IsHealthy {
+		result.Code = strconv.Itoa(constants.SuccessCode)
+		result.Message = fmt.Sprintf("%s is healthy", svcHealthCheckResult.Name)
+		response.WriteJSONResponse(w, http.StatusOK, result)
+		return
+	}
+
+	result.Code = strconv.Itoa(constants.ErrorCode)
+	result.Message = fmt.Sprintf("%s is not healthy", svcHealthCheckResult.Name)
+	response.WriteJSONResponse(w, http.StatusServiceUnavailable, result)
+	return
+}
+
+// VerifyChain godoc
+// @Summary Verify the audit hash chain
+// @Description Walk the hash chain over sales, voids and returns and report the first broken link (Manager only)
+// @Tags audit
+// @Accept json
+// @Produce json
+// @Param X-API-Key header string true "your-secret-api-key-here"
+// @Param Authorization header string true "Bearer <token>"
+// @Success 200 {object} map[string]interface{}
+// @Failure 500 {object} map[string]string
+// @Router /api/audit/verify [get]
+func (h *AuditHandler) VerifyChain(w http.ResponseWriter, r *http.Request) {
+	role := r.Header.Get("X-User-Roles")
+	if role != constants.ManagerRole {
+		response.Error(w, http.StatusUnauthorized, constants.ErrorCode, constants.ErrRoleNotAuthorized, errors.New(fmt.Sprintf("%s", role)))
+		return
+	}
+
+	verification, err := h.service.VerifyChain()
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, constants.ErrorCode, "Audit chain verified failed", err)
+		return
+	}
+
+	message := "Audit chain is intact"
+	if !verification.Valid {
+		message = "Audit chain is broken"
+	}
+
+	response.Success(w, http.StatusOK, constants.SuccessCode, message, verification)
+}
@@ -0,0 +1,112 @@
+package entity
+
+type HealthCheck struct {
+	Name      string `json:"name"`
+	IsHealthy bool   `json:"is_healthy"`
+}
+
+// ChainLink is one record of the audit hash chain.
+type ChainLink struct {
+	Seq           int64
+	Version       int
+	RecordType    string
+	TransactionID int
+	ReturnID      int
+	PrevHash      string
+	Hash          string
+}
+
+// ChainVerification is the result of walking the audit hash chain. BrokenLink is the first record that does not
+// match, every record after it is unverified.
+type ChainVerification struct {
+	Valid          bool        `json:"valid"`
+	RecordsChecked int         `json:"records_checked"`
+	LastHash       string      `json:"last_hash,omitempty"`
+	BrokenLink     *BrokenLink `json:"broken_link,omitempty"`
+}
+
+type BrokenLink struct {
+	Seq           int64  `json:"seq"`
+	RecordType    string `json:"record_type"`
+	TransactionID int    `json:"transaction_id"`
+	ReturnID      int    `json:"return_id,omitempty"`
+	Reason        string `json:"reason"`
+	ExpectedHash  string `json:"expected_hash,omitempty"`
+	StoredHash    string `json:"stored_hash,omitempty"`
+}
+
+// SaleRecord is the canonical content hashed for a sale. Fields that are only hashed from a record version on are
+// pointers left nil for older versions, so those records marshal exactly as they were hashed.
+type SaleRecord struct {
+	TransactionID  int           `json:"transaction_id"`
+	InvoiceNumber  string        `json:"invoice_number"`
+	UserID         int           `json:"user_id"`
+	TerminalID     string        `json:"terminal_id"`
+	ShiftID        int           `json:"shift_id"`
+	ClientID       string        `json:"client_id"`
+	CustomerID     *int          `json:"customer_id,omitempty"`
+	NeedsReview    *bool         `json:"needs_review,omitempty"`
+	PointsEarned   int           `json:"points_earned"`
+	PointsRedeemed int           `json:"points_redeemed"`
+	PointsDiscount int           `json:"points_discount"`
+	SubtotalAmount int           `json:"subtotal_amount"`
+	DiscountAmount int           `json:"discount_amount"`
+	NetAmount      int           `json:"net_amount"`
+	TaxAmount      int           `json:"tax_amount"`
+	ServiceCharge  int           `json:"service_charge"`
+	TotalAmount    int           `json:"total_amount"`
+	PaidAmount     int           `json:"paid_amount"`
+	Change         int           `json:"change"`
+	CreatedAt      string        `json:"created_at"`
+	Lines          []SaleLine    `json:"lines"`
+	Payments       []SalePayment `json:"payments"`
+}
+
+type SaleLine struct {
+	ID             int    `json:"id"`
+	ProductID      *int   `json:"product_id,omitempty"`
+	VariantID      *int   `json:"variant_id,omitempty"`
+	ProductName    string `json:"product_name"`
+	ProductSKU     string `json:"product_sku"`
+	CategoryName   string `json:"category_name"`
+	Unit           string `json:"unit,omitempty"`
+	UnitFactor     int    `json:"unit_factor,omitempty"`
+	Quantity       int    `json:"quantity"`
+	UnitPrice      int    `json:"unit_price"`
+	Subtotal       int    `json:"subtotal"`
+	DiscountAmount int    `json:"discount_amount"`
+	NetAmount      int    `json:"net_amount"`
+	TaxRate        string `json:"tax_rate"`
+	TaxAmount      int    `json:"tax_amount"`
+}
+
+type SalePayment struct {
+	ID        int    `json:"id"`
+	Method    string `json:"method"`
+	Amount    int    `json:"amount"`
+	Reference string `json:"reference"`
+}
+
+// VoidRecord is the canonical content hashed for a void.
+type VoidRecord struct {
+	TransactionID int    `json:"transaction_id"`
+	Status        string `json:"status"`
+	VoidReason    string `json:"void_reason"`
+	VoidedBy      int    `json:"voided_by"`
+	ShiftID       *int   `json:"shift_id,omitempty"`
+	VoidedAt      string `json:"voided_at"`
+}
+
+// ReturnRecord is the canonical content hashed for a return.
+type ReturnRecord struct {
+	ReturnID            int    `json:"return_id"`
+	TransactionID       int    `json:"transaction_id"`
+	TransactionDetailID int    `json:"transaction_detail_id"`
+	Quantity            int    `json:"quantity"`
+	RefundAmount        int    `json:"refund_amount"`
+	CreditAmount        *int   `json:"credit_amount,omitempty"`
+	Reason              string `json:"reason"`
+	UserID              int    `json:"user_id"`
+	ShiftID             *int   `json:"shift_id,omitempty"`
+	CreatedAt           string `json:"created_at"`
+}
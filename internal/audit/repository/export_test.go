@@ -0,0 +1,8 @@
+package repository
+
+// Canonical, ChainHash and GenesisHash let tests rehash a record the way an older record version chained it.
+var (
+	Canonical   = canonical
+	ChainHash   = chainHash
+	GenesisHash = genesisHash
+)
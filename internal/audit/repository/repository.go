@@ -0,0 +1,411 @@
+package repository
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	constants "github.com/pandusatrianura/kasir_api_service/constant"
+	"github.com/pandusatrianura/kasir_api_service/internal/audit/entity"
+	"github.com/pandusatrianura/kasir_api_service/pkg/database"
+)
+
+const (
+	// timestampFormat renders timestamps the same way every time they are hashed, whatever the driver or time zone.
+	timestampFormat = `'YYYY-MM-DD"T"HH24:MI:SS.US'`
+
+	verifyBatchSize = 500
+
+	// recordVersion is the version of the contents hashed for new records. Version 2 added the customer and review
+	// flag of a sale, the product and variant of a line, the shift of a void and the credit and shift of a return.
+	recordVersion = 2
+
+	reasonPrevHash   = "previous hash does not match the record before it"
+	reasonContents   = "record contents do not match its hash"
+	reasonStoredHash = "hash stored on the record does not match the chain"
+	reasonMissing    = "chained record no longer exists"
+)
+
+// genesisHash is the previous hash of the first record in the chain.
+var genesisHash = strings.Repeat("0", 64)
+
+type IAuditRepository interface {
+	VerifyChain() (*entity.ChainVerification, error)
+}
+
+type AuditRepository struct {
+	db *database.DB
+}
+
+func NewAuditRepository(db *database.DB) IAuditRepository {
+	return &AuditRepository{db: db}
+}
+
+// Append chains a sale, void or return inside the database transaction that wrote it. The advisory lock holds
+// other appends back until that transaction commits, so records are chained in commit order and no two records
+// ever share a previous hash. Call it last, just before the commit, to keep the lock short.
+func Append(q database.Querier, recordType string, transactionID int, returnID int) error {
+	var (
+		prevHash string
+		query    string
+		err      error
+	)
+
+	query = "SELECT pg_advisory_xact_lock(hashtext('audit_chain'))"
+	err = q.WithStmt(query, func(stmt *database.Stmt) error {
+		_, err := stmt.Exec()
+		return err
+	})
+
+	if err != nil {
+		return err
+	}
+
+	query = "SELECT hash FROM audit_chain ORDER BY seq DESC LIMIT 1"
+	err = q.WithStmt(query, func(stmt *database.Stmt) error {
+		return stmt.QueryRow().Scan(&prevHash)
+	})
+
+	if errors.Is(err, sql.ErrNoRows) {
+		prevHash = genesisHash
+	} else if err != nil {
+		return err
+	}
+
+	content, _, err := canonical(q, recordVersion, recordType, transactionID, returnID)
+	if err != nil {
+		return err
+	}
+
+	hash := chainHash(prevHash, recordType, content)
+
+	query = "INSERT INTO audit_chain (version, record_type, transaction_id, return_id, prev_hash, hash, created_at) VALUES ($1, $2, $3, NULLIF($4, 0), $5, $6, $7)"
+	err = q.WithStmt(query, func(stmt *database.Stmt) error {
+		_, err := stmt.Exec(recordVersion, recordType, transactionID, returnID, prevHash, hash, "now()")
+		return err
+	})
+
+	if err != nil {
+		return err
+	}
+
+	switch recordType {
+	case constants.ChainRecordSale:
+		query = "UPDATE transactions SET chain_hash = $1 WHERE id = $2"
+	case constants.ChainRecordVoid:
+		query = "UPDATE transactions SET void_chain_hash = $1 WHERE id = $2"
+	default:
+		query = "UPDATE transaction_returns SET chain_hash = $1 WHERE id = $2"
+		transactionID = returnID
+	}
+
+	return q.WithStmt(query, func(stmt *database.Stmt) error {
+		_, err := stmt.Exec(hash, transactionID)
+		return err
+	})
+}
+
+// VerifyChain walks the chain from the first record, recomputing every hash from the records as they are now,
+// and stops at the first link that does not match.
+func (a *AuditRepository) VerifyChain() (*entity.ChainVerification, error) {
+	var (
+		lastSeq  int64
+		prevHash = genesisHash
+	)
+
+	verification := entity.ChainVerification{Valid: true}
+
+	for {
+		links, err := a.getLinks(lastSeq, verifyBatchSize)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(links) == 0 {
+			break
+		}
+
+		for _, link := range links {
+			broken, err := a.verifyLink(link, prevHash)
+			if err != nil {
+				return nil, err
+			}
+
+			if broken != nil {
+				verification.Valid = false
+				verification.BrokenLink = broken
+				return &verification, nil
+			}
+
+			verification.RecordsChecked++
+			verification.LastHash = link.Hash
+			prevHash = link.Hash
+			lastSeq = link.Seq
+		}
+	}
+
+	return &verification, nil
+}
+
+func (a *AuditRepository) getLinks(afterSeq int64, limit int) ([]entity.ChainLink, error) {
+	var (
+		links []entity.ChainLink
+		query string
+		err   error
+	)
+
+	query = "SELECT seq, version, record_type, transaction_id, COALESCE(return_id, 0), prev_hash, hash FROM audit_chain WHERE seq > $1 ORDER BY seq LIMIT $2"
+	err = a.db.WithStmt(query, func(stmt *database.Stmt) error {
+		scanFn := func(rows *database.Rows) error {
+			var link entity.ChainLink
+			if err := rows.Scan(&link.Seq, &link.Version, &link.RecordType, &link.TransactionID, &link.ReturnID, &link.PrevHash, &link.Hash); err != nil {
+				return err
+			}
+
+			links = append(links, link)
+			return nil
+		}
+
+		return stmt.Query(scanFn, afterSeq, limit)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return links, nil
+}
+
+func (a *AuditRepository) verifyLink(link entity.ChainLink, prevHash string) (*entity.BrokenLink, error) {
+	broken := &entity.BrokenLink{
+		Seq:           link.Seq,
+		RecordType:    link.RecordType,
+		TransactionID: link.TransactionID,
+		ReturnID:      link.ReturnID,
+	}
+
+	if link.PrevHash != prevHash {
+		broken.Reason = reasonPrevHash
+		broken.ExpectedHash = prevHash
+		broken.StoredHash = link.PrevHash
+		return broken, nil
+	}
+
+	content, storedHash, err := canonical(a.db, link.Version, link.RecordType, link.TransactionID, link.ReturnID)
+	if err != nil && (err.Error() == constants.ErrTransactionNotFound || err.Error() == constants.ErrReturnNotFound) {
+		broken.Reason = reasonMissing
+		return broken, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if expected := chainHash(link.PrevHash, link.RecordType, content); expected != link.Hash {
+		broken.Reason = reasonContents
+		broken.ExpectedHash = expected
+		broken.StoredHash = link.Hash
+		return broken, nil
+	}
+
+	if storedHash != link.Hash {
+		broken.Reason = reasonStoredHash
+		broken.ExpectedHash = link.Hash
+		broken.StoredHash = storedHash
+		return broken, nil
+	}
+
+	return nil, nil
+}
+
+// chainHash is the hex encoded SHA-256 of the previous hash, the record type and the canonical contents.
+func chainHash(prevHash string, recordType string, content []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(prevHash))
+	hash.Write([]byte("\n"))
+	hash.Write([]byte(recordType))
+	hash.Write([]byte("\n"))
+	hash.Write(content)
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// canonical returns the canonical JSON of a chained record, in the contents of the given record version, together with
+// the hash stored on it.
+func canonical(q database.Querier, version int, recordType string, transactionID int, returnID int) ([]byte, string, error) {
+	var (
+		record     interface{}
+		storedHash string
+		err        error
+	)
+
+	switch recordType {
+	case constants.ChainRecordSale:
+		record, storedHash, err = getSaleRecord(q, version, transactionID)
+	case constants.ChainRecordVoid:
+		record, storedHash, err = getVoidRecord(q, version, transactionID)
+	case constants.ChainRecordReturn:
+		record, storedHash, err = getReturnRecord(q, version, returnID)
+	default:
+		err = fmt.Errorf("unknown chain record type: %s", recordType)
+	}
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	content, err := json.Marshal(record)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return content, storedHash, nil
+}
+
+func getSaleRecord(q database.Querier, version int, transactionID int) (*entity.SaleRecord, string, error) {
+	var (
+		sale        entity.SaleRecord
+		customerID  int
+		needsReview bool
+		storedHash  string
+		query       string
+		err         error
+	)
+
+	sale.Lines = make([]entity.SaleLine, 0)
+	sale.Payments = make([]entity.SalePayment, 0)
+
+	// A sale that has been reviewed was sold with needs_review set.
+	query = fmt.Sprintf("SELECT id, COALESCE(invoice_number, ''), COALESCE(user_id, 0), COALESCE(terminal_id, ''), COALESCE(shift_id, 0), COALESCE(client_id::text, ''), COALESCE(customer_id, 0), needs_review OR reviewed_at IS NOT NULL, points_earned, points_redeemed, points_discount, subtotal_amount, discount_amount, net_amount, tax_amount, service_charge_amount, total_amount, paid_amount, change_amount, to_char(created_at, %s), COALESCE(chain_hash, '') FROM transactions WHERE id = $1", timestampFormat)
+	err = q.WithStmt(query, func(stmt *database.Stmt) error {
+		return stmt.QueryRow(transactionID).Scan(&sale.TransactionID, &sale.InvoiceNumber, &sale.UserID, &sale.TerminalID, &sale.ShiftID, &sale.ClientID, &customerID, &needsReview, &sale.PointsEarned, &sale.PointsRedeemed, &sale.PointsDiscount, &sale.SubtotalAmount, &sale.DiscountAmount, &sale.NetAmount, &sale.TaxAmount, &sale.ServiceCharge, &sale.TotalAmount, &sale.PaidAmount, &sale.Change, &sale.CreatedAt, &storedHash)
+	})
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, "", errors.New(constants.ErrTransactionNotFound)
+	}
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	if version >= 2 {
+		sale.CustomerID = &customerID
+		sale.NeedsReview = &needsReview
+	}
+
+	// Lines sold in the base unit leave unit and unit_factor empty so sales recorded before units existed hash the same.
+	query = "SELECT id, COALESCE(product_id, 0), COALESCE(variant_id, 0), product_name, COALESCE(product_sku, ''), COALESCE(category_name, ''), unit, CASE WHEN unit = '' THEN 0 ELSE unit_factor END, quantity, unit_price, subtotal, discount_amount, net_amount, tax_rate::text, tax_amount FROM transaction_details WHERE transaction_id = $1 ORDER BY id"
+	err = q.WithStmt(query, func(stmt *database.Stmt) error {
+		scanFn := func(rows *database.Rows) error {
+			var (
+				line      entity.SaleLine
+				productID int
+				variantID int
+			)
+
+			if err := rows.Scan(&line.ID, &productID, &variantID, &line.ProductName, &line.ProductSKU, &line.CategoryName, &line.Unit, &line.UnitFactor, &line.Quantity, &line.UnitPrice, &line.Subtotal, &line.DiscountAmount, &line.NetAmount, &line.TaxRate, &line.TaxAmount); err != nil {
+				return err
+			}
+
+			if version >= 2 {
+				line.ProductID = &productID
+				line.VariantID = &variantID
+			}
+
+			sale.Lines = append(sale.Lines, line)
+			return nil
+		}
+
+		return stmt.Query(scanFn, transactionID)
+	})
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	query = "SELECT id, method, amount, COALESCE(reference, '') FROM transaction_payments WHERE transaction_id = $1 ORDER BY id"
+	err = q.WithStmt(query, func(stmt *database.Stmt) error {
+		scanFn := func(rows *database.Rows) error {
+			var payment entity.SalePayment
+			if err := rows.Scan(&payment.ID, &payment.Method, &payment.Amount, &payment.Reference); err != nil {
+				return err
+			}
+
+			sale.Payments = append(sale.Payments, payment)
+			return nil
+		}
+
+		return stmt.Query(scanFn, transactionID)
+	})
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &sale, storedHash, nil
+}
+
+func getVoidRecord(q database.Querier, version int, transactionID int) (*entity.VoidRecord, string, error) {
+	var (
+		void       entity.VoidRecord
+		shiftID    int
+		storedHash string
+		query      string
+		err        error
+	)
+
+	query = fmt.Sprintf("SELECT id, status, COALESCE(void_reason, ''), COALESCE(voided_by, 0), COALESCE(void_shift_id, 0), COALESCE(to_char(voided_at, %s), ''), COALESCE(void_chain_hash, '') FROM transactions WHERE id = $1", timestampFormat)
+	err = q.WithStmt(query, func(stmt *database.Stmt) error {
+		return stmt.QueryRow(transactionID).Scan(&void.TransactionID, &void.Status, &void.VoidReason, &void.VoidedBy, &shiftID, &void.VoidedAt, &storedHash)
+	})
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, "", errors.New(constants.ErrTransactionNotFound)
+	}
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	if version >= 2 {
+		void.ShiftID = &shiftID
+	}
+
+	return &void, storedHash, nil
+}
+
+func getReturnRecord(q database.Querier, version int, returnID int) (*entity.ReturnRecord, string, error) {
+	var (
+		returned     entity.ReturnRecord
+		creditAmount int
+		shiftID      int
+		storedHash   string
+		query        string
+		err          error
+	)
+
+	query = fmt.Sprintf("SELECT id, transaction_id, transaction_detail_id, quantity, refund_amount, credit_amount, COALESCE(reason, ''), COALESCE(user_id, 0), COALESCE(shift_id, 0), to_char(created_at, %s), COALESCE(chain_hash, '') FROM transaction_returns WHERE id = $1", timestampFormat)
+	err = q.WithStmt(query, func(stmt *database.Stmt) error {
+		return stmt.QueryRow(returnID).Scan(&returned.ReturnID, &returned.TransactionID, &returned.TransactionDetailID, &returned.Quantity, &returned.RefundAmount, &creditAmount, &returned.Reason, &returned.UserID, &shiftID, &returned.CreatedAt, &storedHash)
+	})
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, "", errors.New(constants.ErrReturnNotFound)
+	}
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	if version >= 2 {
+		returned.CreditAmount = &creditAmount
+		returned.ShiftID = &shiftID
+	}
+
+	return &returned, storedHash, nil
+}
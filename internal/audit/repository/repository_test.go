@@ -0,0 +1,77 @@
+package repository_test
+
+import (
+	"testing"
+
+	constants "github.com/pandusatrianura/kasir_api_service/constant"
+	"github.com/pandusatrianura/kasir_api_service/internal/audit/repository"
+	"github.com/pandusatrianura/kasir_api_service/internal/transactions/entity"
+	transactionRepository "github.com/pandusatrianura/kasir_api_service/internal/transactions/repository"
+	"github.com/pandusatrianura/kasir_api_service/pkg/database/dbtest"
+)
+
+func TestChainCoversCustomerAndKeepsVersionOneRecords(t *testing.T) {
+	db := dbtest.Open(t)
+
+	userID := dbtest.QueryInt(t, db, "INSERT INTO users (name, email, password) VALUES ('Kasir', 'kasir@example.com', 'x') RETURNING id")
+	categoryID := dbtest.QueryInt(t, db, "INSERT INTO categories (name, description) VALUES ('Makanan', '') RETURNING id")
+	productID := dbtest.QueryInt(t, db, "INSERT INTO products (name, price, stock, category_id) VALUES ('Indomie', 3000, 10, $1) RETURNING id", categoryID)
+	customerID := dbtest.QueryInt(t, db, "INSERT INTO customers (name) VALUES ('Budi') RETURNING id")
+	otherCustomerID := dbtest.QueryInt(t, db, "INSERT INTO customers (name) VALUES ('Siti') RETURNING id")
+
+	sale, err := transactionRepository.NewTransactionsRepository(db).Checkout(entity.Checkout{
+		UserID:            userID,
+		TerminalID:        "KASIR-01",
+		InvoicePrefix:     "TEST",
+		LowStockThreshold: -1,
+		CustomerID:        customerID,
+		Checkouts:         []entity.CheckoutRequest{{ProductID: productID, Quantity: 2}},
+		Payments:          []entity.PaymentRequest{{Method: constants.PaymentMethodCash, Amount: 6000}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	audit := repository.NewAuditRepository(db)
+	verify := func(want bool) {
+		t.Helper()
+
+		verification, err := audit.VerifyChain()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if verification.Valid != want {
+			t.Fatalf("chain verification = %+v, want valid %t", verification, want)
+		}
+	}
+
+	verify(true)
+
+	if _, err = db.Exec("UPDATE transactions SET customer_id = $1 WHERE id = $2", otherCustomerID, sale.Transaction.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	verify(false)
+
+	if _, err = db.Exec("UPDATE transactions SET customer_id = $1 WHERE id = $2", customerID, sale.Transaction.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// Chain the sale again as a version 1 record, the way it was hashed before the customer was covered.
+	content, _, err := repository.Canonical(db, 1, constants.ChainRecordSale, sale.Transaction.ID, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hash := repository.ChainHash(repository.GenesisHash, constants.ChainRecordSale, content)
+	if _, err = db.Exec("UPDATE audit_chain SET version = 1, hash = $1 WHERE transaction_id = $2", hash, sale.Transaction.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = db.Exec("UPDATE transactions SET chain_hash = $1 WHERE id = $2", hash, sale.Transaction.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	verify(true)
+}
@@ -0,0 +1,30 @@
+package service
+
+import (
+	"github.com/pandusatrianura/kasir_api_service/internal/audit/entity"
+	"github.com/pandusatrianura/kasir_api_service/internal/audit/repository"
+)
+
+type IAuditService interface {
+	VerifyChain() (*entity.ChainVerification, error)
+	API() entity.HealthCheck
+}
+
+type AuditService struct {
+	auditRepository repository.IAuditRepository
+}
+
+func NewAuditService(auditRepository repository.IAuditRepository) IAuditService {
+	return &AuditService{auditRepository: auditRepository}
+}
+
+func (s *AuditService) API() entity.HealthCheck {
+	return entity.HealthCheck{
+		Name:      "Audit API",
+		IsHealthy: true,
+	}
+}
+
+func (s *AuditService) VerifyChain() (*entity.ChainVerification, error) {
+	return s.auditRepository.VerifyChain()
+}
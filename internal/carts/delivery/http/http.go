@@ -0,0 +1,373 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	constants "github.com/pandusatrianura/kasir_api_service/constant"
+	"github.com/pandusatrianura/kasir_api_service/internal/carts/entity"
+	"github.com/pandusatrianura/kasir_api_service/internal/carts/service"
+	"github.com/pandusatrianura/kasir_api_service/pkg/response"
+)
+
+type CartHandler struct {
+	service service.ICartService
+}
+
+func NewCartHandler(service service.ICartService) *CartHandler {
+	return &CartHandler{service: service}
+}
+
+// API godoc
+// @Summary Get health status of carts API
+// @Description Get health status of carts API
+// @Tags carts
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 503 {object} map[string]string
+// @Router /api/transactions/carts/health [get]
+func (h *CartHandler) API(w http.ResponseWriter, r *http.Request) {
+	var result response.APIResponse
+	svcHealthCheckResult := h.service.API()
+
+	if svcHealthCheckResult.IsHealthy {
+		result.Code = strconv.Itoa(constants.SuccessCode)
+		result.Message = fmt.Sprintf("%s is healthy", svcHealthCheckResult.Name)
+		response.WriteJSONResponse(w, http.StatusOK, result)
+		return
+	}
+
+	result.Code = strconv.Itoa(constants.ErrorCode)
+	result.Message = fmt.Sprintf("%s is not healthy", svcHealthCheckResult.Name)
+	response.WriteJSONResponse(w, http.StatusServiceUnavailable, result)
+	return
+}
+
+// CreateCart godoc
+// @Summary Create a cart
+// @Description Open a new cart for the logged in cashier
+// @Tags carts
+// @Accept json
+// @Produce json
+// @Param X-API-Key header string true "your-secret-api-key-here"
+// @Param Authorization header string true "Bearer <token>"
+// @Param cart body entity.RequestCart false "Cart Data"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/transactions/carts [post]
+func (h *CartHandler) CreateCart(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		response.Error(w, http.StatusMethodNotAllowed, constants.ErrorCode, constants.ErrInvalidMethod, nil)
+		return
+	}
+
+	role := r.Header.Get("X-User-Roles")
+	if role != constants.KasirRole {
+		response.Error(w, http.StatusUnauthorized, constants.ErrorCode, constants.ErrRoleNotAuthorized, errors.New(fmt.Sprintf("%s", role)))
+		return
+	}
+
+	var request entity.RequestCart
+	if r.ContentLength != 0 {
+		if err := response.ParseJSON(r, &request); err != nil {
+			response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidCartRequest, err)
+			return
+		}
+	}
+
+	request.UserID, _ = strconv.Atoi(r.Header.Get("X-User-ID"))
+
+	cart, err := h.service.CreateCart(&request)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, constants.ErrorCode, "Cart created failed", err)
+		return
+	}
+
+	response.Success(w, http.StatusCreated, constants.SuccessCode, "Cart created successfully", cart)
+}
+
+// GetHeldCarts godoc
+// @Summary Get held carts
+// @Description Get the carts held by the logged in cashier
+// @Tags carts
+// @Accept json
+// @Produce json
+// @Param X-API-Key header string true "your-secret-api-key-here"
+// @Param Authorization header string true "Bearer <token>"
+// @Success 200 {object} map[string]interface{}
+// @Failure 500 {object} map[string]string
+// @Router /api/transactions/carts [get]
+func (h *CartHandler) GetHeldCarts(w http.ResponseWriter, r *http.Request) {
+	role := r.Header.Get("X-User-Roles")
+	if role == "" {
+		response.Error(w, http.StatusUnauthorized, constants.ErrorCode, constants.ErrRoleNotAuthorized, errors.New(fmt.Sprintf("%s", role)))
+		return
+	}
+
+	carts, err := h.service.GetHeldCarts(actorFromRequest(r))
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, constants.ErrorCode, "Carts retrieved failed", err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, constants.SuccessCode, "Carts retrieved successfully", carts)
+}
+
+// GetCartByID godoc
+// @Summary Get a cart by ID
+// @Description Get a cart and its items by ID
+// @Tags carts
+// @Accept json
+// @Produce json
+// @Param X-API-Key header string true "your-secret-api-key-here"
+// @Param Authorization header string true "Bearer <token>"
+// @Param id path int true "Cart ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/transactions/carts/{id} [get]
+func (h *CartHandler) GetCartByID(w http.ResponseWriter, r *http.Request) {
+	role := r.Header.Get("X-User-Roles")
+	if role == "" {
+		response.Error(w, http.StatusUnauthorized, constants.ErrorCode, constants.ErrRoleNotAuthorized, errors.New(fmt.Sprintf("%s", role)))
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidCartID, err)
+		return
+	}
+
+	cart, err := h.service.GetCartByID(id, actorFromRequest(r))
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, constants.ErrorCode, "Cart retrieved failed", err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, constants.SuccessCode, "Cart retrieved successfully", cart)
+}
+
+// AddItem godoc
+// @Summary Add a product to a cart
+// @Description Add a product to an open cart, increasing the quantity when the product is already in the cart
+// @Tags carts
+// @Accept json
+// @Produce json
+// @Param X-API-Key header string true "your-secret-api-key-here"
+// @Param Authorization header string true "Bearer <token>"
+// @Param id path int true "Cart ID"
+// @Param item body entity.RequestCartItem true "Cart Item Data"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/transactions/carts/{id}/items [post]
+func (h *CartHandler) AddItem(w http.ResponseWriter, r *http.Request) {
+	role := r.Header.Get("X-User-Roles")
+	if role != constants.KasirRole {
+		response.Error(w, http.StatusUnauthorized, constants.ErrorCode, constants.ErrRoleNotAuthorized, errors.New(fmt.Sprintf("%s", role)))
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidCartID, err)
+		return
+	}
+
+	var request entity.RequestCartItem
+	if err = response.ParseJSON(r, &request); err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidCartRequest, err)
+		return
+	}
+
+	cart, err := h.service.AddItem(id, &request, actorFromRequest(r))
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, constants.ErrorCode, "Cart item added failed", err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, constants.SuccessCode, "Cart item added successfully", cart)
+}
+
+// RemoveItem godoc
+// @Summary Remove a product from a cart
+// @Description Remove a product line, or the line of one of its variants or units, from an open cart
+// @Tags carts
+// @Accept json
+// @Produce json
+// @Param X-API-Key header string true "your-secret-api-key-here"
+// @Param Authorization header string true "Bearer <token>"
+// @Param id path int true "Cart ID"
+// @Param product_id path int true "Product ID"
+// @Param variant_id query int false "Variant ID"
+// @Param unit query string false "Unit, the base unit of the product when empty"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/transactions/carts/{id}/items/{product_id} [delete]
+func (h *CartHandler) RemoveItem(w http.ResponseWriter, r *http.Request) {
+	role := r.Header.Get("X-User-Roles")
+	if role != constants.KasirRole {
+		response.Error(w, http.StatusUnauthorized, constants.ErrorCode, constants.ErrRoleNotAuthorized, errors.New(fmt.Sprintf("%s", role)))
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidCartID, err)
+		return
+	}
+
+	productID, err := strconv.Atoi(chi.URLParam(r, "product_id"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidProductID, err)
+		return
+	}
+
+	variantID := 0
+	if value := r.URL.Query().Get("variant_id"); value != "" {
+		variantID, err = strconv.Atoi(value)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidVariantID, err)
+			return
+		}
+	}
+
+	cart, err := h.service.RemoveItem(id, productID, variantID, r.URL.Query().Get("unit"), actorFromRequest(r))
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, constants.ErrorCode, "Cart item removed failed", err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, constants.SuccessCode, "Cart item removed successfully", cart)
+}
+
+// HoldCart godoc
+// @Summary Hold a cart
+// @Description Park an open cart so the cashier can serve the next customer
+// @Tags carts
+// @Accept json
+// @Produce json
+// @Param X-API-Key header string true "your-secret-api-key-here"
+// @Param Authorization header string true "Bearer <token>"
+// @Param id path int true "Cart ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/transactions/carts/{id}/hold [post]
+func (h *CartHandler) HoldCart(w http.ResponseWriter, r *http.Request) {
+	role := r.Header.Get("X-User-Roles")
+	if role != constants.KasirRole {
+		response.Error(w, http.StatusUnauthorized, constants.ErrorCode, constants.ErrRoleNotAuthorized, errors.New(fmt.Sprintf("%s", role)))
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidCartID, err)
+		return
+	}
+
+	cart, err := h.service.HoldCart(id, actorFromRequest(r))
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, constants.ErrorCode, "Cart held failed", err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, constants.SuccessCode, "Cart held successfully", cart)
+}
+
+// ResumeCart godoc
+// @Summary Resume a held cart
+// @Description Reopen a held cart so items can be added or removed again
+// @Tags carts
+// @Accept json
+// @Produce json
+// @Param X-API-Key header string true "your-secret-api-key-here"
+// @Param Authorization header string true "Bearer <token>"
+// @Param id path int true "Cart ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/transactions/carts/{id}/resume [post]
+func (h *CartHandler) ResumeCart(w http.ResponseWriter, r *http.Request) {
+	role := r.Header.Get("X-User-Roles")
+	if role != constants.KasirRole {
+		response.Error(w, http.StatusUnauthorized, constants.ErrorCode, constants.ErrRoleNotAuthorized, errors.New(fmt.Sprintf("%s", role)))
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidCartID, err)
+		return
+	}
+
+	cart, err := h.service.ResumeCart(id, actorFromRequest(r))
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, constants.ErrorCode, "Cart resumed failed", err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, constants.SuccessCode, "Cart resumed successfully", cart)
+}
+
+// CheckoutCart godoc
+// @Summary Checkout a cart
+// @Description Convert an open or held cart into a transaction using the regular checkout flow
+// @Tags carts
+// @Accept json
+// @Produce json
+// @Param X-API-Key header string true "your-secret-api-key-here"
+// @Param Authorization header string true "Bearer <token>"
+// @Param X-Terminal-ID header string false "Terminal (register) the sale is rung up on"
+// @Param id path int true "Cart ID"
+// @Param checkout body entity.RequestCartCheckout true "Payment Data"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/transactions/carts/{id}/checkout [post]
+func (h *CartHandler) CheckoutCart(w http.ResponseWriter, r *http.Request) {
+	role := r.Header.Get("X-User-Roles")
+	if role != constants.KasirRole {
+		response.Error(w, http.StatusUnauthorized, constants.ErrorCode, constants.ErrRoleNotAuthorized, errors.New(fmt.Sprintf("%s", role)))
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidCartID, err)
+		return
+	}
+
+	var request entity.RequestCartCheckout
+	if err = response.ParseJSON(r, &request); err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidCheckoutRequest, err)
+		return
+	}
+
+	request.TerminalID = strings.TrimSpace(r.Header.Get("X-Terminal-ID"))
+
+	resp, err := h.service.CheckoutCart(id, &request, actorFromRequest(r))
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, constants.ErrorCode, "Checkout created failed", err)
+		return
+	}
+
+	response.Success(w, http.StatusCreated, constants.SuccessCode, "Checkout created successfully", resp)
+}
+
+func actorFromRequest(r *http.Request) entity.Actor {
+	userID, _ := strconv.Atoi(r.Header.Get("X-User-ID"))
+	return entity.Actor{
+		UserID:  userID,
+		Manager: r.Header.Get("X-User-Roles") == constants.ManagerRole,
+	}
+}
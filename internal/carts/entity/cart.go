@@ -0,0 +1,59 @@
+package entity
+
+import transactionEntity "github.com/pandusatrianura/kasir_api_service/internal/transactions/entity"
+
+type HealthCheck struct {
+	Name      string `json:"name"`
+	IsHealthy bool   `json:"is_healthy"`
+}
+
+type Cart struct {
+	ID            int        `json:"id"`
+	UserID        int        `json:"user_id"`
+	Status        string     `json:"status"`
+	Note          string     `json:"note"`
+	TransactionID int        `json:"transaction_id,omitempty"`
+	TotalAmount   int        `json:"total_amount"`
+	Items         []CartItem `json:"items"`
+	HeldAt        *string    `json:"held_at,omitempty"`
+	CreatedAt     string     `json:"created_at,omitempty"`
+	UpdatedAt     string     `json:"updated_at,omitempty"`
+}
+
+type CartItem struct {
+	ID          int    `json:"id"`
+	ProductID   int    `json:"product_id"`
+	VariantID   int    `json:"variant_id,omitempty"`
+	ProductName string `json:"product_name"`
+	Unit        string `json:"unit"`
+	Price       int    `json:"price"`
+	Quantity    int    `json:"quantity"`
+	Subtotal    int    `json:"subtotal"`
+}
+
+type RequestCart struct {
+	UserID int    `json:"-"`
+	Note   string `json:"note"`
+}
+
+// RequestCartItem adds a product to a cart; a product with variants is added per variant. Quantity is counted in
+// Unit, the base unit of the product when it is empty.
+type RequestCartItem struct {
+	ProductID int    `json:"product_id"`
+	VariantID int    `json:"variant_id,omitempty"`
+	Unit      string `json:"unit,omitempty"`
+	Quantity  int    `json:"quantity"`
+}
+
+type RequestCartCheckout struct {
+	TerminalID   string                             `json:"-"`
+	CustomerID   int                                `json:"customer_id,omitempty"`
+	RedeemPoints int                                `json:"redeem_points,omitempty"`
+	Payments     []transactionEntity.PaymentRequest `json:"payments"`
+}
+
+// Actor is the cashier working on a cart; managers may work on every cashier's carts.
+type Actor struct {
+	UserID  int
+	Manager bool
+}
@@ -0,0 +1,311 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	constants "github.com/pandusatrianura/kasir_api_service/constant"
+	"github.com/pandusatrianura/kasir_api_service/internal/carts/entity"
+	"github.com/pandusatrianura/kasir_api_service/pkg/database"
+)
+
+const cartColumns = "id, user_id, status, COALESCE(note, ''), COALESCE(transaction_id, 0), held_at, created_at, updated_at"
+
+type ICartRepository interface {
+	CreateCart(request *entity.RequestCart) (int, error)
+	GetCartByID(id int) (*entity.Cart, error)
+	GetCartsByUserID(userID int, status string) ([]entity.Cart, error)
+	AddItem(cartID int, request *entity.RequestCartItem) error
+	RemoveItem(cartID int, productID int, variantID int, unit string) error
+	UpdateStatus(id int, from string, to string) error
+	MarkCheckedOut(id int, transactionID int) error
+	ExpireStaleCarts(expiry time.Duration) error
+}
+
+type CartRepository struct {
+	db *database.DB
+}
+
+func NewCartRepository(db *database.DB) ICartRepository {
+	return &CartRepository{db: db}
+}
+
+func (c *CartRepository) CreateCart(request *entity.RequestCart) (int, error) {
+	var (
+		cartID int
+		query  string
+		err    error
+	)
+
+	query = "INSERT INTO carts (user_id, status, note, created_at, updated_at) VALUES ($1, $2, $3, $4, $5) RETURNING id"
+	err = c.db.WithStmt(query, func(stmt *database.Stmt) error {
+		return stmt.QueryRow(request.UserID, constants.CartStatusOpen, request.Note, "now()", "now()").Scan(&cartID)
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return cartID, nil
+}
+
+func (c *CartRepository) GetCartByID(id int) (*entity.Cart, error) {
+	var (
+		cart  entity.Cart
+		query string
+		err   error
+	)
+
+	query = fmt.Sprintf("SELECT %s FROM carts WHERE id = $1", cartColumns)
+	err = c.db.WithStmt(query, func(stmt *database.Stmt) error {
+		scanFn := func(rows *database.Rows) error {
+			return scanCart(rows, &cart)
+		}
+
+		return stmt.Query(scanFn, id)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if cart.ID == 0 {
+		return nil, errors.New(constants.ErrCartNotFound)
+	}
+
+	if err = c.loadItems(&cart); err != nil {
+		return nil, err
+	}
+
+	return &cart, nil
+}
+
+func (c *CartRepository) GetCartsByUserID(userID int, status string) ([]entity.Cart, error) {
+	var (
+		carts []entity.Cart
+		query string
+		err   error
+	)
+
+	carts = make([]entity.Cart, 0)
+
+	query = fmt.Sprintf("SELECT %s FROM carts WHERE user_id = $1 AND status = $2 ORDER BY updated_at DESC, id DESC", cartColumns)
+	err = c.db.WithStmt(query, func(stmt *database.Stmt) error {
+		scanFn := func(rows *database.Rows) error {
+			var cart entity.Cart
+			if err := scanCart(rows, &cart); err != nil {
+				return err
+			}
+
+			carts = append(carts, cart)
+			return nil
+		}
+
+		return stmt.Query(scanFn, userID, status)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range carts {
+		if err = c.loadItems(&carts[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return carts, nil
+}
+
+func (c *CartRepository) AddItem(cartID int, request *entity.RequestCartItem) error {
+	var (
+		productID   int
+		variantID   int
+		hasVariants bool
+		baseUnit    string
+		hasUnit     bool
+		query       string
+		err         error
+	)
+
+	query = "SELECT products.id, COALESCE((SELECT id FROM product_variants WHERE product_variants.id = $2 AND product_variants.product_id = products.id), 0), EXISTS (SELECT 1 FROM product_variants WHERE product_variants.product_id = products.id), products.base_unit, EXISTS (SELECT 1 FROM product_units WHERE product_units.product_id = products.id AND product_units.name = $3) FROM products WHERE products.id = $1"
+	err = c.db.WithStmt(query, func(stmt *database.Stmt) error {
+		scanFn := func(rows *database.Rows) error {
+			return rows.Scan(&productID, &variantID, &hasVariants, &baseUnit, &hasUnit)
+		}
+
+		return stmt.Query(scanFn, request.ProductID, request.VariantID, request.Unit)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if productID == 0 {
+		return errors.New(constants.ErrProductNotFound)
+	}
+
+	if request.VariantID != 0 && variantID == 0 {
+		return errors.New(constants.ErrVariantNotFound)
+	}
+
+	if request.VariantID == 0 && hasVariants {
+		return errors.New(constants.ErrVariantRequired)
+	}
+
+	// Lines in the base unit are stored without a unit so the same product added with and without it is one line.
+	unit := request.Unit
+	if unit == baseUnit {
+		unit = ""
+	}
+
+	if unit != "" && !hasUnit {
+		return errors.New(constants.ErrUnitNotFound)
+	}
+
+	return c.db.WithTx(func(tx *database.Tx) error {
+		query = "INSERT INTO cart_items (cart_id, product_id, variant_id, unit, quantity, created_at, updated_at) VALUES ($1, $2, NULLIF($3, 0), $4, $5, $6, $7) ON CONFLICT (cart_id, product_id, (COALESCE(variant_id, 0)), unit) DO UPDATE SET quantity = cart_items.quantity + EXCLUDED.quantity, updated_at = EXCLUDED.updated_at"
+		err = tx.WithStmt(query, func(stmt *database.Stmt) error {
+			_, err := stmt.Exec(cartID, request.ProductID, request.VariantID, unit, request.Quantity, "now()", "now()")
+			return err
+		})
+
+		if err != nil {
+			return err
+		}
+
+		return touchCart(tx, cartID)
+	})
+}
+
+func (c *CartRepository) RemoveItem(cartID int, productID int, variantID int, unit string) error {
+	var (
+		query string
+		err   error
+	)
+
+	return c.db.WithTx(func(tx *database.Tx) error {
+		query = "DELETE FROM cart_items WHERE cart_id = $1 AND product_id = $2 AND COALESCE(variant_id, 0) = $3 AND (unit = $4 OR (unit = '' AND $4 = (SELECT base_unit FROM products WHERE id = $2)))"
+		err = tx.WithStmt(query, func(stmt *database.Stmt) error {
+			result, err := stmt.Exec(cartID, productID, variantID, unit)
+			if err != nil {
+				return err
+			}
+
+			affected, err := result.RowsAffected()
+			if err != nil {
+				return err
+			}
+
+			if affected == 0 {
+				return errors.New(constants.ErrProductNotFound)
+			}
+
+			return nil
+		})
+
+		if err != nil {
+			return err
+		}
+
+		return touchCart(tx, cartID)
+	})
+}
+
+// UpdateStatus moves a cart from one status to another, failing when the cart is no longer in the expected status.
+func (c *CartRepository) UpdateStatus(id int, from string, to string) error {
+	var (
+		query string
+		err   error
+	)
+
+	query = "UPDATE carts SET status = $1, held_at = CASE WHEN $5 THEN now() ELSE held_at END, updated_at = $2 WHERE id = $3 AND status = $4"
+	err = c.db.WithStmt(query, func(stmt *database.Stmt) error {
+		result, err := stmt.Exec(to, "now()", id, from, to == constants.CartStatusHeld)
+		if err != nil {
+			return err
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if affected == 0 {
+			return errors.New(statusMismatch(from))
+		}
+
+		return nil
+	})
+
+	return err
+}
+
+func (c *CartRepository) MarkCheckedOut(id int, transactionID int) error {
+	query := "UPDATE carts SET status = $1, transaction_id = $2, updated_at = $3 WHERE id = $4"
+	return c.db.WithStmt(query, func(stmt *database.Stmt) error {
+		_, err := stmt.Exec(constants.CartStatusCheckedOut, transactionID, "now()", id)
+		return err
+	})
+}
+
+// ExpireStaleCarts expires open and held carts that have not been touched within the expiry window.
+func (c *CartRepository) ExpireStaleCarts(expiry time.Duration) error {
+	query := "UPDATE carts SET status = $1, updated_at = now() WHERE status IN ($2, $3) AND updated_at < now() - $4::interval"
+	return c.db.WithStmt(query, func(stmt *database.Stmt) error {
+		_, err := stmt.Exec(constants.CartStatusExpired, constants.CartStatusOpen, constants.CartStatusHeld, fmt.Sprintf("%d seconds", int64(expiry.Seconds())))
+		return err
+	})
+}
+
+func (c *CartRepository) loadItems(cart *entity.Cart) error {
+	var (
+		query string
+		err   error
+	)
+
+	cart.Items = make([]entity.CartItem, 0)
+
+	query = "SELECT cart_items.id, cart_items.product_id, COALESCE(cart_items.variant_id, 0), products.name || COALESCE(' (' || product_variants.name || ')', ''), COALESCE(NULLIF(cart_items.unit, ''), products.base_unit), COALESCE(product_variants.price * product_units.factor, product_units.price, product_variants.price, products.price), cart_items.quantity FROM cart_items JOIN products ON cart_items.product_id = products.id LEFT JOIN product_variants ON cart_items.variant_id = product_variants.id LEFT JOIN product_units ON product_units.product_id = cart_items.product_id AND product_units.name = cart_items.unit WHERE cart_items.cart_id = $1 ORDER BY cart_items.id"
+	err = c.db.WithStmt(query, func(stmt *database.Stmt) error {
+		scanFn := func(rows *database.Rows) error {
+			var item entity.CartItem
+			if err := rows.Scan(&item.ID, &item.ProductID, &item.VariantID, &item.ProductName, &item.Unit, &item.Price, &item.Quantity); err != nil {
+				return err
+			}
+
+			item.Subtotal = item.Price * item.Quantity
+			cart.TotalAmount += item.Subtotal
+			cart.Items = append(cart.Items, item)
+			return nil
+		}
+
+		return stmt.Query(scanFn, cart.ID)
+	})
+
+	return err
+}
+
+func statusMismatch(expected string) string {
+	switch expected {
+	case constants.CartStatusOpen:
+		return constants.ErrCartNotOpen
+	case constants.CartStatusHeld:
+		return constants.ErrCartNotHeld
+	default:
+		return constants.ErrCartClosed
+	}
+}
+
+func touchCart(tx *database.Tx, cartID int) error {
+	query := "UPDATE carts SET updated_at = $1 WHERE id = $2"
+	return tx.WithStmt(query, func(stmt *database.Stmt) error {
+		_, err := stmt.Exec("now()", cartID)
+		return err
+	})
+}
+
+func scanCart(rows *database.Rows, cart *entity.Cart) error {
+	return rows.Scan(&cart.ID, &cart.UserID, &cart.Status, &cart.Note, &cart.TransactionID, &cart.HeldAt, &cart.CreatedAt, &cart.UpdatedAt)
+}
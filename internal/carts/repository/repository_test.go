@@ -0,0 +1,41 @@
+package repository_test
+
+import (
+	"testing"
+
+	"github.com/pandusatrianura/kasir_api_service/internal/carts/entity"
+	"github.com/pandusatrianura/kasir_api_service/internal/carts/repository"
+	"github.com/pandusatrianura/kasir_api_service/pkg/database/dbtest"
+)
+
+func TestVariantInCartIsPricedByTheCarton(t *testing.T) {
+	const cartonPrice = 3500 * 40
+
+	db := dbtest.Open(t)
+
+	userID := dbtest.QueryInt(t, db, "INSERT INTO users (name, email, password) VALUES ('Kasir', 'kasir@example.com', 'x') RETURNING id")
+	categoryID := dbtest.QueryInt(t, db, "INSERT INTO categories (name, description) VALUES ('Makanan', '') RETURNING id")
+	productID := dbtest.QueryInt(t, db, "INSERT INTO products (name, price, stock, category_id, variant_axes) VALUES ('Indomie', 3000, 100, $1, '[\"flavor\"]') RETURNING id", categoryID)
+	variantID := dbtest.QueryInt(t, db, "INSERT INTO product_variants (product_id, name, options, price, stock) VALUES ($1, 'Pedas', '{\"flavor\": \"Pedas\"}', 3500, 100) RETURNING id", productID)
+	dbtest.QueryInt(t, db, "INSERT INTO product_units (product_id, name, factor, price) VALUES ($1, 'dus', 40, 100000) RETURNING id", productID)
+
+	repo := repository.NewCartRepository(db)
+
+	cartID, err := repo.CreateCart(&entity.RequestCart{UserID: userID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = repo.AddItem(cartID, &entity.RequestCartItem{ProductID: productID, VariantID: variantID, Unit: "dus", Quantity: 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	cart, err := repo.GetCartByID(cartID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(cart.Items) != 1 || cart.Items[0].Price != cartonPrice || cart.TotalAmount != 2*cartonPrice {
+		t.Fatalf("cart = %+v, want a price of %d and a total of %d", cart, cartonPrice, 2*cartonPrice)
+	}
+}
@@ -0,0 +1,229 @@
+package service
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	constants "github.com/pandusatrianura/kasir_api_service/constant"
+	"github.com/pandusatrianura/kasir_api_service/internal/carts/entity"
+	"github.com/pandusatrianura/kasir_api_service/internal/carts/repository"
+	transactionEntity "github.com/pandusatrianura/kasir_api_service/internal/transactions/entity"
+	transactionService "github.com/pandusatrianura/kasir_api_service/internal/transactions/service"
+	"github.com/spf13/viper"
+)
+
+const defaultCartExpiry = 24 * time.Hour
+
+type ICartService interface {
+	CreateCart(request *entity.RequestCart) (*entity.Cart, error)
+	GetCartByID(id int, actor entity.Actor) (*entity.Cart, error)
+	GetHeldCarts(actor entity.Actor) ([]entity.Cart, error)
+	AddItem(id int, request *entity.RequestCartItem, actor entity.Actor) (*entity.Cart, error)
+	RemoveItem(id int, productID int, variantID int, unit string, actor entity.Actor) (*entity.Cart, error)
+	HoldCart(id int, actor entity.Actor) (*entity.Cart, error)
+	ResumeCart(id int, actor entity.Actor) (*entity.Cart, error)
+	CheckoutCart(id int, request *entity.RequestCartCheckout, actor entity.Actor) (*transactionEntity.CheckoutResponse, error)
+	API() entity.HealthCheck
+}
+
+type CartService struct {
+	cartRepository      repository.ICartRepository
+	transactionsService transactionService.ITransactionsService
+}
+
+func NewCartService(cartRepository repository.ICartRepository, transactionsService transactionService.ITransactionsService) ICartService {
+	return &CartService{
+		cartRepository:      cartRepository,
+		transactionsService: transactionsService,
+	}
+}
+
+func (s *CartService) API() entity.HealthCheck {
+	return entity.HealthCheck{
+		Name:      "Carts API",
+		IsHealthy: true,
+	}
+}
+
+func (s *CartService) CreateCart(request *entity.RequestCart) (*entity.Cart, error) {
+	request.Note = strings.TrimSpace(request.Note)
+
+	id, err := s.cartRepository.CreateCart(request)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.cartRepository.GetCartByID(id)
+}
+
+func (s *CartService) GetCartByID(id int, actor entity.Actor) (*entity.Cart, error) {
+	s.expireStaleCarts()
+	return s.getOwnedCart(id, actor)
+}
+
+func (s *CartService) GetHeldCarts(actor entity.Actor) ([]entity.Cart, error) {
+	s.expireStaleCarts()
+	return s.cartRepository.GetCartsByUserID(actor.UserID, constants.CartStatusHeld)
+}
+
+func (s *CartService) AddItem(id int, request *entity.RequestCartItem, actor entity.Actor) (*entity.Cart, error) {
+	if request.ProductID <= 0 {
+		return nil, errors.New(constants.ErrProductNotFound)
+	}
+
+	if request.Quantity <= 0 {
+		return nil, errors.New(constants.ErrInvalidCartQuantity)
+	}
+
+	if _, err := s.getOpenCart(id, actor); err != nil {
+		return nil, err
+	}
+
+	request.Unit = strings.ToLower(strings.TrimSpace(request.Unit))
+	if err := s.cartRepository.AddItem(id, request); err != nil {
+		return nil, err
+	}
+
+	return s.cartRepository.GetCartByID(id)
+}
+
+func (s *CartService) RemoveItem(id int, productID int, variantID int, unit string, actor entity.Actor) (*entity.Cart, error) {
+	if _, err := s.getOpenCart(id, actor); err != nil {
+		return nil, err
+	}
+
+	if err := s.cartRepository.RemoveItem(id, productID, variantID, strings.ToLower(strings.TrimSpace(unit))); err != nil {
+		return nil, err
+	}
+
+	return s.cartRepository.GetCartByID(id)
+}
+
+func (s *CartService) HoldCart(id int, actor entity.Actor) (*entity.Cart, error) {
+	cart, err := s.getOpenCart(id, actor)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(cart.Items) == 0 {
+		return nil, errors.New(constants.ErrCartEmpty)
+	}
+
+	if err = s.cartRepository.UpdateStatus(id, constants.CartStatusOpen, constants.CartStatusHeld); err != nil {
+		return nil, err
+	}
+
+	return s.cartRepository.GetCartByID(id)
+}
+
+func (s *CartService) ResumeCart(id int, actor entity.Actor) (*entity.Cart, error) {
+	s.expireStaleCarts()
+
+	if _, err := s.getOwnedCart(id, actor); err != nil {
+		return nil, err
+	}
+
+	if err := s.cartRepository.UpdateStatus(id, constants.CartStatusHeld, constants.CartStatusOpen); err != nil {
+		return nil, err
+	}
+
+	return s.cartRepository.GetCartByID(id)
+}
+
+// CheckoutCart turns an open or held cart into a transaction through the regular checkout flow.
+// The cart is claimed first so that two terminals cannot check out the same cart twice.
+func (s *CartService) CheckoutCart(id int, request *entity.RequestCartCheckout, actor entity.Actor) (*transactionEntity.CheckoutResponse, error) {
+	s.expireStaleCarts()
+
+	cart, err := s.getOwnedCart(id, actor)
+	if err != nil {
+		return nil, err
+	}
+
+	if cart.Status != constants.CartStatusOpen && cart.Status != constants.CartStatusHeld {
+		return nil, errors.New(constants.ErrCartClosed)
+	}
+
+	if len(cart.Items) == 0 {
+		return nil, errors.New(constants.ErrCartEmpty)
+	}
+
+	if err = s.cartRepository.UpdateStatus(id, cart.Status, constants.CartStatusCheckedOut); err != nil {
+		return nil, err
+	}
+
+	checkout := transactionEntity.Checkout{
+		UserID:       actor.UserID,
+		TerminalID:   request.TerminalID,
+		CustomerID:   request.CustomerID,
+		RedeemPoints: request.RedeemPoints,
+		Payments:     request.Payments,
+	}
+
+	for _, item := range cart.Items {
+		checkout.Checkouts = append(checkout.Checkouts, transactionEntity.CheckoutRequest{
+			ProductID: item.ProductID,
+			VariantID: item.VariantID,
+			Unit:      item.Unit,
+			Quantity:  item.Quantity,
+		})
+	}
+
+	response, err := s.transactionsService.Checkout(checkout)
+	if err != nil {
+		if revertErr := s.cartRepository.UpdateStatus(id, constants.CartStatusCheckedOut, cart.Status); revertErr != nil {
+			log.Printf("Failed to release cart %d after checkout error: %v", id, revertErr)
+		}
+
+		return nil, err
+	}
+
+	if err = s.cartRepository.MarkCheckedOut(id, response.Transaction.ID); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+func (s *CartService) getOpenCart(id int, actor entity.Actor) (*entity.Cart, error) {
+	s.expireStaleCarts()
+
+	cart, err := s.getOwnedCart(id, actor)
+	if err != nil {
+		return nil, err
+	}
+
+	if cart.Status != constants.CartStatusOpen {
+		return nil, errors.New(constants.ErrCartNotOpen)
+	}
+
+	return cart, nil
+}
+
+// getOwnedCart loads a cart, hiding carts of other cashiers unless the actor is a manager.
+func (s *CartService) getOwnedCart(id int, actor entity.Actor) (*entity.Cart, error) {
+	cart, err := s.cartRepository.GetCartByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !actor.Manager && cart.UserID != actor.UserID {
+		return nil, errors.New(constants.ErrCartNotFound)
+	}
+
+	return cart, nil
+}
+
+// expireStaleCarts lazily expires carts left untouched for longer than CART_EXPIRY (24h by default).
+func (s *CartService) expireStaleCarts() {
+	expiry := viper.GetDuration("CART_EXPIRY")
+	if expiry <= 0 {
+		expiry = defaultCartExpiry
+	}
+
+	if err := s.cartRepository.ExpireStaleCarts(expiry); err != nil {
+		log.Printf("Failed to expire stale carts: %v", err)
+	}
+}
@@ -6,19 +6,25 @@ type Category struct {
 	ID          int64
 	Name        string
 	Description string
+	TaxRate     *float64
+	TaxExempt   bool
 	CreatedAt   string
 	UpdatedAt   string
 }
 
 type RequestCategory struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	TaxRate     *float64 `json:"tax_rate,omitempty"`
+	TaxExempt   bool     `json:"tax_exempt"`
 }
 
 type ResponseCategory struct {
 	ID          int64     `json:"id"`
 	Name        string    `json:"name"`
 	Description string    `json:"description"`
+	TaxRate     *float64  `json:"tax_rate,omitempty"`
+	TaxExempt   bool      `json:"tax_exempt"`
 	CreatedAt   time.Time `json:"created_at,omitempty"`
 	UpdatedAt   time.Time `json:"updated_at,omitempty"`
 }
@@ -31,11 +31,11 @@ func (r *CategoryRepository) CreateCategory(category *entity.Category) error {
 		query string
 	)
 
-	query = "INSERT INTO categories (name, description, created_at, updated_at) VALUES ($1, $2, $3, $4)"
+	query = "INSERT INTO categories (name, description, tax_rate, tax_exempt, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)"
 
 	err = r.db.WithTx(func(tx *database.Tx) error {
 		return tx.WithStmt(query, func(stmt *database.Stmt) error {
-			_, err = stmt.Exec(category.Name, category.Description, "now()", "now()")
+			_, err = stmt.Exec(category.Name, category.Description, category.TaxRate, category.TaxExempt, "now()", "now()")
 			return err
 		})
 	})
@@ -53,11 +53,11 @@ func (r *CategoryRepository) UpdateCategory(id int64, category *entity.Category)
 		query string
 	)
 
-	query = "UPDATE categories SET name = $1, description = $2, updated_at = $3 WHERE id = $4"
+	query = "UPDATE categories SET name = $1, description = $2, tax_rate = $3, tax_exempt = $4, updated_at = $5 WHERE id = $6"
 
 	err = r.db.WithTx(func(tx *database.Tx) error {
 		return tx.WithStmt(query, func(stmt *database.Stmt) error {
-			_, err = stmt.Exec(category.Name, category.Description, "now()", id)
+			_, err = stmt.Exec(category.Name, category.Description, category.TaxRate, category.TaxExempt, "now()", id)
 			return err
 
 		})
@@ -101,11 +101,11 @@ func (r *CategoryRepository) GetCategoryByID(id int64) (*entity.ResponseCategory
 		query        string
 	)
 
-	query = "SELECT id, name, description, created_at, updated_at FROM categories WHERE id = $1"
+	query = "SELECT id, name, description, tax_rate, tax_exempt, created_at, updated_at FROM categories WHERE id = $1"
 
 	err = r.db.WithStmt(query, func(stmt *database.Stmt) error {
 		scanFn := func(rows *database.Rows) error {
-			return rows.Scan(&category.ID, &category.Name, &category.Description, &category.CreatedAt, &category.UpdatedAt)
+			return rows.Scan(&category.ID, &category.Name, &category.Description, &category.TaxRate, &category.TaxExempt, &category.CreatedAt, &category.UpdatedAt)
 		}
 
 		return stmt.Query(scanFn, id)
@@ -126,6 +126,8 @@ func (r *CategoryRepository) GetCategoryByID(id int64) (*entity.ResponseCategory
 		ID:          category.ID,
 		Name:        category.Name,
 		Description: category.Description,
+		TaxRate:     category.TaxRate,
+		TaxExempt:   category.TaxExempt,
 		CreatedAt:   createdAt,
 		UpdatedAt:   updatedAt,
 	}
@@ -142,12 +144,12 @@ func (r *CategoryRepository) GetAllCategories() ([]entity.ResponseCategory, erro
 
 	categories = make([]entity.Category, 0)
 
-	query = "SELECT id, name, description, created_at, updated_at FROM categories"
+	query = "SELECT id, name, description, tax_rate, tax_exempt, created_at, updated_at FROM categories"
 
 	err = r.db.WithStmt(query, func(stmt *database.Stmt) error {
 		scanFn := func(rows *database.Rows) error {
 			var category entity.Category
-			if err := rows.Scan(&category.ID, &category.Name, &category.Description, &category.CreatedAt, &category.UpdatedAt); err != nil {
+			if err := rows.Scan(&category.ID, &category.Name, &category.Description, &category.TaxRate, &category.TaxExempt, &category.CreatedAt, &category.UpdatedAt); err != nil {
 				return err
 			}
 
@@ -171,6 +173,8 @@ func (r *CategoryRepository) GetAllCategories() ([]entity.ResponseCategory, erro
 			ID:          category.ID,
 			Name:        category.Name,
 			Description: category.Description,
+			TaxRate:     category.TaxRate,
+			TaxExempt:   category.TaxExempt,
 			CreatedAt:   createdAt,
 			UpdatedAt:   updatedAt,
 		}
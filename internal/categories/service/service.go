@@ -32,9 +32,15 @@ func (s *CategoryService) API() entity.HealthCheck {
 }
 
 func (s *CategoryService) CreateCategory(requestCategory *entity.RequestCategory) error {
+	if requestCategory.TaxRate != nil && (*requestCategory.TaxRate < 0 || *requestCategory.TaxRate > 100) {
+		return errors.New("tax rate must be between 0 and 100")
+	}
+
 	category := &entity.Category{
 		Name:        requestCategory.Name,
 		Description: requestCategory.Description,
+		TaxRate:     requestCategory.TaxRate,
+		TaxExempt:   requestCategory.TaxExempt,
 	}
 	return s.categoryRepository.CreateCategory(category)
 }
@@ -45,9 +51,15 @@ func (s *CategoryService) UpdateCategory(id int64, requestCategory *entity.Reque
 		return errors.New("category not found")
 	}
 
+	if requestCategory.TaxRate != nil && (*requestCategory.TaxRate < 0 || *requestCategory.TaxRate > 100) {
+		return errors.New("tax rate must be between 0 and 100")
+	}
+
 	category := &entity.Category{
 		Name:        requestCategory.Name,
 		Description: requestCategory.Description,
+		TaxRate:     requestCategory.TaxRate,
+		TaxExempt:   requestCategory.TaxExempt,
 	}
 	return s.categoryRepository.UpdateCategory(id, category)
 }
@@ -0,0 +1,427 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	constants "github.com/pandusatrianura/kasir_api_service/constant"
+	"github.com/pandusatrianura/kasir_api_service/internal/customers/entity"
+	"github.com/pandusatrianura/kasir_api_service/internal/customers/service"
+	"github.com/pandusatrianura/kasir_api_service/pkg/pagination"
+	"github.com/pandusatrianura/kasir_api_service/pkg/response"
+)
+
+type CustomerHandler struct {
+	service service.ICustomerService
+}
+
+func NewCustomerHandler(service service.ICustomerService) *CustomerHandler {
+	return &CustomerHandler{service: service}
+}
+
+// API godoc
+// @Summary Get health status of customers API
+// @Description Get health status of customers API
+// @Tags customers
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 503 {object} map[string]string
+// @Router /api/customers/health [get]
+func (h *CustomerHandler) API(w http.ResponseWriter, r *http.Request) {
+	var result response.APIResponse
+	svcHealthCheckResult := h.service.API()
+
+	if svcHealthCheckResult.IsHealthy {
+		result.Code = strconv.Itoa(constants.SuccessCode)
+		result.Message = fmt.Sprintf("%s is healthy", svcHealthCheckResult.Name)
+		response.WriteJSONResponse(w, http.StatusOK, result)
+		return
+	}
+
+	result.Code = strconv.Itoa(constants.ErrorCode)
+	result.Message = fmt.Sprintf("%s is not healthy", svcHealthCheckResult.Name)
+	response.WriteJSONResponse(w, http.StatusServiceUnavailable, result)
+	return
+}
+
+// CreateCustomer godoc
+// @Summary Create a customer
+// @Description Register a customer so sales can earn and redeem loyalty points, only a manager can set the credit limit
+// @Tags customers
+// @Accept json
+// @Produce json
+// @Param X-API-Key header string true "your-secret-api-key-here"
+// @Param Authorization header string true "Bearer <token>"
+// @Param customer body entity.RequestCustomer true "Customer Data"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/customers [post]
+func (h *CustomerHandler) CreateCustomer(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		response.Error(w, http.StatusMethodNotAllowed, constants.ErrorCode, constants.ErrInvalidMethod, nil)
+		return
+	}
+
+	role := r.Header.Get("X-User-Roles")
+	if role == "" {
+		response.Error(w, http.StatusUnauthorized, constants.ErrorCode, constants.ErrRoleNotAuthorized, errors.New(fmt.Sprintf("%s", role)))
+		return
+	}
+
+	var request entity.RequestCustomer
+	if err := response.ParseJSON(r, &request); err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidCustomerRequest, err)
+		return
+	}
+
+	if request.CreditLimit != nil && role != constants.ManagerRole {
+		response.Error(w, http.StatusUnauthorized, constants.ErrorCode, constants.ErrRoleNotAuthorized, errors.New(fmt.Sprintf("%s", role)))
+		return
+	}
+
+	customer, err := h.service.CreateCustomer(&request)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, constants.ErrorCode, "Customer created failed", err)
+		return
+	}
+
+	response.Success(w, http.StatusCreated, constants.SuccessCode, "Customer created successfully", customer)
+}
+
+// UpdateCustomer godoc
+// @Summary Update a customer
+// @Description Update the name, phone and email of a customer, only a manager can change the credit limit
+// @Tags customers
+// @Accept json
+// @Produce json
+// @Param X-API-Key header string true "your-secret-api-key-here"
+// @Param Authorization header string true "Bearer <token>"
+// @Param id path int true "Customer ID"
+// @Param customer body entity.RequestCustomer true "Customer Data"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/customers/{id} [put]
+func (h *CustomerHandler) UpdateCustomer(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		response.Error(w, http.StatusMethodNotAllowed, constants.ErrorCode, constants.ErrInvalidMethod, nil)
+		return
+	}
+
+	role := r.Header.Get("X-User-Roles")
+	if role == "" {
+		response.Error(w, http.StatusUnauthorized, constants.ErrorCode, constants.ErrRoleNotAuthorized, errors.New(fmt.Sprintf("%s", role)))
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidCustomerID, err)
+		return
+	}
+
+	var request entity.RequestCustomer
+	if err := response.ParseJSON(r, &request); err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidCustomerRequest, err)
+		return
+	}
+
+	if request.CreditLimit != nil && role != constants.ManagerRole {
+		response.Error(w, http.StatusUnauthorized, constants.ErrorCode, constants.ErrRoleNotAuthorized, errors.New(fmt.Sprintf("%s", role)))
+		return
+	}
+
+	customer, err := h.service.UpdateCustomer(id, &request)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, constants.ErrorCode, "Customer updated failed", err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, constants.SuccessCode, "Customer updated successfully", customer)
+}
+
+// DeleteCustomer godoc
+// @Summary Delete a customer
+// @Description Delete a customer and its points and receivables ledgers, past sales are kept without the customer. A customer with a receivable balance cannot be deleted
+// @Tags customers
+// @Accept json
+// @Produce json
+// @Param X-API-Key header string true "your-secret-api-key-here"
+// @Param Authorization header string true "Bearer <token>"
+// @Param id path int true "Customer ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/customers/{id} [delete]
+func (h *CustomerHandler) DeleteCustomer(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		response.Error(w, http.StatusMethodNotAllowed, constants.ErrorCode, constants.ErrInvalidMethod, nil)
+		return
+	}
+
+	role := r.Header.Get("X-User-Roles")
+	if role != constants.ManagerRole {
+		response.Error(w, http.StatusUnauthorized, constants.ErrorCode, constants.ErrRoleNotAuthorized, errors.New(fmt.Sprintf("%s", role)))
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidCustomerID, err)
+		return
+	}
+
+	if err := h.service.DeleteCustomer(id); err != nil {
+		response.Error(w, http.StatusInternalServerError, constants.ErrorCode, "Customer delete failed", err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, constants.SuccessCode, "Customer deleted successfully", nil)
+}
+
+// GetCustomerByID godoc
+// @Summary Get a customer by ID
+// @Description Get a customer with its loyalty points and receivable balance
+// @Tags customers
+// @Accept json
+// @Produce json
+// @Param X-API-Key header string true "your-secret-api-key-here"
+// @Param Authorization header string true "Bearer <token>"
+// @Param id path int true "Customer ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/customers/{id} [get]
+func (h *CustomerHandler) GetCustomerByID(w http.ResponseWriter, r *http.Request) {
+	role := r.Header.Get("X-User-Roles")
+	if role == "" {
+		response.Error(w, http.StatusUnauthorized, constants.ErrorCode, constants.ErrRoleNotAuthorized, errors.New(fmt.Sprintf("%s", role)))
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidCustomerID, err)
+		return
+	}
+
+	customer, err := h.service.GetCustomerByID(id)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, constants.ErrorCode, "Customer retrieved failed", err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, constants.SuccessCode, "Customer retrieved successfully", customer)
+}
+
+// GetCustomers godoc
+// @Summary Get customers
+// @Description List customers, or look a customer up by phone number or email
+// @Tags customers
+// @Accept json
+// @Produce json
+// @Param X-API-Key header string true "your-secret-api-key-here"
+// @Param Authorization header string true "Bearer <token>"
+// @Param page query int false "Page (default 1)"
+// @Param limit query int false "Limit per page (default 20, max 100)"
+// @Param name query string false "Name, or part of it"
+// @Param phone query string false "Phone number"
+// @Param email query string false "Email"
+// @Success 200 {object} map[string]interface{}
+// @Failure 500 {object} map[string]string
+// @Router /api/customers [get]
+func (h *CustomerHandler) GetCustomers(w http.ResponseWriter, r *http.Request) {
+	var filter entity.CustomerFilter
+
+	role := r.Header.Get("X-User-Roles")
+	if role == "" {
+		response.Error(w, http.StatusUnauthorized, constants.ErrorCode, constants.ErrRoleNotAuthorized, errors.New(fmt.Sprintf("%s", role)))
+		return
+	}
+
+	query := r.URL.Query()
+	filter.Page, filter.Limit = pagination.Parse(r)
+	filter.Name = query.Get("name")
+	filter.Phone = query.Get("phone")
+	filter.Email = query.Get("email")
+
+	customers, total, err := h.service.GetCustomers(filter)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, constants.ErrorCode, "Customers retrieved failed", err)
+		return
+	}
+
+	response.SuccessWithMeta(w, http.StatusOK, constants.SuccessCode, "Customers retrieved successfully", customers, pagination.NewMeta(filter.Page, filter.Limit, total))
+}
+
+// GetPointsLedger godoc
+// @Summary Get the loyalty points ledger of a customer
+// @Description Get every point earned, redeemed and reversed by a customer, newest first
+// @Tags customers
+// @Accept json
+// @Produce json
+// @Param X-API-Key header string true "your-secret-api-key-here"
+// @Param Authorization header string true "Bearer <token>"
+// @Param id path int true "Customer ID"
+// @Param page query int false "Page (default 1)"
+// @Param limit query int false "Limit per page (default 20, max 100)"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/customers/{id}/points [get]
+func (h *CustomerHandler) GetPointsLedger(w http.ResponseWriter, r *http.Request) {
+	role := r.Header.Get("X-User-Roles")
+	if role == "" {
+		response.Error(w, http.StatusUnauthorized, constants.ErrorCode, constants.ErrRoleNotAuthorized, errors.New(fmt.Sprintf("%s", role)))
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidCustomerID, err)
+		return
+	}
+
+	page, limit := pagination.Parse(r)
+
+	entries, total, err := h.service.GetPointsLedger(id, page, limit)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, constants.ErrorCode, "Points ledger retrieved failed", err)
+		return
+	}
+
+	response.SuccessWithMeta(w, http.StatusOK, constants.SuccessCode, "Points ledger retrieved successfully", entries, pagination.NewMeta(page, limit, total))
+}
+
+// GetPurchases godoc
+// @Summary Get the purchase history of a customer
+// @Description Get the sales recorded for a customer, newest first
+// @Tags customers
+// @Accept json
+// @Produce json
+// @Param X-API-Key header string true "your-secret-api-key-here"
+// @Param Authorization header string true "Bearer <token>"
+// @Param id path int true "Customer ID"
+// @Param page query int false "Page (default 1)"
+// @Param limit query int false "Limit per page (default 20, max 100)"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/customers/{id}/transactions [get]
+func (h *CustomerHandler) GetPurchases(w http.ResponseWriter, r *http.Request) {
+	role := r.Header.Get("X-User-Roles")
+	if role == "" {
+		response.Error(w, http.StatusUnauthorized, constants.ErrorCode, constants.ErrRoleNotAuthorized, errors.New(fmt.Sprintf("%s", role)))
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidCustomerID, err)
+		return
+	}
+
+	page, limit := pagination.Parse(r)
+
+	purchases, total, err := h.service.GetPurchases(id, page, limit)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, constants.ErrorCode, "Purchase history retrieved failed", err)
+		return
+	}
+
+	response.SuccessWithMeta(w, http.StatusOK, constants.SuccessCode, "Purchase history retrieved successfully", purchases, pagination.NewMeta(page, limit, total))
+}
+
+// GetReceivablesLedger godoc
+// @Summary Get the receivables ledger of a customer
+// @Description Get every sale paid on credit, repayment and voided charge of a customer, newest first
+// @Tags customers
+// @Accept json
+// @Produce json
+// @Param X-API-Key header string true "your-secret-api-key-here"
+// @Param Authorization header string true "Bearer <token>"
+// @Param id path int true "Customer ID"
+// @Param page query int false "Page (default 1)"
+// @Param limit query int false "Limit per page (default 20, max 100)"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/customers/{id}/receivables [get]
+func (h *CustomerHandler) GetReceivablesLedger(w http.ResponseWriter, r *http.Request) {
+	role := r.Header.Get("X-User-Roles")
+	if role == "" {
+		response.Error(w, http.StatusUnauthorized, constants.ErrorCode, constants.ErrRoleNotAuthorized, errors.New(fmt.Sprintf("%s", role)))
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidCustomerID, err)
+		return
+	}
+
+	page, limit := pagination.Parse(r)
+
+	entries, total, err := h.service.GetReceivablesLedger(id, page, limit)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, constants.ErrorCode, "Receivables ledger retrieved failed", err)
+		return
+	}
+
+	response.SuccessWithMeta(w, http.StatusOK, constants.SuccessCode, "Receivables ledger retrieved successfully", entries, pagination.NewMeta(page, limit, total))
+}
+
+// RecordRepayment godoc
+// @Summary Record a repayment of a customer
+// @Description Pay back part or all of the receivable balance of a customer with cash, debit, qris or e-wallet
+// @Tags customers
+// @Accept json
+// @Produce json
+// @Param X-API-Key header string true "your-secret-api-key-here"
+// @Param Authorization header string true "Bearer <token>"
+// @Param id path int true "Customer ID"
+// @Param repayment body entity.RequestRepayment true "Repayment Data"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/customers/{id}/repayments [post]
+func (h *CustomerHandler) RecordRepayment(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		response.Error(w, http.StatusMethodNotAllowed, constants.ErrorCode, constants.ErrInvalidMethod, nil)
+		return
+	}
+
+	role := r.Header.Get("X-User-Roles")
+	if role != constants.KasirRole && role != constants.ManagerRole {
+		response.Error(w, http.StatusUnauthorized, constants.ErrorCode, constants.ErrRoleNotAuthorized, errors.New(fmt.Sprintf("%s", role)))
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidCustomerID, err)
+		return
+	}
+
+	var request entity.RequestRepayment
+	if err := response.ParseJSON(r, &request); err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidRepayment, err)
+		return
+	}
+
+	request.CustomerID = id
+	request.UserID, _ = strconv.Atoi(r.Header.Get("X-User-ID"))
+
+	entry, err := h.service.RecordRepayment(&request)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, constants.ErrorCode, "Repayment recorded failed", err)
+		return
+	}
+
+	response.Success(w, http.StatusCreated, constants.SuccessCode, "Repayment recorded successfully", entry)
+}
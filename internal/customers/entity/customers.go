@@ -0,0 +1,82 @@
+package entity
+
+type HealthCheck struct {
+	Name      string `json:"name"`
+	IsHealthy bool   `json:"is_healthy"`
+}
+
+// Customer is a registered customer. ReceivableBalance is what the customer still owes for sales paid on credit
+// and can never be raised above CreditLimit by a sale.
+type Customer struct {
+	ID                int    `json:"id"`
+	Name              string `json:"name"`
+	Phone             string `json:"phone,omitempty"`
+	Email             string `json:"email,omitempty"`
+	PointsBalance     int    `json:"points_balance"`
+	CreditLimit       int    `json:"credit_limit"`
+	ReceivableBalance int    `json:"receivable_balance"`
+	CreatedAt         string `json:"created_at,omitempty"`
+	UpdatedAt         string `json:"updated_at,omitempty"`
+}
+
+// RequestCustomer creates or updates a customer. Only managers may set CreditLimit; when it is left out an update
+// keeps the current limit.
+type RequestCustomer struct {
+	Name        string `json:"name"`
+	Phone       string `json:"phone"`
+	Email       string `json:"email"`
+	CreditLimit *int   `json:"credit_limit,omitempty"`
+}
+
+type CustomerFilter struct {
+	Page  int
+	Limit int
+	Name  string
+	Phone string
+	Email string
+}
+
+// PointsEntry is one movement in the loyalty points ledger of a customer; redeemed points are negative.
+type PointsEntry struct {
+	ID            int    `json:"id"`
+	TransactionID int    `json:"transaction_id,omitempty"`
+	Type          string `json:"type"`
+	Points        int    `json:"points"`
+	BalanceAfter  int    `json:"balance_after"`
+	CreatedAt     string `json:"created_at,omitempty"`
+}
+
+type Purchase struct {
+	TransactionID  int    `json:"transaction_id"`
+	InvoiceNumber  string `json:"invoice_number,omitempty"`
+	TotalAmount    int    `json:"total_amount"`
+	PointsEarned   int    `json:"points_earned"`
+	PointsRedeemed int    `json:"points_redeemed"`
+	Status         string `json:"status"`
+	CreatedAt      string `json:"created_at,omitempty"`
+}
+
+// ReceivableEntry is one movement in the receivables ledger of a customer; repayments and voided charges are negative.
+type ReceivableEntry struct {
+	ID            int    `json:"id"`
+	TransactionID int    `json:"transaction_id,omitempty"`
+	ShiftID       int    `json:"shift_id,omitempty"`
+	UserID        int    `json:"user_id,omitempty"`
+	Type          string `json:"type"`
+	Method        string `json:"method,omitempty"`
+	Amount        int    `json:"amount"`
+	BalanceAfter  int    `json:"balance_after"`
+	Reference     string `json:"reference,omitempty"`
+	Note          string `json:"note,omitempty"`
+	CreatedAt     string `json:"created_at,omitempty"`
+}
+
+// RequestRepayment pays back part or all of the receivable balance of a customer.
+type RequestRepayment struct {
+	CustomerID int    `json:"-"`
+	UserID     int    `json:"-"`
+	Amount     int    `json:"amount"`
+	Method     string `json:"method"`
+	Reference  string `json:"reference"`
+	Note       string `json:"note"`
+}
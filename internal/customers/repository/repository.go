@@ -0,0 +1,429 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+	constants "github.com/pandusatrianura/kasir_api_service/constant"
+	"github.com/pandusatrianura/kasir_api_service/internal/customers/entity"
+	"github.com/pandusatrianura/kasir_api_service/pkg/database"
+	"github.com/pandusatrianura/kasir_api_service/pkg/pagination"
+)
+
+const customerColumns = "id, name, COALESCE(phone, ''), COALESCE(email, ''), points_balance, credit_limit, receivable_balance, created_at, updated_at"
+
+type ICustomerRepository interface {
+	CreateCustomer(customer *entity.Customer) (int, error)
+	UpdateCustomer(id int, customer *entity.Customer) error
+	DeleteCustomer(id int) error
+	GetCustomerByID(id int) (*entity.Customer, error)
+	GetCustomers(filter entity.CustomerFilter) ([]entity.Customer, int64, error)
+	GetPointsLedger(customerID int, page int, limit int) ([]entity.PointsEntry, int64, error)
+	GetPurchases(customerID int, page int, limit int) ([]entity.Purchase, int64, error)
+	GetReceivablesLedger(customerID int, page int, limit int) ([]entity.ReceivableEntry, int64, error)
+	RecordRepayment(repayment *entity.RequestRepayment) (*entity.ReceivableEntry, error)
+}
+
+type CustomerRepository struct {
+	db *database.DB
+}
+
+func NewCustomerRepository(db *database.DB) ICustomerRepository {
+	return &CustomerRepository{db: db}
+}
+
+func (c *CustomerRepository) CreateCustomer(customer *entity.Customer) (int, error) {
+	var (
+		customerID int
+		query      string
+		err        error
+	)
+
+	query = "INSERT INTO customers (name, phone, email, credit_limit, created_at, updated_at) VALUES ($1, NULLIF($2, ''), NULLIF($3, ''), $4, $5, $6) RETURNING id"
+	err = c.db.WithStmt(query, func(stmt *database.Stmt) error {
+		return stmt.QueryRow(customer.Name, customer.Phone, customer.Email, customer.CreditLimit, "now()", "now()").Scan(&customerID)
+	})
+
+	if err != nil {
+		return 0, customerError(err)
+	}
+
+	return customerID, nil
+}
+
+func (c *CustomerRepository) UpdateCustomer(id int, customer *entity.Customer) error {
+	var (
+		query string
+		err   error
+	)
+
+	query = "UPDATE customers SET name = $1, phone = NULLIF($2, ''), email = NULLIF($3, ''), credit_limit = $4, updated_at = $5 WHERE id = $6"
+	err = c.db.WithStmt(query, func(stmt *database.Stmt) error {
+		result, err := stmt.Exec(customer.Name, customer.Phone, customer.Email, customer.CreditLimit, "now()", id)
+		if err != nil {
+			return err
+		}
+
+		return requireRow(result)
+	})
+
+	if err != nil {
+		return customerError(err)
+	}
+
+	return nil
+}
+
+// DeleteCustomer removes a customer together with its points and receivables ledgers. A customer that still owes
+// money, or has a deposit left, is kept so the outstanding balance is never wiped out with the ledger.
+func (c *CustomerRepository) DeleteCustomer(id int) error {
+	var (
+		balance int
+		query   string
+		err     error
+	)
+
+	return c.db.WithTx(func(tx *database.Tx) error {
+		query = "SELECT receivable_balance FROM customers WHERE id = $1 FOR UPDATE"
+		err = tx.WithStmt(query, func(stmt *database.Stmt) error {
+			return stmt.QueryRow(id).Scan(&balance)
+		})
+
+		if errors.Is(err, sql.ErrNoRows) {
+			return errors.New(constants.ErrCustomerNotFound)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if balance != 0 {
+			return errors.New(constants.ErrCustomerHasReceivable)
+		}
+
+		query = "DELETE FROM customers WHERE id = $1"
+		return tx.WithStmt(query, func(stmt *database.Stmt) error {
+			result, err := stmt.Exec(id)
+			if err != nil {
+				return err
+			}
+
+			return requireRow(result)
+		})
+	})
+}
+
+func (c *CustomerRepository) GetCustomerByID(id int) (*entity.Customer, error) {
+	var (
+		customer entity.Customer
+		query    string
+		err      error
+	)
+
+	query = fmt.Sprintf("SELECT %s FROM customers WHERE id = $1", customerColumns)
+	err = c.db.WithStmt(query, func(stmt *database.Stmt) error {
+		scanFn := func(rows *database.Rows) error {
+			return scanCustomer(rows, &customer)
+		}
+
+		return stmt.Query(scanFn, id)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if customer.ID == 0 {
+		return nil, errors.New(constants.ErrCustomerNotFound)
+	}
+
+	return &customer, nil
+}
+
+// GetCustomers lists customers by name; phone and email are matched exactly so the cashier can look a customer up at the counter.
+func (c *CustomerRepository) GetCustomers(filter entity.CustomerFilter) ([]entity.Customer, int64, error) {
+	var (
+		customers  []entity.Customer
+		total      int64
+		conditions []string
+		args       []interface{}
+		query      string
+		err        error
+	)
+
+	customers = make([]entity.Customer, 0)
+
+	if filter.Name != "" {
+		args = append(args, "%"+filter.Name+"%")
+		conditions = append(conditions, fmt.Sprintf("name ILIKE $%d", len(args)))
+	}
+
+	if filter.Phone != "" {
+		args = append(args, filter.Phone)
+		conditions = append(conditions, fmt.Sprintf("phone = $%d", len(args)))
+	}
+
+	if filter.Email != "" {
+		args = append(args, filter.Email)
+		conditions = append(conditions, fmt.Sprintf("email = $%d", len(args)))
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	query = "SELECT COUNT(id) FROM customers" + where
+	err = c.db.WithStmt(query, func(stmt *database.Stmt) error {
+		return stmt.QueryRow(args...).Scan(&total)
+	})
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query = fmt.Sprintf("SELECT %s FROM customers%s ORDER BY name, id LIMIT $%d OFFSET $%d", customerColumns, where, len(args)+1, len(args)+2)
+	args = append(args, filter.Limit, pagination.Offset(filter.Page, filter.Limit))
+
+	err = c.db.WithStmt(query, func(stmt *database.Stmt) error {
+		scanFn := func(rows *database.Rows) error {
+			var customer entity.Customer
+			if err := scanCustomer(rows, &customer); err != nil {
+				return err
+			}
+
+			customers = append(customers, customer)
+			return nil
+		}
+
+		return stmt.Query(scanFn, args...)
+	})
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return customers, total, nil
+}
+
+func (c *CustomerRepository) GetPointsLedger(customerID int, page int, limit int) ([]entity.PointsEntry, int64, error) {
+	var (
+		entries []entity.PointsEntry
+		total   int64
+		query   string
+		err     error
+	)
+
+	entries = make([]entity.PointsEntry, 0)
+
+	query = "SELECT COUNT(id) FROM customer_points_ledger WHERE customer_id = $1"
+	err = c.db.WithStmt(query, func(stmt *database.Stmt) error {
+		return stmt.QueryRow(customerID).Scan(&total)
+	})
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query = "SELECT id, COALESCE(transaction_id, 0), type, points, balance_after, created_at FROM customer_points_ledger WHERE customer_id = $1 ORDER BY id DESC LIMIT $2 OFFSET $3"
+	err = c.db.WithStmt(query, func(stmt *database.Stmt) error {
+		scanFn := func(rows *database.Rows) error {
+			var entry entity.PointsEntry
+			if err := rows.Scan(&entry.ID, &entry.TransactionID, &entry.Type, &entry.Points, &entry.BalanceAfter, &entry.CreatedAt); err != nil {
+				return err
+			}
+
+			entries = append(entries, entry)
+			return nil
+		}
+
+		return stmt.Query(scanFn, customerID, limit, pagination.Offset(page, limit))
+	})
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return entries, total, nil
+}
+
+func (c *CustomerRepository) GetPurchases(customerID int, page int, limit int) ([]entity.Purchase, int64, error) {
+	var (
+		purchases []entity.Purchase
+		total     int64
+		query     string
+		err       error
+	)
+
+	purchases = make([]entity.Purchase, 0)
+
+	query = "SELECT COUNT(id) FROM transactions WHERE customer_id = $1"
+	err = c.db.WithStmt(query, func(stmt *database.Stmt) error {
+		return stmt.QueryRow(customerID).Scan(&total)
+	})
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query = "SELECT id, COALESCE(invoice_number, ''), total_amount, points_earned, points_redeemed, status, created_at FROM transactions WHERE customer_id = $1 ORDER BY created_at DESC, id DESC LIMIT $2 OFFSET $3"
+	err = c.db.WithStmt(query, func(stmt *database.Stmt) error {
+		scanFn := func(rows *database.Rows) error {
+			var purchase entity.Purchase
+			if err := rows.Scan(&purchase.TransactionID, &purchase.InvoiceNumber, &purchase.TotalAmount, &purchase.PointsEarned, &purchase.PointsRedeemed, &purchase.Status, &purchase.CreatedAt); err != nil {
+				return err
+			}
+
+			purchases = append(purchases, purchase)
+			return nil
+		}
+
+		return stmt.Query(scanFn, customerID, limit, pagination.Offset(page, limit))
+	})
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return purchases, total, nil
+}
+
+func (c *CustomerRepository) GetReceivablesLedger(customerID int, page int, limit int) ([]entity.ReceivableEntry, int64, error) {
+	var (
+		entries []entity.ReceivableEntry
+		total   int64
+		query   string
+		err     error
+	)
+
+	entries = make([]entity.ReceivableEntry, 0)
+
+	query = "SELECT COUNT(id) FROM customer_receivables_ledger WHERE customer_id = $1"
+	err = c.db.WithStmt(query, func(stmt *database.Stmt) error {
+		return stmt.QueryRow(customerID).Scan(&total)
+	})
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query = "SELECT id, COALESCE(transaction_id, 0), COALESCE(shift_id, 0), COALESCE(user_id, 0), type, COALESCE(method, ''), amount, balance_after, COALESCE(reference, ''), COALESCE(note, ''), created_at FROM customer_receivables_ledger WHERE customer_id = $1 ORDER BY id DESC LIMIT $2 OFFSET $3"
+	err = c.db.WithStmt(query, func(stmt *database.Stmt) error {
+		scanFn := func(rows *database.Rows) error {
+			var entry entity.ReceivableEntry
+			if err := scanReceivableEntry(rows, &entry); err != nil {
+				return err
+			}
+
+			entries = append(entries, entry)
+			return nil
+		}
+
+		return stmt.Query(scanFn, customerID, limit, pagination.Offset(page, limit))
+	})
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return entries, total, nil
+}
+
+// RecordRepayment takes a repayment off the receivable balance of a customer. The customer is locked so two
+// repayments can never pay back more than is owed. A repayment taken by a cashier with an open shift is booked
+// on that shift, so cash repayments are counted in its drawer.
+func (c *CustomerRepository) RecordRepayment(repayment *entity.RequestRepayment) (*entity.ReceivableEntry, error) {
+	var (
+		entry   entity.ReceivableEntry
+		balance int
+		shiftID int
+		query   string
+		err     error
+	)
+
+	err = c.db.WithTx(func(tx *database.Tx) error {
+		query = "SELECT receivable_balance FROM customers WHERE id = $1 FOR UPDATE"
+		err = tx.WithStmt(query, func(stmt *database.Stmt) error {
+			return stmt.QueryRow(repayment.CustomerID).Scan(&balance)
+		})
+
+		if errors.Is(err, sql.ErrNoRows) {
+			return errors.New(constants.ErrCustomerNotFound)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if repayment.Amount > balance {
+			return errors.New(constants.ErrRepaymentExceedsDebt)
+		}
+
+		query = "SELECT COALESCE(MAX(id), 0) FROM shifts WHERE user_id = $1 AND status = $2"
+		err = tx.WithStmt(query, func(stmt *database.Stmt) error {
+			return stmt.QueryRow(repayment.UserID, constants.ShiftStatusOpen).Scan(&shiftID)
+		})
+
+		if err != nil {
+			return err
+		}
+
+		query = "UPDATE customers SET receivable_balance = receivable_balance - $1, updated_at = $2 WHERE id = $3 RETURNING receivable_balance"
+		err = tx.WithStmt(query, func(stmt *database.Stmt) error {
+			return stmt.QueryRow(repayment.Amount, "now()", repayment.CustomerID).Scan(&balance)
+		})
+
+		if err != nil {
+			return err
+		}
+
+		query = "INSERT INTO customer_receivables_ledger (customer_id, shift_id, user_id, type, method, amount, balance_after, reference, note, created_at) VALUES ($1, NULLIF($2, 0), NULLIF($3, 0), $4, $5, $6, $7, NULLIF($8, ''), NULLIF($9, ''), $10) RETURNING id, COALESCE(transaction_id, 0), COALESCE(shift_id, 0), COALESCE(user_id, 0), type, COALESCE(method, ''), amount, balance_after, COALESCE(reference, ''), COALESCE(note, ''), created_at"
+		return tx.WithStmt(query, func(stmt *database.Stmt) error {
+			scanFn := func(rows *database.Rows) error {
+				return scanReceivableEntry(rows, &entry)
+			}
+
+			return stmt.Query(scanFn, repayment.CustomerID, shiftID, repayment.UserID, constants.ReceivableTypeRepayment, repayment.Method, -repayment.Amount, balance, repayment.Reference, repayment.Note, "now()")
+		})
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+func scanReceivableEntry(rows *database.Rows, entry *entity.ReceivableEntry) error {
+	return rows.Scan(&entry.ID, &entry.TransactionID, &entry.ShiftID, &entry.UserID, &entry.Type, &entry.Method, &entry.Amount, &entry.BalanceAfter, &entry.Reference, &entry.Note, &entry.CreatedAt)
+}
+
+func scanCustomer(rows *database.Rows, customer *entity.Customer) error {
+	return rows.Scan(&customer.ID, &customer.Name, &customer.Phone, &customer.Email, &customer.PointsBalance, &customer.CreditLimit, &customer.ReceivableBalance, &customer.CreatedAt, &customer.UpdatedAt)
+}
+
+func requireRow(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return errors.New(constants.ErrCustomerNotFound)
+	}
+
+	return nil
+}
+
+// customerError reports a taken phone number or email instead of the raw constraint violation.
+func customerError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == database.UniqueViolation {
+		return errors.New(constants.ErrCustomerExists)
+	}
+
+	return err
+}
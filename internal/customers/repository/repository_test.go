@@ -0,0 +1,49 @@
+package repository_test
+
+import (
+	"testing"
+
+	constants "github.com/pandusatrianura/kasir_api_service/constant"
+	"github.com/pandusatrianura/kasir_api_service/internal/customers/entity"
+	"github.com/pandusatrianura/kasir_api_service/internal/customers/repository"
+	"github.com/pandusatrianura/kasir_api_service/pkg/database/dbtest"
+)
+
+func TestCustomerWithKasbon(t *testing.T) {
+	db := dbtest.Open(t)
+
+	userID := dbtest.QueryInt(t, db, "INSERT INTO users (name, email, password) VALUES ('Kasir', 'kasir@example.com', 'x') RETURNING id")
+	customerID := dbtest.QueryInt(t, db, "INSERT INTO customers (name, credit_limit, receivable_balance) VALUES ('Budi', 100000, 5000) RETURNING id")
+
+	repo := repository.NewCustomerRepository(db)
+
+	if err := repo.DeleteCustomer(customerID); err == nil || err.Error() != constants.ErrCustomerHasReceivable {
+		t.Fatalf("deleting a customer who owes kasbon returned %v, want %s", err, constants.ErrCustomerHasReceivable)
+	}
+
+	entry, err := repo.RecordRepayment(&entity.RequestRepayment{CustomerID: customerID, UserID: userID, Amount: 6000, Method: constants.PaymentMethodCash})
+	if err == nil || err.Error() != constants.ErrRepaymentExceedsDebt {
+		t.Fatalf("overpaying the debt returned %+v, %v, want %s", entry, err, constants.ErrRepaymentExceedsDebt)
+	}
+
+	if balance := dbtest.QueryInt(t, db, "SELECT receivable_balance FROM customers WHERE id = $1", customerID); balance != 5000 {
+		t.Fatalf("receivable balance is %d after a rejected repayment, want 5000", balance)
+	}
+
+	entry, err = repo.RecordRepayment(&entity.RequestRepayment{CustomerID: customerID, UserID: userID, Amount: 5000, Method: constants.PaymentMethodCash})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if entry.ID == 0 || entry.Amount != -5000 || entry.BalanceAfter != 0 {
+		t.Fatalf("repayment entry = %+v, want an amount of -5000 and a balance of 0", entry)
+	}
+
+	if err = repo.DeleteCustomer(customerID); err != nil {
+		t.Fatalf("deleting a customer who paid everything back returned %v", err)
+	}
+
+	if err = repo.DeleteCustomer(customerID); err == nil || err.Error() != constants.ErrCustomerNotFound {
+		t.Fatalf("deleting a missing customer returned %v, want %s", err, constants.ErrCustomerNotFound)
+	}
+}
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"errors"
+	"strings"
+
+	constants "github.com/pandusatrianura/kasir_api_service/constant"
+	"github.com/pandusatrianura/kasir_api_service/internal/customers/entity"
+	"github.com/pandusatrianura/kasir_api_service/internal/customers/repository"
+)
+
+type ICustomerService interface {
+	CreateCustomer(request *entity.RequestCustomer) (*entity.Customer, error)
+	UpdateCustomer(id int, request *entity.RequestCustomer) (*entity.Customer, error)
+	DeleteCustomer(id int) error
+	GetCustomerByID(id int) (*entity.Customer, error)
+	GetCustomers(filter entity.CustomerFilter) ([]entity.Customer, int64, error)
+	GetPointsLedger(id int, page int, limit int) ([]entity.PointsEntry, int64, error)
+	GetPurchases(id int, page int, limit int) ([]entity.Purchase, int64, error)
+	GetReceivablesLedger(id int, page int, limit int) ([]entity.ReceivableEntry, int64, error)
+	RecordRepayment(request *entity.RequestRepayment) (*entity.ReceivableEntry, error)
+	API() entity.HealthCheck
+}
+
+type CustomerService struct {
+	customerRepository repository.ICustomerRepository
+}
+
+func NewCustomerService(customerRepository repository.ICustomerRepository) ICustomerService {
+	return &CustomerService{customerRepository: customerRepository}
+}
+
+func (s *CustomerService) API() entity.HealthCheck {
+	return entity.HealthCheck{
+		Name:      "Customers API",
+		IsHealthy: true,
+	}
+}
+
+func (s *CustomerService) CreateCustomer(request *entity.RequestCustomer) (*entity.Customer, error) {
+	customer, err := newCustomer(request)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := s.customerRepository.CreateCustomer(customer)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.customerRepository.GetCustomerByID(id)
+}
+
+func (s *CustomerService) UpdateCustomer(id int, request *entity.RequestCustomer) (*entity.Customer, error) {
+	customer, err := newCustomer(request)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.CreditLimit == nil {
+		current, err := s.customerRepository.GetCustomerByID(id)
+		if err != nil {
+			return nil, err
+		}
+
+		customer.CreditLimit = current.CreditLimit
+	}
+
+	if err = s.customerRepository.UpdateCustomer(id, customer); err != nil {
+		return nil, err
+	}
+
+	return s.customerRepository.GetCustomerByID(id)
+}
+
+func (s *CustomerService) DeleteCustomer(id int) error {
+	return s.customerRepository.DeleteCustomer(id)
+}
+
+func (s *CustomerService) GetCustomerByID(id int) (*entity.Customer, error) {
+	return s.customerRepository.GetCustomerByID(id)
+}
+
+func (s *CustomerService) GetCustomers(filter entity.CustomerFilter) ([]entity.Customer, int64, error) {
+	filter.Name = strings.TrimSpace(filter.Name)
+	filter.Phone = normalizePhone(filter.Phone)
+	filter.Email = normalizeEmail(filter.Email)
+
+	return s.customerRepository.GetCustomers(filter)
+}
+
+func (s *CustomerService) GetPointsLedger(id int, page int, limit int) ([]entity.PointsEntry, int64, error) {
+	if _, err := s.customerRepository.GetCustomerByID(id); err != nil {
+		return nil, 0, err
+	}
+
+	return s.customerRepository.GetPointsLedger(id, page, limit)
+}
+
+func (s *CustomerService) GetPurchases(id int, page int, limit int) ([]entity.Purchase, int64, error) {
+	if _, err := s.customerRepository.GetCustomerByID(id); err != nil {
+		return nil, 0, err
+	}
+
+	return s.customerRepository.GetPurchases(id, page, limit)
+}
+
+func (s *CustomerService) GetReceivablesLedger(id int, page int, limit int) ([]entity.ReceivableEntry, int64, error) {
+	if _, err := s.customerRepository.GetCustomerByID(id); err != nil {
+		return nil, 0, err
+	}
+
+	return s.customerRepository.GetReceivablesLedger(id, page, limit)
+}
+
+// RecordRepayment records money paid back by a customer. Vouchers and credit cannot pay off a debt.
+func (s *CustomerService) RecordRepayment(request *entity.RequestRepayment) (*entity.ReceivableEntry, error) {
+	if request.Amount <= 0 {
+		return nil, errors.New(constants.ErrInvalidRepaymentAmount)
+	}
+
+	request.Method = strings.TrimSpace(request.Method)
+	switch request.Method {
+	case constants.PaymentMethodCash, constants.PaymentMethodDebit, constants.PaymentMethodQRIS, constants.PaymentMethodEWallet:
+	default:
+		return nil, errors.New(constants.ErrInvalidRepaymentMethod)
+	}
+
+	request.Reference = strings.TrimSpace(request.Reference)
+	request.Note = strings.TrimSpace(request.Note)
+
+	return s.customerRepository.RecordRepayment(request)
+}
+
+func newCustomer(request *entity.RequestCustomer) (*entity.Customer, error) {
+	customer := &entity.Customer{
+		Name:  strings.TrimSpace(request.Name),
+		Phone: normalizePhone(request.Phone),
+		Email: normalizeEmail(request.Email),
+	}
+
+	if customer.Name == "" {
+		return nil, errors.New(constants.ErrCustomerNameRequired)
+	}
+
+	if request.CreditLimit != nil {
+		if *request.CreditLimit < 0 {
+			return nil, errors.New(constants.ErrInvalidCreditLimit)
+		}
+
+		customer.CreditLimit = *request.CreditLimit
+	}
+
+	return customer, nil
+}
+
+// normalizePhone strips the separators cashiers tend to type, so "0812-3456 789" and "08123456789" find the same customer.
+func normalizePhone(phone string) string {
+	return strings.NewReplacer(" ", "", "-", "", ".", "", "(", "", ")", "").Replace(strings.TrimSpace(phone))
+}
+
+// normalizeEmail lower cases an email address so lookups are case insensitive.
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
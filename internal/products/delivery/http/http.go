@@ -5,11 +5,14 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 	constants "github.com/pandusatrianura/kasir_api_service/constant"
 	"github.com/pandusatrianura/kasir_api_service/internal/products/entity"
 	"github.com/pandusatrianura/kasir_api_service/internal/products/service"
+	"github.com/pandusatrianura/kasir_api_service/pkg/barcode"
+	"github.com/pandusatrianura/kasir_api_service/pkg/pagination"
 	"github.com/pandusatrianura/kasir_api_service/pkg/response"
 )
 
@@ -211,24 +214,397 @@ func (h *ProductHandler) GetProductByID(w http.ResponseWriter, r *http.Request)
 	response.Success(w, http.StatusOK, constants.SuccessCode, "Product retrieved successfully", product)
 }
 
-// GetAllProducts godoc
-// @Summary Get all products
-// @Description Get all products
+// GetProductByBarcode godoc
+// @Summary Get a product by barcode
+// @Description Look a product up by a scanned EAN-13, UPC-A or EAN-8 barcode; a barcode of a variant also returns that variant
 // @Tags products
 // @Accept json
 // @Produce json
-// @Param name query string false "Product's name"
+// @Param Authorization header string true "Bearer <token>"
+// @Param X-API-Key header string true "your-secret-api-key-here"
+// @Param code path string true "Barcode"
 // @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/products/barcode/{code} [get]
+func (h *ProductHandler) GetProductByBarcode(w http.ResponseWriter, r *http.Request) {
+	role := r.Header.Get("X-User-Roles")
+	if role == "" {
+		response.Error(w, http.StatusUnauthorized, constants.ErrorCode, constants.ErrRoleNotAuthorized, errors.New(fmt.Sprintf("%s", role)))
+		return
+	}
+
+	code, err := barcode.Normalize(chi.URLParam(r, "code"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidBarcode, err)
+		return
+	}
+
+	product, err := h.service.GetProductByBarcode(code)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, constants.ErrorCode, "Product retrieved failed", err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, constants.SuccessCode, "Product retrieved successfully", product)
+}
+
+// GetProducts godoc
+// @Summary Get products
+// @Description List products page by page, filtered by name, category, price range and stock and sorted by name, price, stock or updated_at
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param page query int false "Page (default 1)"
+// @Param limit query int false "Limit per page (default 20, max 100)"
+// @Param name query string false "Product's name, or part of it"
+// @Param category_id query int false "Category ID"
+// @Param min_price query int false "Minimum price"
+// @Param max_price query int false "Maximum price"
+// @Param in_stock query bool false "true for products in stock, false for sold out products"
+// @Param sort query string false "name, price, stock or updated_at (default name)"
+// @Param order query string false "asc or desc (default asc)"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /api/products [get]
-func (h *ProductHandler) GetAllProducts(w http.ResponseWriter, r *http.Request) {
-	name := r.URL.Query().Get("name")
+func (h *ProductHandler) GetProducts(w http.ResponseWriter, r *http.Request) {
+	var (
+		filter entity.ProductFilter
+		err    error
+	)
+
+	query := r.URL.Query()
+	filter.Page, filter.Limit = pagination.Parse(r)
+	filter.Name = query.Get("name")
+
+	if filter.CategoryID, err = parseIntFilter(query.Get("category_id")); err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidProductList, err)
+		return
+	}
+
+	if filter.MinPrice, err = parseIntFilter(query.Get("min_price")); err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidProductList, err)
+		return
+	}
+
+	if filter.MaxPrice, err = parseIntFilter(query.Get("max_price")); err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidProductList, err)
+		return
+	}
+
+	if filter.MinPrice > 0 && filter.MaxPrice > 0 && filter.MinPrice > filter.MaxPrice {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidProductList, errors.New(constants.ErrInvalidPriceRange))
+		return
+	}
 
-	products, err := h.service.GetAllProducts(name)
+	if inStock := query.Get("in_stock"); inStock != "" {
+		value, err := strconv.ParseBool(inStock)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidProductList, err)
+			return
+		}
+
+		filter.InStock = &value
+	}
+
+	filter.Sort = strings.ToLower(strings.TrimSpace(query.Get("sort")))
+	switch filter.Sort {
+	case "":
+		filter.Sort = constants.ProductSortName
+	case constants.ProductSortName, constants.ProductSortPrice, constants.ProductSortStock, constants.ProductSortUpdatedAt:
+	default:
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidProductList, errors.New(constants.ErrInvalidProductSort))
+		return
+	}
+
+	filter.Order = strings.ToLower(strings.TrimSpace(query.Get("order")))
+	switch filter.Order {
+	case "":
+		filter.Order = constants.SortOrderAsc
+	case constants.SortOrderAsc, constants.SortOrderDesc:
+	default:
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidProductList, errors.New(constants.ErrInvalidSortOrder))
+		return
+	}
+
+	products, total, err := h.service.GetProducts(filter)
 	if err != nil {
 		response.Error(w, http.StatusInternalServerError, constants.ErrorCode, "Products retrieved failed", err)
 		return
 	}
 
-	response.Success(w, http.StatusOK, constants.SuccessCode, "Products retrieved successfully", products)
+	response.SuccessWithMeta(w, http.StatusOK, constants.SuccessCode, "Products retrieved successfully", products, pagination.NewMeta(filter.Page, filter.Limit, total))
+}
+
+// CreateVariant godoc
+// @Summary Add a variant to a product
+// @Description Add a variant with its own sku, barcodes, price and stock; options need one value for every variant axis of the product
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer <token>"
+// @Param X-API-Key header string true "your-secret-api-key-here"
+// @Param id path int true "Product ID"
+// @Param variant body entity.RequestVariant true "Variant Data"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/products/{id}/variants [post]
+func (h *ProductHandler) CreateVariant(w http.ResponseWriter, r *http.Request) {
+	role := r.Header.Get("X-User-Roles")
+	if role != constants.ManagerRole {
+		response.Error(w, http.StatusUnauthorized, constants.ErrorCode, constants.ErrRoleNotAuthorized, errors.New(fmt.Sprintf("%s", role)))
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidProductID, err)
+		return
+	}
+
+	var requestVariant entity.RequestVariant
+	if err := response.ParseJSON(r, &requestVariant); err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidVariantRequest, err)
+		return
+	}
+
+	variant, err := h.service.CreateVariant(int64(id), &requestVariant)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, constants.ErrorCode, "Variant created failed", err)
+		return
+	}
+
+	response.Success(w, http.StatusCreated, constants.SuccessCode, "Variant created successfully", variant)
+}
+
+// UpdateVariant godoc
+// @Summary Update a variant of a product
+// @Description Update the sku, barcodes, options, price and stock of a variant; missing barcodes keep the current ones
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer <token>"
+// @Param X-API-Key header string true "your-secret-api-key-here"
+// @Param id path int true "Product ID"
+// @Param variant_id path int true "Variant ID"
+// @Param variant body entity.RequestVariant true "Variant Data"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/products/{id}/variants/{variant_id} [put]
+func (h *ProductHandler) UpdateVariant(w http.ResponseWriter, r *http.Request) {
+	role := r.Header.Get("X-User-Roles")
+	if role != constants.ManagerRole {
+		response.Error(w, http.StatusUnauthorized, constants.ErrorCode, constants.ErrRoleNotAuthorized, errors.New(fmt.Sprintf("%s", role)))
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidProductID, err)
+		return
+	}
+
+	variantID, err := strconv.Atoi(chi.URLParam(r, "variant_id"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidVariantID, err)
+		return
+	}
+
+	var requestVariant entity.RequestVariant
+	if err := response.ParseJSON(r, &requestVariant); err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidVariantRequest, err)
+		return
+	}
+
+	variant, err := h.service.UpdateVariant(int64(id), int64(variantID), &requestVariant)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, constants.ErrorCode, "Variant updated failed", err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, constants.SuccessCode, "Variant updated successfully", variant)
+}
+
+// DeleteVariant godoc
+// @Summary Delete a variant of a product
+// @Description Delete a variant; its stock is taken off the stock of the product
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer <token>"
+// @Param X-API-Key header string true "your-secret-api-key-here"
+// @Param id path int true "Product ID"
+// @Param variant_id path int true "Variant ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/products/{id}/variants/{variant_id} [delete]
+func (h *ProductHandler) DeleteVariant(w http.ResponseWriter, r *http.Request) {
+	role := r.Header.Get("X-User-Roles")
+	if role != constants.ManagerRole {
+		response.Error(w, http.StatusUnauthorized, constants.ErrorCode, constants.ErrRoleNotAuthorized, errors.New(fmt.Sprintf("%s", role)))
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidProductID, err)
+		return
+	}
+
+	variantID, err := strconv.Atoi(chi.URLParam(r, "variant_id"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidVariantID, err)
+		return
+	}
+
+	if err := h.service.DeleteVariant(int64(id), int64(variantID)); err != nil {
+		response.Error(w, http.StatusInternalServerError, constants.ErrorCode, "Variant delete failed", err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, constants.SuccessCode, "Variant deleted successfully", nil)
+}
+
+// CreateUnit godoc
+// @Summary Add a unit to a product
+// @Description Add a unit the product is sold in, e.g. a dus of 40 pcs, with its own price
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer <token>"
+// @Param X-API-Key header string true "your-secret-api-key-here"
+// @Param id path int true "Product ID"
+// @Param unit body entity.RequestUnit true "Unit Data"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/products/{id}/units [post]
+func (h *ProductHandler) CreateUnit(w http.ResponseWriter, r *http.Request) {
+	role := r.Header.Get("X-User-Roles")
+	if role != constants.ManagerRole {
+		response.Error(w, http.StatusUnauthorized, constants.ErrorCode, constants.ErrRoleNotAuthorized, errors.New(fmt.Sprintf("%s", role)))
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidProductID, err)
+		return
+	}
+
+	var requestUnit entity.RequestUnit
+	if err := response.ParseJSON(r, &requestUnit); err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidUnitRequest, err)
+		return
+	}
+
+	unit, err := h.service.CreateUnit(int64(id), &requestUnit)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, constants.ErrorCode, "Unit created failed", err)
+		return
+	}
+
+	response.Success(w, http.StatusCreated, constants.SuccessCode, "Unit created successfully", unit)
+}
+
+// UpdateUnit godoc
+// @Summary Update a unit of a product
+// @Description Update the name, factor and price of a unit
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer <token>"
+// @Param X-API-Key header string true "your-secret-api-key-here"
+// @Param id path int true "Product ID"
+// @Param unit_id path int true "Unit ID"
+// @Param unit body entity.RequestUnit true "Unit Data"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/products/{id}/units/{unit_id} [put]
+func (h *ProductHandler) UpdateUnit(w http.ResponseWriter, r *http.Request) {
+	role := r.Header.Get("X-User-Roles")
+	if role != constants.ManagerRole {
+		response.Error(w, http.StatusUnauthorized, constants.ErrorCode, constants.ErrRoleNotAuthorized, errors.New(fmt.Sprintf("%s", role)))
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidProductID, err)
+		return
+	}
+
+	unitID, err := strconv.Atoi(chi.URLParam(r, "unit_id"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidUnitID, err)
+		return
+	}
+
+	var requestUnit entity.RequestUnit
+	if err := response.ParseJSON(r, &requestUnit); err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidUnitRequest, err)
+		return
+	}
+
+	unit, err := h.service.UpdateUnit(int64(id), int64(unitID), &requestUnit)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, constants.ErrorCode, "Unit updated failed", err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, constants.SuccessCode, "Unit updated successfully", unit)
+}
+
+// DeleteUnit godoc
+// @Summary Delete a unit of a product
+// @Description Delete a unit; lines already sold in it keep their unit
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer <token>"
+// @Param X-API-Key header string true "your-secret-api-key-here"
+// @Param id path int true "Product ID"
+// @Param unit_id path int true "Unit ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/products/{id}/units/{unit_id} [delete]
+func (h *ProductHandler) DeleteUnit(w http.ResponseWriter, r *http.Request) {
+	role := r.Header.Get("X-User-Roles")
+	if role != constants.ManagerRole {
+		response.Error(w, http.StatusUnauthorized, constants.ErrorCode, constants.ErrRoleNotAuthorized, errors.New(fmt.Sprintf("%s", role)))
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidProductID, err)
+		return
+	}
+
+	unitID, err := strconv.Atoi(chi.URLParam(r, "unit_id"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidUnitID, err)
+		return
+	}
+
+	if err := h.service.DeleteUnit(int64(id), int64(unitID)); err != nil {
+		response.Error(w, http.StatusInternalServerError, constants.ErrorCode, "Unit delete failed", err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, constants.SuccessCode, "Unit deleted successfully", nil)
+}
+
+func parseIntFilter(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	return strconv.Atoi(value)
 }
@@ -3,20 +3,31 @@ package entity
 import "time"
 
 type Product struct {
-	ID         int    `json:"id"`
-	Name       string `json:"name"`
-	Price      int    `json:"price"`
-	Stock      int    `json:"stock"`
-	CategoryID int    `json:"category_id"`
-	CreatedAt  string `json:"created_at,omitempty"`
-	UpdatedAt  string `json:"updated_at,omitempty"`
+	ID          int      `json:"id"`
+	Name        string   `json:"name"`
+	SKU         string   `json:"sku,omitempty"`
+	Barcodes    []string `json:"barcodes,omitempty"`
+	VariantAxes []string `json:"variant_axes,omitempty"`
+	BaseUnit    string   `json:"base_unit"`
+	Price       int      `json:"price"`
+	Stock       int      `json:"stock"`
+	CategoryID  int      `json:"category_id"`
+	CreatedAt   string   `json:"created_at,omitempty"`
+	UpdatedAt   string   `json:"updated_at,omitempty"`
 }
 
+// RequestProduct creates or updates a product. On update a missing sku, barcodes or variant axes keeps the current
+// ones, an empty sku or an empty list removes them. The stock of a product with variants is the total of its
+// variants and is not changed here. Price and stock are in the base unit, pcs when it is not set.
 type RequestProduct struct {
-	Name       string `json:"name"`
-	Price      int    `json:"price"`
-	Stock      int    `json:"stock"`
-	CategoryID int    `json:"category_id"`
+	Name        string   `json:"name"`
+	SKU         *string  `json:"sku,omitempty"`
+	Barcodes    []string `json:"barcodes,omitempty"`
+	VariantAxes []string `json:"variant_axes,omitempty"`
+	BaseUnit    string   `json:"base_unit,omitempty"`
+	Price       int      `json:"price"`
+	Stock       int      `json:"stock"`
+	CategoryID  int      `json:"category_id"`
 }
 
 type HealthCheck struct {
@@ -27,6 +38,10 @@ type HealthCheck struct {
 type ProductWithCategories struct {
 	ID           int    `json:"id"`
 	Name         string `json:"name"`
+	SKU          string `json:"sku,omitempty"`
+	Barcodes     string `json:"barcodes,omitempty"`
+	VariantAxes  string `json:"variant_axes,omitempty"`
+	BaseUnit     string `json:"base_unit"`
 	Price        int    `json:"price"`
 	Stock        int    `json:"stock"`
 	CategoryID   int    `json:"category_id,omitempty"`
@@ -38,6 +53,13 @@ type ProductWithCategories struct {
 type ResponseProductWithCategories struct {
 	ID           int       `json:"id"`
 	Name         string    `json:"name"`
+	SKU          string    `json:"sku,omitempty"`
+	Barcodes     []string  `json:"barcodes"`
+	VariantAxes  []string  `json:"variant_axes"`
+	Variants     []Variant `json:"variants"`
+	Variant      *Variant  `json:"variant,omitempty"`
+	BaseUnit     string    `json:"base_unit"`
+	Units        []Unit    `json:"units"`
 	Price        int       `json:"price"`
 	Stock        int       `json:"stock"`
 	CategoryID   int       `json:"category_id,omitempty"`
@@ -46,6 +68,20 @@ type ResponseProductWithCategories struct {
 	UpdatedAt    time.Time `json:"updated_at,omitempty"`
 }
 
+// ProductFilter narrows and orders the product listing. InStock is nil for all products, true for products with
+// stock left and false for products that are sold out.
+type ProductFilter struct {
+	Page       int
+	Limit      int
+	Name       string
+	CategoryID int
+	MinPrice   int
+	MaxPrice   int
+	InStock    *bool
+	Sort       string
+	Order      string
+}
+
 type Category struct {
 	ID   int    `json:"id"`
 	Name string `json:"name"`
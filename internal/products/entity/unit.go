@@ -0,0 +1,21 @@
+package entity
+
+import "time"
+
+// Unit is a unit a product is sold in besides its base unit. One of it holds Factor base units, e.g. a dus of 40
+// pcs, and it is sold at its own price.
+type Unit struct {
+	ID        int       `json:"id"`
+	ProductID int       `json:"product_id"`
+	Name      string    `json:"name"`
+	Factor    int       `json:"factor"`
+	Price     int       `json:"price"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type RequestUnit struct {
+	Name   string `json:"name"`
+	Factor int    `json:"factor"`
+	Price  int    `json:"price"`
+}
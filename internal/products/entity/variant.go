@@ -0,0 +1,28 @@
+package entity
+
+import "time"
+
+// Variant is one sellable version of a product, e.g. size M in red. Options holds one value for every variant
+// axis of the product and Name joins them in axis order, e.g. "M / Red".
+type Variant struct {
+	ID        int               `json:"id"`
+	ProductID int               `json:"product_id"`
+	Name      string            `json:"name"`
+	SKU       string            `json:"sku,omitempty"`
+	Barcodes  []string          `json:"barcodes"`
+	Options   map[string]string `json:"options"`
+	Price     int               `json:"price"`
+	Stock     int               `json:"stock"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// RequestVariant creates or updates a variant. On update missing barcodes keep the current ones and an empty list
+// removes them.
+type RequestVariant struct {
+	SKU      string            `json:"sku,omitempty"`
+	Barcodes []string          `json:"barcodes,omitempty"`
+	Options  map[string]string `json:"options"`
+	Price    int               `json:"price"`
+	Stock    int               `json:"stock"`
+}
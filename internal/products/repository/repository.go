@@ -1,11 +1,19 @@
 package repository
 
 import (
+	"database/sql"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"strings"
 
+	"github.com/lib/pq"
+	constants "github.com/pandusatrianura/kasir_api_service/constant"
 	"github.com/pandusatrianura/kasir_api_service/internal/products/entity"
 	"github.com/pandusatrianura/kasir_api_service/pkg/database"
 	"github.com/pandusatrianura/kasir_api_service/pkg/datetime"
+	"github.com/pandusatrianura/kasir_api_service/pkg/outbox"
+	"github.com/pandusatrianura/kasir_api_service/pkg/pagination"
 )
 
 type IProductRepository interface {
@@ -13,7 +21,28 @@ type IProductRepository interface {
 	UpdateProduct(id int64, product *entity.Product) error
 	DeleteProduct(id int64) error
 	GetProductByID(id int64) (*entity.ResponseProductWithCategories, error)
-	GetAllProducts(name string) ([]entity.ResponseProductWithCategories, error)
+	GetProductByBarcode(code string) (*entity.ResponseProductWithCategories, error)
+	GetProducts(filter entity.ProductFilter) ([]entity.ResponseProductWithCategories, int64, error)
+	CreateVariant(variant *entity.Variant) (*entity.Variant, error)
+	UpdateVariant(variant *entity.Variant) error
+	DeleteVariant(productID int64, variantID int64) error
+	GetVariant(productID int64, variantID int64) (*entity.Variant, error)
+	CreateUnit(unit *entity.Unit) (*entity.Unit, error)
+	UpdateUnit(unit *entity.Unit) error
+	DeleteUnit(productID int64, unitID int64) error
+	GetUnit(productID int64, unitID int64) (*entity.Unit, error)
+}
+
+// productColumns reads a product with its category; the barcodes of the product itself, not those of its variants,
+// are joined with commas and the variant axes are read as a JSON array.
+const productColumns = "products.id, products.name, COALESCE(products.sku, ''), COALESCE((SELECT string_agg(product_barcodes.code, ',' ORDER BY product_barcodes.id) FROM product_barcodes WHERE product_barcodes.product_id = products.id AND product_barcodes.variant_id IS NULL), ''), products.variant_axes::text, products.base_unit, products.price, products.stock, products.created_at, products.updated_at, categories.id as category_id, categories.name as category_name"
+
+// productSortColumns maps the sort fields of the product listing to their columns.
+var productSortColumns = map[string]string{
+	constants.ProductSortName:      "products.name",
+	constants.ProductSortPrice:     "products.price",
+	constants.ProductSortStock:     "products.stock",
+	constants.ProductSortUpdatedAt: "products.updated_at",
 }
 
 type ProductRepository struct {
@@ -30,17 +59,24 @@ func (r *ProductRepository) CreateProduct(product *entity.Product) error {
 		err   error
 	)
 
-	query = "INSERT INTO products (name, price, stock, category_id, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)"
+	query = "INSERT INTO products (name, sku, variant_axes, base_unit, price, stock, category_id, created_at, updated_at) VALUES ($1, NULLIF($2, ''), $3::jsonb, $4, $5, $6, $7, $8, $9) RETURNING id"
 
 	err = r.db.WithTx(func(tx *database.Tx) error {
-		return tx.WithStmt(query, func(stmt *database.Stmt) error {
-			_, err = stmt.Exec(product.Name, product.Price, product.Stock, product.CategoryID, "now()", "now()")
-			return err
+		var productID int64
+
+		err = tx.WithStmt(query, func(stmt *database.Stmt) error {
+			return stmt.QueryRow(product.Name, product.SKU, axesJSON(product.VariantAxes), product.BaseUnit, product.Price, product.Stock, product.CategoryID, "now()", "now()").Scan(&productID)
 		})
+
+		if err != nil {
+			return err
+		}
+
+		return replaceBarcodes(tx, productID, product.Barcodes)
 	})
 
 	if err != nil {
-		return err
+		return productError(err)
 	}
 
 	return nil
@@ -52,17 +88,37 @@ func (r *ProductRepository) UpdateProduct(id int64, product *entity.Product) err
 		err   error
 	)
 
-	query = "UPDATE products SET name = $1, price = $2, stock = $3, category_id = $4, updated_at = $5 WHERE id = $6"
+	// The stock of a product with variants is the total of its variants and only changes with them.
+	query = "UPDATE products SET name = $1, sku = NULLIF($2, ''), variant_axes = $3::jsonb, base_unit = $4, price = $5, stock = CASE WHEN EXISTS (SELECT 1 FROM product_variants WHERE product_variants.product_id = products.id) THEN products.stock ELSE $6 END, category_id = $7, updated_at = $8 WHERE id = $9"
 
 	err = r.db.WithTx(func(tx *database.Tx) error {
-		return tx.WithStmt(query, func(stmt *database.Stmt) error {
-			_, err := stmt.Exec(product.Name, product.Price, product.Stock, product.CategoryID, "now()", id)
+		var affected int64
+
+		err = tx.WithStmt(query, func(stmt *database.Stmt) error {
+			result, err := stmt.Exec(product.Name, product.SKU, axesJSON(product.VariantAxes), product.BaseUnit, product.Price, product.Stock, product.CategoryID, "now()", id)
+			if err != nil {
+				return err
+			}
+
+			affected, err = result.RowsAffected()
 			return err
 		})
+
+		if err != nil || affected == 0 {
+			return err
+		}
+
+		if err = replaceBarcodes(tx, id, product.Barcodes); err != nil {
+			return err
+		}
+
+		event := *product
+		event.ID = int(id)
+		return outbox.Write(tx, constants.EventProductUpdated, event)
 	})
 
 	if err != nil {
-		return err
+		return productError(err)
 	}
 
 	return nil
@@ -90,65 +146,167 @@ func (r *ProductRepository) DeleteProduct(id int64) error {
 	return nil
 }
 
-func (r *ProductRepository) GetAllProducts(name string) ([]entity.ResponseProductWithCategories, error) {
+// GetProducts lists one page of products. Sorting always ends on the id, so rows with the same sort value keep
+// their order from page to page.
+func (r *ProductRepository) GetProducts(filter entity.ProductFilter) ([]entity.ResponseProductWithCategories, int64, error) {
 	var (
 		query             string
 		products          []entity.ProductWithCategories
 		productCategories []entity.ResponseProductWithCategories
+		total             int64
+		conditions        []string
+		args              []interface{}
 		err               error
-		args              string
 	)
 
 	products = make([]entity.ProductWithCategories, 0)
 	productCategories = make([]entity.ResponseProductWithCategories, 0)
 
-	query = "SELECT products.id, products.name, products.price, products.stock, products.created_at, products.updated_at, categories.id as category_id, categories.name as category_name FROM products JOIN categories ON products.category_id = categories.id"
+	if filter.Name != "" {
+		args = append(args, "%"+filter.Name+"%")
+		conditions = append(conditions, fmt.Sprintf("products.name ILIKE $%d", len(args)))
+	}
+
+	if filter.CategoryID > 0 {
+		args = append(args, filter.CategoryID)
+		conditions = append(conditions, fmt.Sprintf("products.category_id = $%d", len(args)))
+	}
+
+	if filter.MinPrice > 0 {
+		args = append(args, filter.MinPrice)
+		conditions = append(conditions, fmt.Sprintf("products.price >= $%d", len(args)))
+	}
+
+	if filter.MaxPrice > 0 {
+		args = append(args, filter.MaxPrice)
+		conditions = append(conditions, fmt.Sprintf("products.price <= $%d", len(args)))
+	}
+
+	if filter.InStock != nil {
+		if *filter.InStock {
+			conditions = append(conditions, "products.stock > 0")
+		} else {
+			conditions = append(conditions, "products.stock <= 0")
+		}
+	}
 
-	if name != "" {
-		query += " WHERE products.name ILIKE $1"
-		args = "%" + name + "%"
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
 	}
 
+	query = "SELECT COUNT(products.id) FROM products JOIN categories ON products.category_id = categories.id" + where
+	err = r.db.WithStmt(query, func(stmt *database.Stmt) error {
+		return stmt.QueryRow(args...).Scan(&total)
+	})
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	order := "ASC"
+	if filter.Order == constants.SortOrderDesc {
+		order = "DESC"
+	}
+
+	sortColumn, ok := productSortColumns[filter.Sort]
+	if !ok {
+		sortColumn = productSortColumns[constants.ProductSortName]
+	}
+
+	query = fmt.Sprintf("SELECT %s FROM products JOIN categories ON products.category_id = categories.id%s ORDER BY %s %s, products.id %s LIMIT $%d OFFSET $%d", productColumns, where, sortColumn, order, order, len(args)+1, len(args)+2)
+	args = append(args, filter.Limit, pagination.Offset(filter.Page, filter.Limit))
+
 	err = r.db.WithStmt(query, func(stmt *database.Stmt) error {
 		scanFn := func(rows *database.Rows) error {
 			var product entity.ProductWithCategories
-			if err := rows.Scan(&product.ID, &product.Name, &product.Price, &product.Stock, &product.CreatedAt, &product.UpdatedAt, &product.CategoryID, &product.CategoryName); err != nil {
+			if err := scanProduct(rows, &product); err != nil {
 				return err
 			}
 			products = append(products, product)
 			return nil
 		}
 
-		if name != "" {
-			return stmt.Query(scanFn, args)
-		}
-		return stmt.Query(scanFn)
+		return stmt.Query(scanFn, args...)
 	})
 
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
+	ids := make([]int64, 0, len(products))
 	for _, product := range products {
-		createdAt, _ := datetime.ParseTime(product.CreatedAt)
-		updatedAt, _ := datetime.ParseTime(product.UpdatedAt)
-
-		productCategories = append(productCategories, entity.ResponseProductWithCategories{
-			ID:           product.ID,
-			Name:         product.Name,
-			Price:        product.Price,
-			Stock:        product.Stock,
-			CategoryName: product.CategoryName,
-			CategoryID:   product.CategoryID,
-			CreatedAt:    createdAt,
-			UpdatedAt:    updatedAt,
-		})
+		ids = append(ids, int64(product.ID))
+	}
+
+	variants, err := r.getVariants(ids)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	units, err := r.getUnits(ids)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	for _, product := range products {
+		productCategory := toResponse(product)
+		if productVariants, ok := variants[product.ID]; ok {
+			productCategory.Variants = productVariants
+		}
+
+		if productUnits, ok := units[product.ID]; ok {
+			productCategory.Units = productUnits
+		}
+
+		productCategories = append(productCategories, productCategory)
 	}
 
-	return productCategories, nil
+	return productCategories, total, nil
 }
 
 func (r *ProductRepository) GetProductByID(id int64) (*entity.ResponseProductWithCategories, error) {
+	return r.getProduct("products.id = $1", id)
+}
+
+// GetProductByBarcode finds the product a scanned barcode belongs to. When the barcode is one of a variant, that
+// variant is set on the product as well. The code must already be normalized.
+func (r *ProductRepository) GetProductByBarcode(code string) (*entity.ResponseProductWithCategories, error) {
+	var (
+		productID int64
+		variantID int
+		query     string
+		err       error
+	)
+
+	query = "SELECT product_id, COALESCE(variant_id, 0) FROM product_barcodes WHERE code = $1"
+	err = r.db.WithStmt(query, func(stmt *database.Stmt) error {
+		return stmt.QueryRow(code).Scan(&productID, &variantID)
+	})
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.New(constants.ErrProductNotFound)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	product, err := r.getProduct("products.id = $1", productID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range product.Variants {
+		if product.Variants[i].ID == variantID {
+			product.Variant = &product.Variants[i]
+		}
+	}
+
+	return product, nil
+}
+
+func (r *ProductRepository) getProduct(condition string, arg interface{}) (*entity.ResponseProductWithCategories, error) {
 	var (
 		product         entity.ProductWithCategories
 		productCategory entity.ResponseProductWithCategories
@@ -156,13 +314,13 @@ func (r *ProductRepository) GetProductByID(id int64) (*entity.ResponseProductWit
 		query           string
 	)
 
-	query = "SELECT products.id, products.name, products.price, products.stock, products.created_at, products.updated_at, categories.id as category_id, categories.name as category_name FROM products JOIN categories ON products.category_id = categories.id WHERE products.id = $1"
+	query = "SELECT " + productColumns + " FROM products JOIN categories ON products.category_id = categories.id WHERE " + condition
 
 	err = r.db.WithStmt(query, func(stmt *database.Stmt) error {
 		scanFn := func(rows *database.Rows) error {
-			return rows.Scan(&product.ID, &product.Name, &product.Price, &product.Stock, &product.CreatedAt, &product.UpdatedAt, &product.CategoryID, &product.CategoryName)
+			return scanProduct(rows, &product)
 		}
-		return stmt.Query(scanFn, id)
+		return stmt.Query(scanFn, arg)
 	})
 
 	if err != nil {
@@ -173,12 +331,76 @@ func (r *ProductRepository) GetProductByID(id int64) (*entity.ResponseProductWit
 		return nil, errors.New("product not found")
 	}
 
+	productCategory = toResponse(product)
+
+	variants, err := r.getVariants([]int64{int64(product.ID)})
+	if err != nil {
+		return nil, err
+	}
+
+	if productVariants, ok := variants[product.ID]; ok {
+		productCategory.Variants = productVariants
+	}
+
+	units, err := r.getUnits([]int64{int64(product.ID)})
+	if err != nil {
+		return nil, err
+	}
+
+	if productUnits, ok := units[product.ID]; ok {
+		productCategory.Units = productUnits
+	}
+
+	return &productCategory, nil
+}
+
+// replaceBarcodes swaps the barcodes of a product for the given ones, keeping their order. The barcodes of its
+// variants are left alone.
+func replaceBarcodes(tx *database.Tx, productID int64, barcodes []string) error {
+	err := tx.WithStmt("DELETE FROM product_barcodes WHERE product_id = $1 AND variant_id IS NULL", func(stmt *database.Stmt) error {
+		_, err := stmt.Exec(productID)
+		return err
+	})
+
+	if err != nil || len(barcodes) == 0 {
+		return err
+	}
+
+	query := "INSERT INTO product_barcodes (product_id, code, created_at) SELECT $1, code, $2 FROM unnest($3::varchar[]) WITH ORDINALITY AS barcodes(code, position) ORDER BY position"
+	return tx.WithStmt(query, func(stmt *database.Stmt) error {
+		_, err := stmt.Exec(productID, "now()", pq.Array(barcodes))
+		return err
+	})
+}
+
+// scanProduct reads a row selected with productColumns.
+func scanProduct(rows *database.Rows, product *entity.ProductWithCategories) error {
+	return rows.Scan(&product.ID, &product.Name, &product.SKU, &product.Barcodes, &product.VariantAxes, &product.BaseUnit, &product.Price, &product.Stock, &product.CreatedAt, &product.UpdatedAt, &product.CategoryID, &product.CategoryName)
+}
+
+func toResponse(product entity.ProductWithCategories) entity.ResponseProductWithCategories {
 	createdAt, _ := datetime.ParseTime(product.CreatedAt)
 	updatedAt, _ := datetime.ParseTime(product.UpdatedAt)
 
-	productCategory = entity.ResponseProductWithCategories{
+	barcodes := make([]string, 0)
+	if product.Barcodes != "" {
+		barcodes = strings.Split(product.Barcodes, ",")
+	}
+
+	axes := make([]string, 0)
+	if product.VariantAxes != "" {
+		_ = json.Unmarshal([]byte(product.VariantAxes), &axes)
+	}
+
+	return entity.ResponseProductWithCategories{
 		ID:           product.ID,
 		Name:         product.Name,
+		SKU:          product.SKU,
+		Barcodes:     barcodes,
+		VariantAxes:  axes,
+		Variants:     make([]entity.Variant, 0),
+		BaseUnit:     product.BaseUnit,
+		Units:        make([]entity.Unit, 0),
 		Price:        product.Price,
 		Stock:        product.Stock,
 		CategoryID:   product.CategoryID,
@@ -186,6 +408,14 @@ func (r *ProductRepository) GetProductByID(id int64) (*entity.ResponseProductWit
 		CreatedAt:    createdAt,
 		UpdatedAt:    updatedAt,
 	}
+}
 
-	return &productCategory, nil
+// productError reports a taken sku or barcode in words instead of as a database error.
+func productError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == database.UniqueViolation {
+		return errors.New(constants.ErrProductExists)
+	}
+
+	return err
 }
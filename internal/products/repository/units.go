@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/lib/pq"
+	constants "github.com/pandusatrianura/kasir_api_service/constant"
+	"github.com/pandusatrianura/kasir_api_service/internal/products/entity"
+	"github.com/pandusatrianura/kasir_api_service/pkg/database"
+	"github.com/pandusatrianura/kasir_api_service/pkg/datetime"
+)
+
+const unitColumns = "id, product_id, name, factor, price, created_at, updated_at"
+
+func (r *ProductRepository) CreateUnit(unit *entity.Unit) (*entity.Unit, error) {
+	var (
+		unitID int64
+		query  string
+		err    error
+	)
+
+	query = "INSERT INTO product_units (product_id, name, factor, price, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id"
+	err = r.db.WithStmt(query, func(stmt *database.Stmt) error {
+		return stmt.QueryRow(unit.ProductID, unit.Name, unit.Factor, unit.Price, "now()", "now()").Scan(&unitID)
+	})
+
+	if err != nil {
+		return nil, unitError(err)
+	}
+
+	return r.GetUnit(int64(unit.ProductID), unitID)
+}
+
+func (r *ProductRepository) UpdateUnit(unit *entity.Unit) error {
+	var (
+		query string
+		err   error
+	)
+
+	query = "UPDATE product_units SET name = $1, factor = $2, price = $3, updated_at = $4 WHERE id = $5 AND product_id = $6"
+	err = r.db.WithStmt(query, func(stmt *database.Stmt) error {
+		result, err := stmt.Exec(unit.Name, unit.Factor, unit.Price, "now()", unit.ID, unit.ProductID)
+		if err != nil {
+			return err
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if affected == 0 {
+			return errors.New(constants.ErrUnitNotFound)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return unitError(err)
+	}
+
+	return nil
+}
+
+func (r *ProductRepository) DeleteUnit(productID int64, unitID int64) error {
+	query := "DELETE FROM product_units WHERE id = $1 AND product_id = $2"
+	return r.db.WithStmt(query, func(stmt *database.Stmt) error {
+		result, err := stmt.Exec(unitID, productID)
+		if err != nil {
+			return err
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if affected == 0 {
+			return errors.New(constants.ErrUnitNotFound)
+		}
+
+		return nil
+	})
+}
+
+func (r *ProductRepository) GetUnit(productID int64, unitID int64) (*entity.Unit, error) {
+	var (
+		unit  entity.Unit
+		query string
+		err   error
+	)
+
+	query = "SELECT " + unitColumns + " FROM product_units WHERE id = $1 AND product_id = $2"
+	err = r.db.WithStmt(query, func(stmt *database.Stmt) error {
+		scanFn := func(rows *database.Rows) error {
+			return scanUnit(rows, &unit)
+		}
+
+		return stmt.Query(scanFn, unitID, productID)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if unit.ID == 0 {
+		return nil, errors.New(constants.ErrUnitNotFound)
+	}
+
+	return &unit, nil
+}
+
+// getUnits loads the units of the given products with one query, grouped by product and smallest first.
+func (r *ProductRepository) getUnits(productIDs []int64) (map[int][]entity.Unit, error) {
+	var (
+		units map[int][]entity.Unit
+		query string
+		err   error
+	)
+
+	units = make(map[int][]entity.Unit)
+	if len(productIDs) == 0 {
+		return units, nil
+	}
+
+	query = "SELECT " + unitColumns + " FROM product_units WHERE product_id = ANY($1) ORDER BY product_id, factor, id"
+	err = r.db.WithStmt(query, func(stmt *database.Stmt) error {
+		scanFn := func(rows *database.Rows) error {
+			var unit entity.Unit
+			if err := scanUnit(rows, &unit); err != nil {
+				return err
+			}
+
+			units[unit.ProductID] = append(units[unit.ProductID], unit)
+			return nil
+		}
+
+		return stmt.Query(scanFn, pq.Array(productIDs))
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return units, nil
+}
+
+func scanUnit(rows *database.Rows, unit *entity.Unit) error {
+	var (
+		createdAt string
+		updatedAt string
+	)
+
+	if err := rows.Scan(&unit.ID, &unit.ProductID, &unit.Name, &unit.Factor, &unit.Price, &createdAt, &updatedAt); err != nil {
+		return err
+	}
+
+	unit.CreatedAt, _ = datetime.ParseTime(createdAt)
+	unit.UpdatedAt, _ = datetime.ParseTime(updatedAt)
+
+	return nil
+}
+
+// unitError reports a unit name the product already has in words instead of as a database error.
+func unitError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == database.UniqueViolation {
+		return errors.New(constants.ErrUnitExists)
+	}
+
+	return err
+}
@@ -0,0 +1,309 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/lib/pq"
+	constants "github.com/pandusatrianura/kasir_api_service/constant"
+	"github.com/pandusatrianura/kasir_api_service/internal/products/entity"
+	"github.com/pandusatrianura/kasir_api_service/pkg/database"
+	"github.com/pandusatrianura/kasir_api_service/pkg/datetime"
+)
+
+// variantColumns reads a variant with its barcodes joined with commas.
+const variantColumns = "product_variants.id, product_variants.product_id, product_variants.name, COALESCE(product_variants.sku, ''), COALESCE((SELECT string_agg(product_barcodes.code, ',' ORDER BY product_barcodes.id) FROM product_barcodes WHERE product_barcodes.variant_id = product_variants.id), ''), product_variants.options::text, product_variants.price, product_variants.stock, product_variants.created_at, product_variants.updated_at"
+
+// CreateVariant adds a variant with its barcodes to a product and adds its stock to the stock of the product.
+func (r *ProductRepository) CreateVariant(variant *entity.Variant) (*entity.Variant, error) {
+	var (
+		variantID int64
+		query     string
+		err       error
+	)
+
+	options, err := json.Marshal(variant.Options)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.db.WithTx(func(tx *database.Tx) error {
+		if err = lockProduct(tx, variant.ProductID); err != nil {
+			return err
+		}
+
+		query = "INSERT INTO product_variants (product_id, name, sku, options, price, stock, created_at, updated_at) VALUES ($1, $2, NULLIF($3, ''), $4::jsonb, $5, $6, $7, $8) RETURNING id"
+		err = tx.WithStmt(query, func(stmt *database.Stmt) error {
+			return stmt.QueryRow(variant.ProductID, variant.Name, variant.SKU, string(options), variant.Price, variant.Stock, "now()", "now()").Scan(&variantID)
+		})
+
+		if err != nil {
+			return err
+		}
+
+		if err = replaceVariantBarcodes(tx, variant.ProductID, int(variantID), variant.Barcodes); err != nil {
+			return err
+		}
+
+		return syncProductStock(tx, variant.ProductID)
+	})
+
+	if err != nil {
+		return nil, variantError(err)
+	}
+
+	return r.GetVariant(int64(variant.ProductID), variantID)
+}
+
+func (r *ProductRepository) UpdateVariant(variant *entity.Variant) error {
+	var (
+		query string
+		err   error
+	)
+
+	options, err := json.Marshal(variant.Options)
+	if err != nil {
+		return err
+	}
+
+	err = r.db.WithTx(func(tx *database.Tx) error {
+		if err = lockProduct(tx, variant.ProductID); err != nil {
+			return err
+		}
+
+		query = "UPDATE product_variants SET name = $1, sku = NULLIF($2, ''), options = $3::jsonb, price = $4, stock = $5, updated_at = $6 WHERE id = $7 AND product_id = $8"
+		err = tx.WithStmt(query, func(stmt *database.Stmt) error {
+			result, err := stmt.Exec(variant.Name, variant.SKU, string(options), variant.Price, variant.Stock, "now()", variant.ID, variant.ProductID)
+			if err != nil {
+				return err
+			}
+
+			affected, err := result.RowsAffected()
+			if err != nil {
+				return err
+			}
+
+			if affected == 0 {
+				return errors.New(constants.ErrVariantNotFound)
+			}
+
+			return nil
+		})
+
+		if err != nil {
+			return err
+		}
+
+		if err = replaceVariantBarcodes(tx, variant.ProductID, variant.ID, variant.Barcodes); err != nil {
+			return err
+		}
+
+		return syncProductStock(tx, variant.ProductID)
+	})
+
+	if err != nil {
+		return variantError(err)
+	}
+
+	return nil
+}
+
+// DeleteVariant removes a variant and takes its stock off the stock of the product. Sold lines keep their snapshot.
+func (r *ProductRepository) DeleteVariant(productID int64, variantID int64) error {
+	var (
+		query string
+		err   error
+	)
+
+	err = r.db.WithTx(func(tx *database.Tx) error {
+		if err = lockProduct(tx, int(productID)); err != nil {
+			return err
+		}
+
+		query = "DELETE FROM product_variants WHERE id = $1 AND product_id = $2"
+		err = tx.WithStmt(query, func(stmt *database.Stmt) error {
+			result, err := stmt.Exec(variantID, productID)
+			if err != nil {
+				return err
+			}
+
+			affected, err := result.RowsAffected()
+			if err != nil {
+				return err
+			}
+
+			if affected == 0 {
+				return errors.New(constants.ErrVariantNotFound)
+			}
+
+			return nil
+		})
+
+		if err != nil {
+			return err
+		}
+
+		return syncProductStock(tx, int(productID))
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *ProductRepository) GetVariant(productID int64, variantID int64) (*entity.Variant, error) {
+	var (
+		variant entity.Variant
+		query   string
+		err     error
+	)
+
+	query = "SELECT " + variantColumns + " FROM product_variants WHERE product_variants.id = $1 AND product_variants.product_id = $2"
+	err = r.db.WithStmt(query, func(stmt *database.Stmt) error {
+		scanFn := func(rows *database.Rows) error {
+			return scanVariant(rows, &variant)
+		}
+
+		return stmt.Query(scanFn, variantID, productID)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if variant.ID == 0 {
+		return nil, errors.New(constants.ErrVariantNotFound)
+	}
+
+	return &variant, nil
+}
+
+// getVariants loads the variants of the given products with one query, grouped by product.
+func (r *ProductRepository) getVariants(productIDs []int64) (map[int][]entity.Variant, error) {
+	var (
+		variants map[int][]entity.Variant
+		query    string
+		err      error
+	)
+
+	variants = make(map[int][]entity.Variant)
+	if len(productIDs) == 0 {
+		return variants, nil
+	}
+
+	query = "SELECT " + variantColumns + " FROM product_variants WHERE product_variants.product_id = ANY($1) ORDER BY product_variants.product_id, product_variants.id"
+	err = r.db.WithStmt(query, func(stmt *database.Stmt) error {
+		scanFn := func(rows *database.Rows) error {
+			var variant entity.Variant
+			if err := scanVariant(rows, &variant); err != nil {
+				return err
+			}
+
+			variants[variant.ProductID] = append(variants[variant.ProductID], variant)
+			return nil
+		}
+
+		return stmt.Query(scanFn, pq.Array(productIDs))
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return variants, nil
+}
+
+// lockProduct locks the product row, so the stock of the product is only summed up once every checkout that
+// sells its variants has finished. Checkouts lock products before variants too, so the two never deadlock.
+func lockProduct(tx *database.Tx, productID int) error {
+	var id int
+
+	err := tx.WithStmt("SELECT id FROM products WHERE id = $1 FOR UPDATE", func(stmt *database.Stmt) error {
+		return stmt.QueryRow(productID).Scan(&id)
+	})
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return errors.New(constants.ErrProductNotFound)
+	}
+
+	return err
+}
+
+// replaceVariantBarcodes swaps the barcodes of a variant for the given ones, keeping their order.
+func replaceVariantBarcodes(tx *database.Tx, productID int, variantID int, barcodes []string) error {
+	err := tx.WithStmt("DELETE FROM product_barcodes WHERE variant_id = $1", func(stmt *database.Stmt) error {
+		_, err := stmt.Exec(variantID)
+		return err
+	})
+
+	if err != nil || len(barcodes) == 0 {
+		return err
+	}
+
+	query := "INSERT INTO product_barcodes (product_id, variant_id, code, created_at) SELECT $1, $2, code, $3 FROM unnest($4::varchar[]) WITH ORDINALITY AS barcodes(code, position) ORDER BY position"
+	return tx.WithStmt(query, func(stmt *database.Stmt) error {
+		_, err := stmt.Exec(productID, variantID, "now()", pq.Array(barcodes))
+		return err
+	})
+}
+
+// syncProductStock sets the stock of a product to the total stock of its variants.
+func syncProductStock(tx *database.Tx, productID int) error {
+	query := "UPDATE products SET stock = (SELECT COALESCE(SUM(stock), 0) FROM product_variants WHERE product_id = $1), updated_at = $2 WHERE id = $1"
+	return tx.WithStmt(query, func(stmt *database.Stmt) error {
+		_, err := stmt.Exec(productID, "now()")
+		return err
+	})
+}
+
+func scanVariant(rows *database.Rows, variant *entity.Variant) error {
+	var (
+		barcodes  string
+		options   string
+		createdAt string
+		updatedAt string
+	)
+
+	if err := rows.Scan(&variant.ID, &variant.ProductID, &variant.Name, &variant.SKU, &barcodes, &options, &variant.Price, &variant.Stock, &createdAt, &updatedAt); err != nil {
+		return err
+	}
+
+	variant.Barcodes = make([]string, 0)
+	if barcodes != "" {
+		variant.Barcodes = strings.Split(barcodes, ",")
+	}
+
+	variant.Options = make(map[string]string)
+	if err := json.Unmarshal([]byte(options), &variant.Options); err != nil {
+		return err
+	}
+
+	variant.CreatedAt, _ = datetime.ParseTime(createdAt)
+	variant.UpdatedAt, _ = datetime.ParseTime(updatedAt)
+
+	return nil
+}
+
+// axesJSON stores the variant axes of a product as a JSON array, empty when the product has none.
+func axesJSON(axes []string) string {
+	if len(axes) == 0 {
+		return "[]"
+	}
+
+	body, _ := json.Marshal(axes)
+	return string(body)
+}
+
+// variantError reports a taken sku or barcode or a repeated set of options in words instead of as a database error.
+func variantError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == database.UniqueViolation {
+		return errors.New(constants.ErrVariantExists)
+	}
+
+	return err
+}
@@ -2,13 +2,18 @@ package service
 
 import (
 	"errors"
+	"strings"
 
 	constants "github.com/pandusatrianura/kasir_api_service/constant"
 	categoryRepository "github.com/pandusatrianura/kasir_api_service/internal/categories/repository"
 	"github.com/pandusatrianura/kasir_api_service/internal/products/entity"
 	"github.com/pandusatrianura/kasir_api_service/internal/products/repository"
+	"github.com/pandusatrianura/kasir_api_service/pkg/barcode"
 )
 
+// maxSKULength matches the size of the sku column.
+const maxSKULength = 64
+
 type ProductService struct {
 	productRepository  repository.IProductRepository
 	categoryRepository categoryRepository.ICategoryRepository
@@ -19,7 +24,14 @@ type IProductService interface {
 	UpdateProduct(id int64, product *entity.RequestProduct) error
 	DeleteProduct(id int64) error
 	GetProductByID(id int64) (*entity.ResponseProductWithCategories, error)
-	GetAllProducts(name string) ([]entity.ResponseProductWithCategories, error)
+	GetProductByBarcode(code string) (*entity.ResponseProductWithCategories, error)
+	GetProducts(filter entity.ProductFilter) ([]entity.ResponseProductWithCategories, int64, error)
+	CreateVariant(productID int64, variant *entity.RequestVariant) (*entity.Variant, error)
+	UpdateVariant(productID int64, variantID int64, variant *entity.RequestVariant) (*entity.Variant, error)
+	DeleteVariant(productID int64, variantID int64) error
+	CreateUnit(productID int64, unit *entity.RequestUnit) (*entity.Unit, error)
+	UpdateUnit(productID int64, unitID int64, unit *entity.RequestUnit) (*entity.Unit, error)
+	DeleteUnit(productID int64, unitID int64) error
 	API() entity.HealthCheck
 }
 
@@ -50,11 +62,30 @@ func (s *ProductService) CreateProduct(requestProduct *entity.RequestProduct) er
 		CategoryID: requestProduct.CategoryID,
 	}
 
+	if requestProduct.SKU != nil {
+		product.SKU = *requestProduct.SKU
+	}
+
+	if err = identifyProduct(product, requestProduct.Barcodes); err != nil {
+		return err
+	}
+
+	if product.VariantAxes, err = variantAxes(requestProduct.VariantAxes); err != nil {
+		return err
+	}
+
+	product.BaseUnit = constants.DefaultBaseUnit
+	if requestProduct.BaseUnit != "" {
+		if product.BaseUnit, err = unitName(requestProduct.BaseUnit); err != nil {
+			return err
+		}
+	}
+
 	return s.productRepository.CreateProduct(product)
 }
 
 func (s *ProductService) UpdateProduct(id int64, requestProduct *entity.RequestProduct) error {
-	_, err := s.productRepository.GetProductByID(id)
+	current, err := s.productRepository.GetProductByID(id)
 	if err != nil {
 		return errors.New(constants.ErrProductNotFound)
 	}
@@ -69,6 +100,45 @@ func (s *ProductService) UpdateProduct(id int64, requestProduct *entity.RequestP
 		Price:      requestProduct.Price,
 		Stock:      requestProduct.Stock,
 		CategoryID: requestProduct.CategoryID,
+		SKU:        current.SKU,
+	}
+
+	if requestProduct.SKU != nil {
+		product.SKU = *requestProduct.SKU
+	}
+
+	barcodes := requestProduct.Barcodes
+	if barcodes == nil {
+		barcodes = current.Barcodes
+	}
+
+	if err = identifyProduct(product, barcodes); err != nil {
+		return err
+	}
+
+	product.VariantAxes = current.VariantAxes
+	if requestProduct.VariantAxes != nil {
+		if product.VariantAxes, err = variantAxes(requestProduct.VariantAxes); err != nil {
+			return err
+		}
+
+		// Existing variants have a value for every current axis, so the axes are fixed until they are removed.
+		if len(current.Variants) > 0 && !sameAxes(current.VariantAxes, product.VariantAxes) {
+			return errors.New(constants.ErrVariantAxesInUse)
+		}
+	}
+
+	product.BaseUnit = current.BaseUnit
+	if requestProduct.BaseUnit != "" {
+		if product.BaseUnit, err = unitName(requestProduct.BaseUnit); err != nil {
+			return err
+		}
+
+		for _, unit := range current.Units {
+			if unit.Name == product.BaseUnit {
+				return errors.New(constants.ErrUnitExists)
+			}
+		}
 	}
 
 	return s.productRepository.UpdateProduct(id, product)
@@ -88,6 +158,264 @@ func (s *ProductService) GetProductByID(id int64) (*entity.ResponseProductWithCa
 	return result, err
 }
 
-func (s *ProductService) GetAllProducts(name string) ([]entity.ResponseProductWithCategories, error) {
-	return s.productRepository.GetAllProducts(name)
+// GetProductByBarcode looks a product up by any of its barcodes or those of its variants. UPC-A codes are found
+// under their EAN-13 form.
+func (s *ProductService) GetProductByBarcode(code string) (*entity.ResponseProductWithCategories, error) {
+	code, err := barcode.Normalize(code)
+	if err != nil {
+		return nil, errors.New(constants.ErrInvalidBarcode)
+	}
+
+	result, err := s.productRepository.GetProductByBarcode(code)
+	return result, err
+}
+
+func (s *ProductService) GetProducts(filter entity.ProductFilter) ([]entity.ResponseProductWithCategories, int64, error) {
+	filter.Name = strings.TrimSpace(filter.Name)
+
+	return s.productRepository.GetProducts(filter)
+}
+
+// identifyProduct trims the sku and sets the normalized barcodes of the product.
+func identifyProduct(product *entity.Product, barcodes []string) error {
+	product.SKU = strings.TrimSpace(product.SKU)
+	if len(product.SKU) > maxSKULength {
+		return errors.New(constants.ErrInvalidSKU)
+	}
+
+	normalized, err := normalizeBarcodes(barcodes)
+	if err != nil {
+		return err
+	}
+
+	product.Barcodes = normalized
+	return nil
+}
+
+// normalizeBarcodes normalizes barcodes and checks them for a valid check digit. A barcode listed twice, even once
+// as UPC-A and once as EAN-13, is rejected.
+func normalizeBarcodes(barcodes []string) ([]string, error) {
+	result := make([]string, 0, len(barcodes))
+	seen := make(map[string]bool, len(barcodes))
+
+	for _, code := range barcodes {
+		code, err := barcode.Normalize(code)
+		if err != nil {
+			return nil, errors.New(constants.ErrInvalidBarcode)
+		}
+
+		if seen[code] {
+			return nil, errors.New(constants.ErrDuplicateBarcode)
+		}
+
+		seen[code] = true
+		result = append(result, code)
+	}
+
+	return result, nil
+}
+
+func (s *ProductService) CreateVariant(productID int64, requestVariant *entity.RequestVariant) (*entity.Variant, error) {
+	product, err := s.productRepository.GetProductByID(productID)
+	if err != nil {
+		return nil, errors.New(constants.ErrProductNotFound)
+	}
+
+	variant, err := newVariant(product, requestVariant)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.productRepository.CreateVariant(variant)
+}
+
+func (s *ProductService) UpdateVariant(productID int64, variantID int64, requestVariant *entity.RequestVariant) (*entity.Variant, error) {
+	product, err := s.productRepository.GetProductByID(productID)
+	if err != nil {
+		return nil, errors.New(constants.ErrProductNotFound)
+	}
+
+	variant, err := newVariant(product, requestVariant)
+	if err != nil {
+		return nil, err
+	}
+
+	if requestVariant.Barcodes == nil {
+		current, err := s.productRepository.GetVariant(productID, variantID)
+		if err != nil {
+			return nil, err
+		}
+
+		variant.Barcodes = current.Barcodes
+	}
+
+	variant.ID = int(variantID)
+	if err = s.productRepository.UpdateVariant(variant); err != nil {
+		return nil, err
+	}
+
+	return s.productRepository.GetVariant(productID, variantID)
+}
+
+func (s *ProductService) DeleteVariant(productID int64, variantID int64) error {
+	return s.productRepository.DeleteVariant(productID, variantID)
+}
+
+func (s *ProductService) CreateUnit(productID int64, requestUnit *entity.RequestUnit) (*entity.Unit, error) {
+	product, err := s.productRepository.GetProductByID(productID)
+	if err != nil {
+		return nil, errors.New(constants.ErrProductNotFound)
+	}
+
+	unit, err := newUnit(product, requestUnit)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.productRepository.CreateUnit(unit)
+}
+
+func (s *ProductService) UpdateUnit(productID int64, unitID int64, requestUnit *entity.RequestUnit) (*entity.Unit, error) {
+	product, err := s.productRepository.GetProductByID(productID)
+	if err != nil {
+		return nil, errors.New(constants.ErrProductNotFound)
+	}
+
+	unit, err := newUnit(product, requestUnit)
+	if err != nil {
+		return nil, err
+	}
+
+	unit.ID = int(unitID)
+	if err = s.productRepository.UpdateUnit(unit); err != nil {
+		return nil, err
+	}
+
+	return s.productRepository.GetUnit(productID, unitID)
+}
+
+func (s *ProductService) DeleteUnit(productID int64, unitID int64) error {
+	return s.productRepository.DeleteUnit(productID, unitID)
+}
+
+// newUnit validates a unit of a product. Its name must differ from the base unit, which always has a factor of one.
+func newUnit(product *entity.ResponseProductWithCategories, requestUnit *entity.RequestUnit) (*entity.Unit, error) {
+	name, err := unitName(requestUnit.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	if name == product.BaseUnit {
+		return nil, errors.New(constants.ErrUnitExists)
+	}
+
+	if requestUnit.Factor <= 1 {
+		return nil, errors.New(constants.ErrInvalidUnitFactor)
+	}
+
+	if requestUnit.Price < 0 {
+		return nil, errors.New(constants.ErrInvalidUnitPrice)
+	}
+
+	return &entity.Unit{
+		ProductID: product.ID,
+		Name:      name,
+		Factor:    requestUnit.Factor,
+		Price:     requestUnit.Price,
+	}, nil
+}
+
+// unitName trims and lowercases a unit name, so a unit is found however the cashier types it.
+func unitName(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" || len(name) > constants.MaxUnitLength {
+		return "", errors.New(constants.ErrInvalidUnitName)
+	}
+
+	return name, nil
+}
+
+// newVariant validates a variant against the axes of its product and names it after its options in axis order.
+func newVariant(product *entity.ResponseProductWithCategories, requestVariant *entity.RequestVariant) (*entity.Variant, error) {
+	if len(product.VariantAxes) == 0 {
+		return nil, errors.New(constants.ErrProductHasNoAxes)
+	}
+
+	if requestVariant.Price < 0 {
+		return nil, errors.New(constants.ErrInvalidVariantPrice)
+	}
+
+	requested := make(map[string]string, len(requestVariant.Options))
+	for axis, value := range requestVariant.Options {
+		requested[strings.ToLower(strings.TrimSpace(axis))] = value
+	}
+
+	if len(requested) != len(product.VariantAxes) {
+		return nil, errors.New(constants.ErrInvalidVariantOptions)
+	}
+
+	options := make(map[string]string, len(product.VariantAxes))
+	values := make([]string, 0, len(product.VariantAxes))
+
+	for _, axis := range product.VariantAxes {
+		value := strings.TrimSpace(requested[axis])
+		if value == "" {
+			return nil, errors.New(constants.ErrInvalidVariantOptions)
+		}
+
+		options[axis] = value
+		values = append(values, value)
+	}
+
+	sku := strings.TrimSpace(requestVariant.SKU)
+	if len(sku) > maxSKULength {
+		return nil, errors.New(constants.ErrInvalidSKU)
+	}
+
+	barcodes, err := normalizeBarcodes(requestVariant.Barcodes)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.Variant{
+		ProductID: product.ID,
+		Name:      strings.Join(values, " / "),
+		SKU:       sku,
+		Barcodes:  barcodes,
+		Options:   options,
+		Price:     requestVariant.Price,
+		Stock:     requestVariant.Stock,
+	}, nil
+}
+
+// variantAxes trims the variant axes of a product and rejects empty or repeated ones.
+func variantAxes(axes []string) ([]string, error) {
+	result := make([]string, 0, len(axes))
+	seen := make(map[string]bool, len(axes))
+
+	for _, axis := range axes {
+		axis = strings.ToLower(strings.TrimSpace(axis))
+		if axis == "" || seen[axis] {
+			return nil, errors.New(constants.ErrInvalidVariantAxes)
+		}
+
+		seen[axis] = true
+		result = append(result, axis)
+	}
+
+	return result, nil
+}
+
+func sameAxes(current []string, axes []string) bool {
+	if len(current) != len(axes) {
+		return false
+	}
+
+	for i := range current {
+		if current[i] != axes[i] {
+			return false
+		}
+	}
+
+	return true
 }
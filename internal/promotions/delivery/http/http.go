@@ -0,0 +1,241 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	constants "github.com/pandusatrianura/kasir_api_service/constant"
+	"github.com/pandusatrianura/kasir_api_service/internal/promotions/entity"
+	"github.com/pandusatrianura/kasir_api_service/internal/promotions/service"
+	"github.com/pandusatrianura/kasir_api_service/pkg/response"
+)
+
+type PromotionHandler struct {
+	service service.IPromotionService
+}
+
+func NewPromotionHandler(service service.IPromotionService) *PromotionHandler {
+	return &PromotionHandler{service: service}
+}
+
+// API godoc
+// @Summary Get health status of promotions API
+// @Description Get health status of promotions API
+// @Tags promotions
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 503 {object} map[string]string
+// @Router /api/promotions/health [get]
+func (h *PromotionHandler) API(w http.ResponseWriter, r *http.Request) {
+	var result response.APIResponse
+	svcHealthCheckResult := h.service.API()
+
+	if svcHealthCheckResult.IsHealthy {
+		result.Code = strconv.Itoa(constants.SuccessCode)
+		result.Message = fmt.Sprintf("%s is healthy", svcHealthCheckResult.Name)
+		response.WriteJSONResponse(w, http.StatusOK, result)
+		return
+	}
+
+	result.Code = strconv.Itoa(constants.ErrorCode)
+	result.Message = fmt.Sprintf("%s is not healthy", svcHealthCheckResult.Name)
+	response.WriteJSONResponse(w, http.StatusServiceUnavailable, result)
+	return
+}
+
+// CreatePromotion godoc
+// @Summary Create a new promotion
+// @Description Create a new promotion (percentage, fixed, buy_x_get_y, bundle, min_basket_percentage, min_basket_fixed)
+// @Tags promotions
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer <token>"
+// @Param X-API-Key header string true "your-secret-api-key-here"
+// @Param promotion body entity.RequestPromotion true "Promotion Data"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/promotions [post]
+func (h *PromotionHandler) CreatePromotion(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		response.Error(w, http.StatusMethodNotAllowed, constants.ErrorCode, constants.ErrInvalidMethod, nil)
+		return
+	}
+
+	role := r.Header.Get("X-User-Roles")
+	if role != constants.ManagerRole {
+		response.Error(w, http.StatusUnauthorized, constants.ErrorCode, constants.ErrRoleNotAuthorized, errors.New(fmt.Sprintf("%s", role)))
+		return
+	}
+
+	var requestPromotion entity.RequestPromotion
+	if err := response.ParseJSON(r, &requestPromotion); err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidPromotionReq, err)
+		return
+	}
+
+	if err := h.service.CreatePromotion(&requestPromotion); err != nil {
+		response.Error(w, http.StatusInternalServerError, constants.ErrorCode, "Promotion created failed", err)
+		return
+	}
+
+	response.Success(w, http.StatusCreated, constants.SuccessCode, "Promotion created successfully", nil)
+}
+
+// UpdatePromotion godoc
+// @Summary Update a promotion
+// @Description Update a promotion
+// @Tags promotions
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer <token>"
+// @Param X-API-Key header string true "your-secret-api-key-here"
+// @Param id path int true "Promotion ID"
+// @Param promotion body entity.RequestPromotion true "Promotion Data"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/promotions/{id} [put]
+func (h *PromotionHandler) UpdatePromotion(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		response.Error(w, http.StatusMethodNotAllowed, constants.ErrorCode, constants.ErrInvalidMethod, nil)
+		return
+	}
+
+	role := r.Header.Get("X-User-Roles")
+	if role != constants.ManagerRole {
+		response.Error(w, http.StatusUnauthorized, constants.ErrorCode, constants.ErrRoleNotAuthorized, errors.New(fmt.Sprintf("%s", role)))
+		return
+	}
+
+	var requestPromotion entity.RequestPromotion
+
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidPromotionID, err)
+		return
+	}
+
+	if err := response.ParseJSON(r, &requestPromotion); err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidPromotionReq, err)
+		return
+	}
+
+	if err := h.service.UpdatePromotion(int64(id), &requestPromotion); err != nil {
+		response.Error(w, http.StatusInternalServerError, constants.ErrorCode, "Promotion updated failed", err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, constants.SuccessCode, "Promotion updated successfully", nil)
+}
+
+// DeletePromotion godoc
+// @Summary Delete a promotion
+// @Description Delete a promotion
+// @Tags promotions
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer <token>"
+// @Param X-API-Key header string true "your-secret-api-key-here"
+// @Param id path int true "Promotion ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/promotions/{id} [delete]
+func (h *PromotionHandler) DeletePromotion(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		response.Error(w, http.StatusMethodNotAllowed, constants.ErrorCode, constants.ErrInvalidMethod, nil)
+		return
+	}
+
+	role := r.Header.Get("X-User-Roles")
+	if role != constants.ManagerRole {
+		response.Error(w, http.StatusUnauthorized, constants.ErrorCode, constants.ErrRoleNotAuthorized, errors.New(fmt.Sprintf("%s", role)))
+		return
+	}
+
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidPromotionID, err)
+		return
+	}
+
+	if err := h.service.DeletePromotion(int64(id)); err != nil {
+		response.Error(w, http.StatusInternalServerError, constants.ErrorCode, "Promotion delete failed", err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, constants.SuccessCode, "Promotion deleted successfully", nil)
+}
+
+// GetPromotionByID godoc
+// @Summary Get a promotion by ID
+// @Description Get a promotion by ID
+// @Tags promotions
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer <token>"
+// @Param X-API-Key header string true "your-secret-api-key-here"
+// @Param id path int true "Promotion ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/promotions/{id} [get]
+func (h *PromotionHandler) GetPromotionByID(w http.ResponseWriter, r *http.Request) {
+	role := r.Header.Get("X-User-Roles")
+	if role == "" {
+		response.Error(w, http.StatusUnauthorized, constants.ErrorCode, constants.ErrRoleNotAuthorized, errors.New(fmt.Sprintf("%s", role)))
+		return
+	}
+
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidPromotionID, err)
+		return
+	}
+
+	promotion, err := h.service.GetPromotionByID(int64(id))
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, constants.ErrorCode, "Promotion retrieved failed", err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, constants.SuccessCode, "Promotion retrieved successfully", promotion)
+}
+
+// GetAllPromotions godoc
+// @Summary Get all promotions
+// @Description Get all promotions, optionally only the ones currently active
+// @Tags promotions
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer <token>"
+// @Param X-API-Key header string true "your-secret-api-key-here"
+// @Param active query bool false "Only active promotions"
+// @Success 200 {object} map[string]interface{}
+// @Failure 500 {object} map[string]string
+// @Router /api/promotions [get]
+func (h *PromotionHandler) GetAllPromotions(w http.ResponseWriter, r *http.Request) {
+	role := r.Header.Get("X-User-Roles")
+	if role == "" {
+		response.Error(w, http.StatusUnauthorized, constants.ErrorCode, constants.ErrRoleNotAuthorized, errors.New(fmt.Sprintf("%s", role)))
+		return
+	}
+
+	activeOnly, _ := strconv.ParseBool(r.URL.Query().Get("active"))
+
+	promotions, err := h.service.GetAllPromotions(activeOnly)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, constants.ErrorCode, "Promotions retrieved failed", err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, constants.SuccessCode, "Promotions retrieved successfully", promotions)
+}
@@ -0,0 +1,315 @@
+// Package engine evaluates promotions against checkout lines. It is pure so the same rules price a
+// checkout, a quote or a held cart without touching the database.
+package engine
+
+import (
+	"time"
+
+	constants "github.com/pandusatrianura/kasir_api_service/constant"
+	"github.com/pandusatrianura/kasir_api_service/internal/promotions/entity"
+)
+
+const clockLayout = "15:04"
+
+// Line is one checkout line. Quantity and UnitPrice are in the unit the line is sold in and Factor is the number of
+// base units in one of it, one when it is not set; quantity based promotions count base units.
+type Line struct {
+	ProductID  int
+	CategoryID int
+	Quantity   int
+	Factor     int
+	UnitPrice  int
+}
+
+type Applied struct {
+	PromotionID   int    `json:"promotion_id"`
+	PromotionName string `json:"promotion_name"`
+	Amount        int    `json:"discount_amount"`
+}
+
+type Result struct {
+	Applied       [][]Applied
+	LineDiscounts []int
+	TotalDiscount int
+}
+
+// Apply picks the best line promotion for every product, then the best basket promotion for the
+// discounted basket, and spreads the basket discount over the lines so every discount is stored per line.
+func Apply(promotions []entity.Promotion, lines []Line, at time.Time) Result {
+	result := Result{
+		Applied:       make([][]Applied, len(lines)),
+		LineDiscounts: make([]int, len(lines)),
+	}
+
+	active := make([]entity.Promotion, 0)
+	for _, promotion := range promotions {
+		if IsActive(promotion, at) {
+			active = append(active, promotion)
+		}
+	}
+
+	for _, group := range groupLines(lines) {
+		var best Applied
+		for _, promotion := range active {
+			if isBasketPromotion(promotion) || !matches(promotion, lines[group[0]]) {
+				continue
+			}
+
+			if amount := lineDiscount(promotion, lines, group); amount > best.Amount {
+				best = Applied{PromotionID: promotion.ID, PromotionName: promotion.Name, Amount: amount}
+			}
+		}
+
+		if best.Amount > 0 {
+			spread(lines, group, &result, best)
+		}
+	}
+
+	basket := 0
+	for i, line := range lines {
+		basket += line.UnitPrice*line.Quantity - result.LineDiscounts[i]
+	}
+
+	var best Applied
+	for _, promotion := range active {
+		if !isBasketPromotion(promotion) || basket < promotion.MinBasketAmount {
+			continue
+		}
+
+		if amount := basketDiscount(promotion, basket); amount > best.Amount {
+			best = Applied{PromotionID: promotion.ID, PromotionName: promotion.Name, Amount: amount}
+		}
+	}
+
+	if best.Amount > 0 {
+		Prorate(lines, &result, best, basket)
+	}
+
+	for _, discount := range result.LineDiscounts {
+		result.TotalDiscount += discount
+	}
+
+	return result
+}
+
+// Prorate spreads a basket level discount over the lines by their share of the basket, giving the
+// rounding remainder to the last line that still has something left to discount.
+func Prorate(lines []Line, result *Result, basketPromotion Applied, basket int) {
+	if basket <= 0 {
+		return
+	}
+
+	if basketPromotion.Amount > basket {
+		basketPromotion.Amount = basket
+	}
+
+	last := -1
+	remaining := basketPromotion.Amount
+	for i, line := range lines {
+		net := line.UnitPrice*line.Quantity - result.LineDiscounts[i]
+		if net <= 0 {
+			continue
+		}
+
+		share := basketPromotion.Amount * net / basket
+		remaining -= share
+		last = i
+
+		if share > 0 {
+			result.Applied[i] = append(result.Applied[i], Applied{PromotionID: basketPromotion.PromotionID, PromotionName: basketPromotion.PromotionName, Amount: share})
+			result.LineDiscounts[i] += share
+		}
+	}
+
+	if last >= 0 && remaining > 0 {
+		applied := result.Applied[last]
+		if len(applied) > 0 && applied[len(applied)-1].PromotionID == basketPromotion.PromotionID {
+			applied[len(applied)-1].Amount += remaining
+		} else {
+			result.Applied[last] = append(applied, Applied{PromotionID: basketPromotion.PromotionID, PromotionName: basketPromotion.PromotionName, Amount: remaining})
+		}
+		result.LineDiscounts[last] += remaining
+	}
+}
+
+// IsActive reports whether the promotion is switched on and inside its date range and daily time window at the given time.
+func IsActive(promotion entity.Promotion, at time.Time) bool {
+	if !promotion.IsActive {
+		return false
+	}
+
+	if promotion.StartAt != nil && *promotion.StartAt != "" {
+		startAt, err := time.Parse(time.RFC3339, *promotion.StartAt)
+		if err != nil || at.Before(startAt) {
+			return false
+		}
+	}
+
+	if promotion.EndAt != nil && *promotion.EndAt != "" {
+		endAt, err := time.Parse(time.RFC3339, *promotion.EndAt)
+		if err != nil || at.After(endAt) {
+			return false
+		}
+	}
+
+	if promotion.StartTime == "" || promotion.EndTime == "" {
+		return true
+	}
+
+	startTime, err := time.Parse(clockLayout, promotion.StartTime)
+	if err != nil {
+		return false
+	}
+
+	endTime, err := time.Parse(clockLayout, promotion.EndTime)
+	if err != nil {
+		return false
+	}
+
+	minute := at.Hour()*60 + at.Minute()
+	start := startTime.Hour()*60 + startTime.Minute()
+	end := endTime.Hour()*60 + endTime.Minute()
+
+	if start <= end {
+		return minute >= start && minute < end
+	}
+
+	// Overnight windows such as 22:00-02:00 wrap around midnight.
+	return minute >= start || minute < end
+}
+
+func isBasketPromotion(promotion entity.Promotion) bool {
+	return promotion.Type == constants.PromotionTypeMinBasketPercentage || promotion.Type == constants.PromotionTypeMinBasketFixed
+}
+
+func matches(promotion entity.Promotion, line Line) bool {
+	if promotion.ProductID != 0 && promotion.ProductID != line.ProductID {
+		return false
+	}
+
+	if promotion.CategoryID != 0 && promotion.CategoryID != line.CategoryID {
+		return false
+	}
+
+	return true
+}
+
+// groupLines groups the lines by product, in the order the products first appear, so a product rung up
+// over several lines is discounted as if it were one line.
+func groupLines(lines []Line) [][]int {
+	groups := make([][]int, 0, len(lines))
+	index := make(map[int]int)
+
+	for i, line := range lines {
+		if line.ProductID == 0 {
+			groups = append(groups, []int{i})
+			continue
+		}
+
+		g, ok := index[line.ProductID]
+		if !ok {
+			index[line.ProductID] = len(groups)
+			groups = append(groups, []int{i})
+			continue
+		}
+
+		groups[g] = append(groups[g], i)
+	}
+
+	return groups
+}
+
+// lineDiscount is the discount of a promotion on the lines of one product, with quantities counted in base units
+// so a carton of 40 counts as 40 pieces. Free and bundled items are valued at the average price of a base unit of
+// the product over its lines.
+func lineDiscount(promotion entity.Promotion, lines []Line, group []int) int {
+	subtotal, quantity := 0, 0
+	for _, i := range group {
+		subtotal += lines[i].UnitPrice * lines[i].Quantity
+		quantity += lines[i].baseQuantity()
+	}
+
+	if quantity <= 0 {
+		return 0
+	}
+
+	discount := 0
+
+	switch promotion.Type {
+	case constants.PromotionTypePercentage:
+		discount = subtotal * promotion.Value / 100
+	case constants.PromotionTypeFixed:
+		discount = promotion.Value * quantity
+	case constants.PromotionTypeBuyXGetY:
+		size := promotion.BuyQuantity + promotion.GetQuantity
+		if promotion.BuyQuantity > 0 && promotion.GetQuantity > 0 {
+			discount = subtotal * (quantity / size * promotion.GetQuantity) / quantity
+		}
+	case constants.PromotionTypeBundle:
+		if promotion.BundleQuantity > 0 {
+			saving := subtotal*promotion.BundleQuantity/quantity - promotion.BundlePrice
+			if saving > 0 {
+				discount = quantity / promotion.BundleQuantity * saving
+			}
+		}
+	}
+
+	if discount > subtotal {
+		return subtotal
+	}
+
+	return discount
+}
+
+// baseQuantity is the quantity of the line in base units.
+func (l Line) baseQuantity() int {
+	if l.Factor <= 1 {
+		return l.Quantity
+	}
+
+	return l.Quantity * l.Factor
+}
+
+// spread splits the discount of a product over its lines by their share of its subtotal, giving the
+// rounding remainder to the last line.
+func spread(lines []Line, group []int, result *Result, promotion Applied) {
+	subtotal := 0
+	for _, i := range group {
+		subtotal += lines[i].UnitPrice * lines[i].Quantity
+	}
+
+	if subtotal <= 0 {
+		return
+	}
+
+	remaining := promotion.Amount
+	for n, i := range group {
+		share := promotion.Amount * lines[i].UnitPrice * lines[i].Quantity / subtotal
+		if n == len(group)-1 {
+			share = remaining
+		}
+
+		remaining -= share
+		if share > 0 {
+			result.Applied[i] = append(result.Applied[i], Applied{PromotionID: promotion.PromotionID, PromotionName: promotion.PromotionName, Amount: share})
+			result.LineDiscounts[i] += share
+		}
+	}
+}
+
+func basketDiscount(promotion entity.Promotion, basket int) int {
+	discount := 0
+
+	switch promotion.Type {
+	case constants.PromotionTypeMinBasketPercentage:
+		discount = basket * promotion.Value / 100
+	case constants.PromotionTypeMinBasketFixed:
+		discount = promotion.Value
+	}
+
+	if discount > basket {
+		return basket
+	}
+
+	return discount
+}
@@ -0,0 +1,240 @@
+package engine
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	constants "github.com/pandusatrianura/kasir_api_service/constant"
+	"github.com/pandusatrianura/kasir_api_service/internal/promotions/entity"
+)
+
+func stringPtr(value string) *string {
+	return &value
+}
+
+func TestApply(t *testing.T) {
+	at := time.Date(2026, 2, 4, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		promotions []entity.Promotion
+		lines      []Line
+		want       []int
+	}{
+		{
+			name:       "no promotions",
+			promotions: nil,
+			lines:      []Line{{ProductID: 1, Quantity: 2, UnitPrice: 10000}},
+			want:       []int{0},
+		},
+		{
+			name:       "percentage",
+			promotions: []entity.Promotion{{ID: 1, Type: constants.PromotionTypePercentage, ProductID: 1, Value: 10, IsActive: true}},
+			lines:      []Line{{ProductID: 1, Quantity: 2, UnitPrice: 10000}, {ProductID: 2, Quantity: 1, UnitPrice: 5000}},
+			want:       []int{2000, 0},
+		},
+		{
+			name:       "fixed per unit",
+			promotions: []entity.Promotion{{ID: 1, Type: constants.PromotionTypeFixed, ProductID: 1, Value: 500, IsActive: true}},
+			lines:      []Line{{ProductID: 1, Quantity: 3, UnitPrice: 4000}},
+			want:       []int{1500},
+		},
+		{
+			name:       "fixed is capped at the subtotal",
+			promotions: []entity.Promotion{{ID: 1, Type: constants.PromotionTypeFixed, ProductID: 1, Value: 5000, IsActive: true}},
+			lines:      []Line{{ProductID: 1, Quantity: 2, UnitPrice: 3000}},
+			want:       []int{6000},
+		},
+		{
+			name:       "category",
+			promotions: []entity.Promotion{{ID: 1, Type: constants.PromotionTypePercentage, CategoryID: 7, Value: 50, IsActive: true}},
+			lines:      []Line{{ProductID: 1, CategoryID: 7, Quantity: 1, UnitPrice: 8000}, {ProductID: 2, CategoryID: 8, Quantity: 1, UnitPrice: 8000}},
+			want:       []int{4000, 0},
+		},
+		{
+			name:       "buy x get y",
+			promotions: []entity.Promotion{{ID: 1, Type: constants.PromotionTypeBuyXGetY, ProductID: 1, BuyQuantity: 2, GetQuantity: 1, IsActive: true}},
+			lines:      []Line{{ProductID: 1, Quantity: 7, UnitPrice: 1000}},
+			want:       []int{2000},
+		},
+		{
+			name:       "buy x get y below the threshold",
+			promotions: []entity.Promotion{{ID: 1, Type: constants.PromotionTypeBuyXGetY, ProductID: 1, BuyQuantity: 2, GetQuantity: 1, IsActive: true}},
+			lines:      []Line{{ProductID: 1, Quantity: 2, UnitPrice: 1000}},
+			want:       []int{0},
+		},
+		{
+			name:       "buy x get y over split lines",
+			promotions: []entity.Promotion{{ID: 1, Type: constants.PromotionTypeBuyXGetY, ProductID: 1, BuyQuantity: 2, GetQuantity: 1, IsActive: true}},
+			lines:      []Line{{ProductID: 1, Quantity: 1, UnitPrice: 1000}, {ProductID: 2, Quantity: 1, UnitPrice: 5000}, {ProductID: 1, Quantity: 1, UnitPrice: 1000}, {ProductID: 1, Quantity: 1, UnitPrice: 1000}},
+			want:       []int{333, 0, 333, 334},
+		},
+		{
+			name:       "fixed per base unit of a carton",
+			promotions: []entity.Promotion{{ID: 1, Type: constants.PromotionTypeFixed, ProductID: 1, Value: 100, IsActive: true}},
+			lines:      []Line{{ProductID: 1, Quantity: 2, Factor: 40, UnitPrice: 100000}},
+			want:       []int{8000},
+		},
+		{
+			name:       "buy x get y counts base units",
+			promotions: []entity.Promotion{{ID: 1, Type: constants.PromotionTypeBuyXGetY, ProductID: 1, BuyQuantity: 2, GetQuantity: 1, IsActive: true}},
+			lines:      []Line{{ProductID: 1, Quantity: 1, Factor: 6, UnitPrice: 5400}, {ProductID: 1, Quantity: 1, UnitPrice: 1000}},
+			want:       []int{1542, 286},
+		},
+		{
+			name:       "bundle",
+			promotions: []entity.Promotion{{ID: 1, Type: constants.PromotionTypeBundle, ProductID: 1, BundleQuantity: 3, BundlePrice: 2500, IsActive: true}},
+			lines:      []Line{{ProductID: 1, Quantity: 7, UnitPrice: 1000}},
+			want:       []int{1000},
+		},
+		{
+			name:       "bundle over split lines",
+			promotions: []entity.Promotion{{ID: 1, Type: constants.PromotionTypeBundle, ProductID: 1, BundleQuantity: 3, BundlePrice: 2500, IsActive: true}},
+			lines:      []Line{{ProductID: 1, Quantity: 2, UnitPrice: 1000}, {ProductID: 1, Quantity: 1, UnitPrice: 1000}},
+			want:       []int{333, 167},
+		},
+		{
+			name:       "bundle counts base units",
+			promotions: []entity.Promotion{{ID: 1, Type: constants.PromotionTypeBundle, ProductID: 1, BundleQuantity: 3, BundlePrice: 2500, IsActive: true}},
+			lines:      []Line{{ProductID: 1, Quantity: 1, Factor: 6, UnitPrice: 6000}},
+			want:       []int{1000},
+		},
+		{
+			name:       "bundle priced above the items",
+			promotions: []entity.Promotion{{ID: 1, Type: constants.PromotionTypeBundle, ProductID: 1, BundleQuantity: 2, BundlePrice: 3000, IsActive: true}},
+			lines:      []Line{{ProductID: 1, Quantity: 2, UnitPrice: 1000}},
+			want:       []int{0},
+		},
+		{
+			name: "best line promotion wins",
+			promotions: []entity.Promotion{
+				{ID: 1, Type: constants.PromotionTypePercentage, ProductID: 1, Value: 10, IsActive: true},
+				{ID: 2, Type: constants.PromotionTypeBuyXGetY, ProductID: 1, BuyQuantity: 1, GetQuantity: 1, IsActive: true},
+			},
+			lines: []Line{{ProductID: 1, Quantity: 2, UnitPrice: 1000}},
+			want:  []int{1000},
+		},
+		{
+			name:       "minimum basket fixed is prorated",
+			promotions: []entity.Promotion{{ID: 1, Type: constants.PromotionTypeMinBasketFixed, Value: 1000, MinBasketAmount: 30000, IsActive: true}},
+			lines:      []Line{{ProductID: 1, Quantity: 1, UnitPrice: 20000}, {ProductID: 2, Quantity: 1, UnitPrice: 10000}},
+			want:       []int{666, 334},
+		},
+		{
+			name:       "minimum basket not reached",
+			promotions: []entity.Promotion{{ID: 1, Type: constants.PromotionTypeMinBasketPercentage, Value: 10, MinBasketAmount: 50000, IsActive: true}},
+			lines:      []Line{{ProductID: 1, Quantity: 1, UnitPrice: 20000}},
+			want:       []int{0},
+		},
+		{
+			name:       "switched off",
+			promotions: []entity.Promotion{{ID: 1, Type: constants.PromotionTypePercentage, ProductID: 1, Value: 10}},
+			lines:      []Line{{ProductID: 1, Quantity: 1, UnitPrice: 10000}},
+			want:       []int{0},
+		},
+		{
+			name:       "outside the time window",
+			promotions: []entity.Promotion{{ID: 1, Type: constants.PromotionTypePercentage, ProductID: 1, Value: 10, StartTime: "17:00", EndTime: "21:00", IsActive: true}},
+			lines:      []Line{{ProductID: 1, Quantity: 1, UnitPrice: 10000}},
+			want:       []int{0},
+		},
+		{
+			name:       "inside the time window",
+			promotions: []entity.Promotion{{ID: 1, Type: constants.PromotionTypePercentage, ProductID: 1, Value: 10, StartTime: "11:00", EndTime: "14:00", IsActive: true}},
+			lines:      []Line{{ProductID: 1, Quantity: 1, UnitPrice: 10000}},
+			want:       []int{1000},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := Apply(test.promotions, test.lines, at)
+
+			if !reflect.DeepEqual(result.LineDiscounts, test.want) {
+				t.Fatalf("line discounts = %v, want %v", result.LineDiscounts, test.want)
+			}
+
+			total := 0
+			for _, discount := range test.want {
+				total += discount
+			}
+
+			if result.TotalDiscount != total {
+				t.Fatalf("total discount = %d, want %d", result.TotalDiscount, total)
+			}
+		})
+	}
+}
+
+func TestIsActive(t *testing.T) {
+	tests := []struct {
+		name      string
+		promotion entity.Promotion
+		at        time.Time
+		want      bool
+	}{
+		{
+			name:      "always on",
+			promotion: entity.Promotion{IsActive: true},
+			at:        time.Date(2026, 2, 4, 12, 0, 0, 0, time.UTC),
+			want:      true,
+		},
+		{
+			name:      "switched off",
+			promotion: entity.Promotion{},
+			at:        time.Date(2026, 2, 4, 12, 0, 0, 0, time.UTC),
+			want:      false,
+		},
+		{
+			name:      "before the start date",
+			promotion: entity.Promotion{IsActive: true, StartAt: stringPtr("2026-02-05T00:00:00Z")},
+			at:        time.Date(2026, 2, 4, 12, 0, 0, 0, time.UTC),
+			want:      false,
+		},
+		{
+			name:      "after the end date",
+			promotion: entity.Promotion{IsActive: true, EndAt: stringPtr("2026-02-03T23:59:59Z")},
+			at:        time.Date(2026, 2, 4, 12, 0, 0, 0, time.UTC),
+			want:      false,
+		},
+		{
+			name:      "inside the date range",
+			promotion: entity.Promotion{IsActive: true, StartAt: stringPtr("2026-02-01T00:00:00Z"), EndAt: stringPtr("2026-02-28T23:59:59Z")},
+			at:        time.Date(2026, 2, 4, 12, 0, 0, 0, time.UTC),
+			want:      true,
+		},
+		{
+			name:      "end of the daily window is exclusive",
+			promotion: entity.Promotion{IsActive: true, StartTime: "10:00", EndTime: "12:00"},
+			at:        time.Date(2026, 2, 4, 12, 0, 0, 0, time.UTC),
+			want:      false,
+		},
+		{
+			name:      "overnight window before midnight",
+			promotion: entity.Promotion{IsActive: true, StartTime: "22:00", EndTime: "02:00"},
+			at:        time.Date(2026, 2, 4, 23, 30, 0, 0, time.UTC),
+			want:      true,
+		},
+		{
+			name:      "overnight window after midnight",
+			promotion: entity.Promotion{IsActive: true, StartTime: "22:00", EndTime: "02:00"},
+			at:        time.Date(2026, 2, 4, 1, 30, 0, 0, time.UTC),
+			want:      true,
+		},
+		{
+			name:      "outside the overnight window",
+			promotion: entity.Promotion{IsActive: true, StartTime: "22:00", EndTime: "02:00"},
+			at:        time.Date(2026, 2, 4, 12, 0, 0, 0, time.UTC),
+			want:      false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := IsActive(test.promotion, test.at); got != test.want {
+				t.Fatalf("IsActive() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
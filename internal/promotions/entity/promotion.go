@@ -0,0 +1,47 @@
+package entity
+
+type HealthCheck struct {
+	Name      string `json:"name"`
+	IsHealthy bool   `json:"is_healthy"`
+}
+
+// Promotion is a discount rule evaluated at checkout. Value is a percentage for the percentage types
+// and an amount in rupiah for the fixed types (per unit for line discounts, per basket otherwise).
+type Promotion struct {
+	ID              int     `json:"id"`
+	Name            string  `json:"name"`
+	Type            string  `json:"type"`
+	ProductID       int     `json:"product_id,omitempty"`
+	CategoryID      int     `json:"category_id,omitempty"`
+	Value           int     `json:"value"`
+	BuyQuantity     int     `json:"buy_quantity,omitempty"`
+	GetQuantity     int     `json:"get_quantity,omitempty"`
+	BundleQuantity  int     `json:"bundle_quantity,omitempty"`
+	BundlePrice     int     `json:"bundle_price,omitempty"`
+	MinBasketAmount int     `json:"min_basket_amount,omitempty"`
+	StartAt         *string `json:"start_at,omitempty"`
+	EndAt           *string `json:"end_at,omitempty"`
+	StartTime       string  `json:"start_time,omitempty"`
+	EndTime         string  `json:"end_time,omitempty"`
+	IsActive        bool    `json:"is_active"`
+	CreatedAt       string  `json:"created_at,omitempty"`
+	UpdatedAt       string  `json:"updated_at,omitempty"`
+}
+
+type RequestPromotion struct {
+	Name            string  `json:"name"`
+	Type            string  `json:"type"`
+	ProductID       int     `json:"product_id"`
+	CategoryID      int     `json:"category_id"`
+	Value           int     `json:"value"`
+	BuyQuantity     int     `json:"buy_quantity"`
+	GetQuantity     int     `json:"get_quantity"`
+	BundleQuantity  int     `json:"bundle_quantity"`
+	BundlePrice     int     `json:"bundle_price"`
+	MinBasketAmount int     `json:"min_basket_amount"`
+	StartAt         *string `json:"start_at"`
+	EndAt           *string `json:"end_at"`
+	StartTime       string  `json:"start_time"`
+	EndTime         string  `json:"end_time"`
+	IsActive        bool    `json:"is_active"`
+}
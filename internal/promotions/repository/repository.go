@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	constants "github.com/pandusatrianura/kasir_api_service/constant"
+	"github.com/pandusatrianura/kasir_api_service/internal/promotions/entity"
+	"github.com/pandusatrianura/kasir_api_service/pkg/database"
+)
+
+const promotionColumns = "id, name, type, COALESCE(product_id, 0), COALESCE(category_id, 0), value, buy_quantity, get_quantity, bundle_quantity, bundle_price, min_basket_amount, start_at, end_at, COALESCE(start_time, ''), COALESCE(end_time, ''), is_active, created_at, updated_at"
+
+type IPromotionRepository interface {
+	CreatePromotion(promotion *entity.Promotion) error
+	UpdatePromotion(id int64, promotion *entity.Promotion) error
+	DeletePromotion(id int64) error
+	GetPromotionByID(id int64) (*entity.Promotion, error)
+	GetAllPromotions(activeOnly bool) ([]entity.Promotion, error)
+}
+
+type PromotionRepository struct {
+	db *database.DB
+}
+
+func NewPromotionRepository(db *database.DB) IPromotionRepository {
+	return &PromotionRepository{db: db}
+}
+
+func (r *PromotionRepository) CreatePromotion(promotion *entity.Promotion) error {
+	var (
+		query string
+		err   error
+	)
+
+	query = "INSERT INTO promotions (name, type, product_id, category_id, value, buy_quantity, get_quantity, bundle_quantity, bundle_price, min_basket_amount, start_at, end_at, start_time, end_time, is_active, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)"
+
+	err = r.db.WithTx(func(tx *database.Tx) error {
+		return tx.WithStmt(query, func(stmt *database.Stmt) error {
+			_, err = stmt.Exec(promotion.Name, promotion.Type, nullableID(promotion.ProductID), nullableID(promotion.CategoryID), promotion.Value, promotion.BuyQuantity, promotion.GetQuantity, promotion.BundleQuantity, promotion.BundlePrice, promotion.MinBasketAmount, promotion.StartAt, promotion.EndAt, nullableString(promotion.StartTime), nullableString(promotion.EndTime), promotion.IsActive, "now()", "now()")
+			return err
+		})
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *PromotionRepository) UpdatePromotion(id int64, promotion *entity.Promotion) error {
+	var (
+		query string
+		err   error
+	)
+
+	query = "UPDATE promotions SET name = $1, type = $2, product_id = $3, category_id = $4, value = $5, buy_quantity = $6, get_quantity = $7, bundle_quantity = $8, bundle_price = $9, min_basket_amount = $10, start_at = $11, end_at = $12, start_time = $13, end_time = $14, is_active = $15, updated_at = $16 WHERE id = $17"
+
+	err = r.db.WithTx(func(tx *database.Tx) error {
+		return tx.WithStmt(query, func(stmt *database.Stmt) error {
+			_, err := stmt.Exec(promotion.Name, promotion.Type, nullableID(promotion.ProductID), nullableID(promotion.CategoryID), promotion.Value, promotion.BuyQuantity, promotion.GetQuantity, promotion.BundleQuantity, promotion.BundlePrice, promotion.MinBasketAmount, promotion.StartAt, promotion.EndAt, nullableString(promotion.StartTime), nullableString(promotion.EndTime), promotion.IsActive, "now()", id)
+			return err
+		})
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *PromotionRepository) DeletePromotion(id int64) error {
+	var (
+		query string
+		err   error
+	)
+
+	// Promotions already applied to transactions are kept for history, so deleting only switches them off.
+	query = "UPDATE promotions SET is_active = false, deleted_at = $1, updated_at = $2 WHERE id = $3"
+
+	err = r.db.WithTx(func(tx *database.Tx) error {
+		return tx.WithStmt(query, func(stmt *database.Stmt) error {
+			_, err = stmt.Exec("now()", "now()", id)
+			return err
+		})
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *PromotionRepository) GetPromotionByID(id int64) (*entity.Promotion, error) {
+	var (
+		promotion entity.Promotion
+		query     string
+		err       error
+	)
+
+	query = fmt.Sprintf("SELECT %s FROM promotions WHERE id = $1 AND deleted_at IS NULL", promotionColumns)
+
+	err = r.db.WithStmt(query, func(stmt *database.Stmt) error {
+		scanFn := func(rows *database.Rows) error {
+			return scanPromotion(rows, &promotion)
+		}
+
+		return stmt.Query(scanFn, id)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if promotion.ID == 0 {
+		return nil, errors.New(constants.ErrPromotionNotFound)
+	}
+
+	return &promotion, nil
+}
+
+func (r *PromotionRepository) GetAllPromotions(activeOnly bool) ([]entity.Promotion, error) {
+	var (
+		promotions []entity.Promotion
+		query      string
+		err        error
+	)
+
+	promotions = make([]entity.Promotion, 0)
+
+	query = fmt.Sprintf("SELECT %s FROM promotions WHERE deleted_at IS NULL", promotionColumns)
+	if activeOnly {
+		query += " AND is_active = true AND (start_at IS NULL OR start_at <= now()) AND (end_at IS NULL OR end_at >= now())"
+	}
+	query += " ORDER BY id"
+
+	err = r.db.WithStmt(query, func(stmt *database.Stmt) error {
+		scanFn := func(rows *database.Rows) error {
+			var promotion entity.Promotion
+			if err := scanPromotion(rows, &promotion); err != nil {
+				return err
+			}
+
+			promotions = append(promotions, promotion)
+			return nil
+		}
+
+		return stmt.Query(scanFn)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return promotions, nil
+}
+
+func scanPromotion(rows *database.Rows, promotion *entity.Promotion) error {
+	return rows.Scan(&promotion.ID, &promotion.Name, &promotion.Type, &promotion.ProductID, &promotion.CategoryID, &promotion.Value, &promotion.BuyQuantity, &promotion.GetQuantity, &promotion.BundleQuantity, &promotion.BundlePrice, &promotion.MinBasketAmount, &promotion.StartAt, &promotion.EndAt, &promotion.StartTime, &promotion.EndTime, &promotion.IsActive, &promotion.CreatedAt, &promotion.UpdatedAt)
+}
+
+func nullableID(id int) interface{} {
+	if id == 0 {
+		return nil
+	}
+
+	return id
+}
+
+func nullableString(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+
+	return value
+}
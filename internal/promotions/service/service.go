@@ -0,0 +1,178 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	constants "github.com/pandusatrianura/kasir_api_service/constant"
+	"github.com/pandusatrianura/kasir_api_service/internal/promotions/entity"
+	"github.com/pandusatrianura/kasir_api_service/internal/promotions/repository"
+)
+
+type IPromotionService interface {
+	CreatePromotion(request *entity.RequestPromotion) error
+	UpdatePromotion(id int64, request *entity.RequestPromotion) error
+	DeletePromotion(id int64) error
+	GetPromotionByID(id int64) (*entity.Promotion, error)
+	GetAllPromotions(activeOnly bool) ([]entity.Promotion, error)
+	API() entity.HealthCheck
+}
+
+type PromotionService struct {
+	promotionRepository repository.IPromotionRepository
+}
+
+func NewPromotionService(promotionRepository repository.IPromotionRepository) IPromotionService {
+	return &PromotionService{promotionRepository: promotionRepository}
+}
+
+func (s *PromotionService) API() entity.HealthCheck {
+	return entity.HealthCheck{
+		Name:      "Promotions API",
+		IsHealthy: true,
+	}
+}
+
+func (s *PromotionService) CreatePromotion(request *entity.RequestPromotion) error {
+	promotion, err := toPromotion(request)
+	if err != nil {
+		return err
+	}
+
+	return s.promotionRepository.CreatePromotion(promotion)
+}
+
+func (s *PromotionService) UpdatePromotion(id int64, request *entity.RequestPromotion) error {
+	_, err := s.promotionRepository.GetPromotionByID(id)
+	if err != nil {
+		return errors.New(constants.ErrPromotionNotFound)
+	}
+
+	promotion, err := toPromotion(request)
+	if err != nil {
+		return err
+	}
+
+	return s.promotionRepository.UpdatePromotion(id, promotion)
+}
+
+func (s *PromotionService) DeletePromotion(id int64) error {
+	_, err := s.promotionRepository.GetPromotionByID(id)
+	if err != nil {
+		return errors.New(constants.ErrPromotionNotFound)
+	}
+
+	return s.promotionRepository.DeletePromotion(id)
+}
+
+func (s *PromotionService) GetPromotionByID(id int64) (*entity.Promotion, error) {
+	return s.promotionRepository.GetPromotionByID(id)
+}
+
+func (s *PromotionService) GetAllPromotions(activeOnly bool) ([]entity.Promotion, error) {
+	return s.promotionRepository.GetAllPromotions(activeOnly)
+}
+
+func toPromotion(request *entity.RequestPromotion) (*entity.Promotion, error) {
+	if strings.TrimSpace(request.Name) == "" {
+		return nil, errors.New("promotion name is required")
+	}
+
+	switch request.Type {
+	case constants.PromotionTypePercentage, constants.PromotionTypeMinBasketPercentage:
+		if request.Value <= 0 || request.Value > 100 {
+			return nil, errors.New("percentage value must be between 1 and 100")
+		}
+	case constants.PromotionTypeFixed, constants.PromotionTypeMinBasketFixed:
+		if request.Value <= 0 {
+			return nil, errors.New("fixed value must be greater than zero")
+		}
+	case constants.PromotionTypeBuyXGetY:
+		if request.BuyQuantity <= 0 || request.GetQuantity <= 0 {
+			return nil, errors.New("buy_quantity and get_quantity must be greater than zero")
+		}
+	case constants.PromotionTypeBundle:
+		if request.BundleQuantity <= 1 || request.BundlePrice <= 0 {
+			return nil, errors.New("bundle_quantity must be greater than one and bundle_price greater than zero")
+		}
+	default:
+		return nil, fmt.Errorf("invalid promotion type: %s", request.Type)
+	}
+
+	if (request.Type == constants.PromotionTypeBuyXGetY || request.Type == constants.PromotionTypeBundle) && request.ProductID == 0 {
+		return nil, errors.New("product_id is required for buy_x_get_y and bundle promotions")
+	}
+
+	if request.MinBasketAmount < 0 {
+		return nil, errors.New("min_basket_amount cannot be negative")
+	}
+
+	startAt, err := parseDate(request.StartAt)
+	if err != nil {
+		return nil, err
+	}
+
+	endAt, err := parseDate(request.EndAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if startAt == nil {
+		request.StartAt = nil
+	}
+
+	if endAt == nil {
+		request.EndAt = nil
+	}
+
+	if startAt != nil && endAt != nil && endAt.Before(*startAt) {
+		return nil, errors.New("end_at cannot be before start_at")
+	}
+
+	if (request.StartTime == "") != (request.EndTime == "") {
+		return nil, errors.New("start_time and end_time must be set together")
+	}
+
+	for _, clock := range []string{request.StartTime, request.EndTime} {
+		if clock == "" {
+			continue
+		}
+
+		if _, err := time.Parse("15:04", clock); err != nil {
+			return nil, fmt.Errorf("invalid time %q, expected HH:MM", clock)
+		}
+	}
+
+	return &entity.Promotion{
+		Name:            request.Name,
+		Type:            request.Type,
+		ProductID:       request.ProductID,
+		CategoryID:      request.CategoryID,
+		Value:           request.Value,
+		BuyQuantity:     request.BuyQuantity,
+		GetQuantity:     request.GetQuantity,
+		BundleQuantity:  request.BundleQuantity,
+		BundlePrice:     request.BundlePrice,
+		MinBasketAmount: request.MinBasketAmount,
+		StartAt:         request.StartAt,
+		EndAt:           request.EndAt,
+		StartTime:       request.StartTime,
+		EndTime:         request.EndTime,
+		IsActive:        request.IsActive,
+	}, nil
+}
+
+func parseDate(value *string) (*time.Time, error) {
+	if value == nil || *value == "" {
+		return nil, nil
+	}
+
+	parsed, err := time.Parse(time.RFC3339, *value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date %q, expected RFC3339", *value)
+	}
+
+	return &parsed, nil
+}
@@ -178,3 +178,98 @@ func (h *ReportHandler) Report(w http.ResponseWriter, r *http.Request) {
 
 	response.Success(w, http.StatusOK, constants.SuccessCode, "Report received successfully", report)
 }
+
+// Cashier godoc
+// @Summary Get sales report per cashier with or without Date Range (Default Today)
+// @Description Get revenue, transaction count, average basket and voids per cashier (Manager only)
+// @Tags reports
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer <token>"
+// @Param X-API-Key header string true "your-secret-api-key-here"
+// @Param start_date query string false "Start Date"
+// @Param end_date query string false "End Date"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/reports/cashiers [get]
+func (h *ReportHandler) Cashier(w http.ResponseWriter, r *http.Request) {
+	role := r.Header.Get("X-User-Roles")
+	if role != constants.ManagerRole {
+		response.Error(w, http.StatusUnauthorized, constants.ErrorCode, constants.ErrRoleNotAuthorized, errors.New(fmt.Sprintf("%s", role)))
+		return
+	}
+
+	startUTC, endUTC, err := reportRange(r.URL.Query().Get("start_date"), r.URL.Query().Get("end_date"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrReportRequest, err)
+		return
+	}
+
+	log.Printf("Search cashier report with start date: %v until end date: %v\n", startUTC, endUTC)
+
+	report, err := h.service.CashierReport(startUTC, endUTC)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, constants.ErrorCode, "Report received failed", err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, constants.SuccessCode, "Report received successfully", report)
+}
+
+// ReceivablesAging godoc
+// @Summary Get the aging of customer receivables
+// @Description Get what every customer still owes for sales paid on credit, split into 0-30, 31-60, 61-90 and over 90 days (Manager only)
+// @Tags reports
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer <token>"
+// @Param X-API-Key header string true "your-secret-api-key-here"
+// @Success 200 {object} map[string]interface{}
+// @Failure 500 {object} map[string]string
+// @Router /api/reports/receivables-aging [get]
+func (h *ReportHandler) ReceivablesAging(w http.ResponseWriter, r *http.Request) {
+	role := r.Header.Get("X-User-Roles")
+	if role != constants.ManagerRole {
+		response.Error(w, http.StatusUnauthorized, constants.ErrorCode, constants.ErrRoleNotAuthorized, errors.New(fmt.Sprintf("%s", role)))
+		return
+	}
+
+	report, err := h.service.ReceivablesAging()
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, constants.ErrorCode, "Report received failed", err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, constants.SuccessCode, "Report received successfully", report)
+}
+
+// reportRange turns the start and end dates (YYYY-MM-DD, Jakarta time) into a UTC range covering whole days.
+// Today is used when neither date is given.
+func reportRange(startDate string, endDate string) (string, string, error) {
+	if startDate == "" && endDate == "" {
+		timeNow, err := datetime.ParseTime(time.Now().Format(time.RFC3339))
+		if err != nil {
+			return "", "", err
+		}
+
+		startDate = timeNow.Format("2006-01-02")
+		endDate = startDate
+	}
+
+	startUTC, err := datetime.ParseUTC(fmt.Sprintf("%s 00:00:00", startDate))
+	if err != nil {
+		return "", "", err
+	}
+
+	endUTC, err := datetime.ParseUTC(fmt.Sprintf("%s 23:59:59", endDate))
+	if err != nil {
+		return "", "", err
+	}
+
+	if startUTC > endUTC {
+		return "", "", errors.New(constants.ErrStarDate)
+	}
+
+	return startUTC, endUTC, nil
+}
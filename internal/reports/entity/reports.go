@@ -5,9 +5,26 @@ type HealthCheck struct {
 	IsHealthy bool   `json:"is_healthy"`
 }
 type ReportTransaction struct {
-	TotalRevenue      int64             `json:"total_revenue"`
-	TotalTransactions int               `json:"total_transactions"`
-	MostSoldProduct   []MostSoldProduct `json:"most_sold_product"`
+	TotalRevenue       int64             `json:"total_revenue"`
+	TotalTransactions  int               `json:"total_transactions"`
+	TotalVoidedAmount  int64             `json:"total_voided_amount"`
+	TotalVoided        int               `json:"total_voided_transactions"`
+	TotalReturnAmount  int64             `json:"total_return_amount"`
+	TotalReturns       int               `json:"total_returns"`
+	NetRevenue         int64             `json:"net_revenue"`
+	TotalNetSales      int64             `json:"total_net_sales"`
+	TotalTax           int64             `json:"total_tax"`
+	TotalServiceCharge int64             `json:"total_service_charge"`
+	TotalDiscount      int64             `json:"total_discount"`
+	Promotions         []PromotionUsage  `json:"promotions"`
+	MostSoldProduct    []MostSoldProduct `json:"most_sold_product"`
+}
+
+type PromotionUsage struct {
+	PromotionID       int    `json:"promotion_id"`
+	PromotionName     string `json:"promotion_name"`
+	TotalTransactions int    `json:"total_transactions"`
+	DiscountAmount    int64  `json:"discount_amount"`
 }
 
 type MostSoldProduct struct {
@@ -15,3 +32,40 @@ type MostSoldProduct struct {
 	ProductID int    `json:"id,omitempty"`
 	QtySold   int    `json:"quantity_sold"`
 }
+
+type CashierReport struct {
+	UserID            int    `json:"user_id"`
+	Name              string `json:"name"`
+	TotalRevenue      int64  `json:"total_revenue"`
+	TotalTransactions int    `json:"total_transactions"`
+	AverageBasket     int64  `json:"average_basket"`
+	TotalVoidedAmount int64  `json:"total_voided_amount"`
+	TotalVoided       int    `json:"total_voided_transactions"`
+}
+
+// ReceivablesAging splits what customers still owe for sales paid on credit by the age of those sales.
+// Repayments pay off the oldest sales first, so the outstanding balance always belongs to the newest ones.
+type ReceivablesAging struct {
+	TotalOutstanding int64           `json:"total_outstanding"`
+	TotalCustomers   int             `json:"total_customers"`
+	Buckets          AgingBuckets    `json:"buckets"`
+	Customers        []CustomerAging `json:"customers"`
+}
+
+// AgingBuckets holds outstanding amounts by the number of days since the sale.
+type AgingBuckets struct {
+	Days0To30  int64 `json:"days_0_30"`
+	Days31To60 int64 `json:"days_31_60"`
+	Days61To90 int64 `json:"days_61_90"`
+	Over90     int64 `json:"over_90"`
+}
+
+type CustomerAging struct {
+	CustomerID       int          `json:"customer_id"`
+	Name             string       `json:"name"`
+	Phone            string       `json:"phone,omitempty"`
+	CreditLimit      int          `json:"credit_limit"`
+	Balance          int64        `json:"balance"`
+	OldestUnpaidDays int          `json:"oldest_unpaid_days"`
+	Buckets          AgingBuckets `json:"buckets"`
+}
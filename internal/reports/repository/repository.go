@@ -3,6 +3,7 @@ package repository
 import (
 	"database/sql"
 	"errors"
+	"math"
 
 	constants "github.com/pandusatrianura/kasir_api_service/constant"
 	"github.com/pandusatrianura/kasir_api_service/internal/reports/entity"
@@ -11,6 +12,24 @@ import (
 
 type IReportsRepository interface {
 	Report(startDate string, endDate string) (*entity.ReportTransaction, error)
+	CashierReport(startDate string, endDate string) ([]entity.CashierReport, error)
+	ReceivablesAging() (*entity.ReceivablesAging, error)
+}
+
+type voidSummary struct {
+	amount int64
+	count  int
+}
+
+type returnSummary struct {
+	amount int64
+	count  int
+}
+
+type taxSummary struct {
+	net           int64
+	tax           int64
+	serviceCharge int64
 }
 
 type ReportsRepository struct {
@@ -27,6 +46,11 @@ func (r *ReportsRepository) Report(startDate string, endDate string) (*entity.Re
 	var (
 		totalRevenue     int
 		totalTransaction int
+		voids            voidSummary
+		returns          returnSummary
+		taxes            taxSummary
+		totalDiscount    int64
+		promotions       []entity.PromotionUsage
 		soldsProduct     []entity.MostSoldProduct
 		err              error
 	)
@@ -42,6 +66,26 @@ func (r *ReportsRepository) Report(startDate string, endDate string) (*entity.Re
 		return nil, err
 	}
 
+	voids, err = r.getVoidedTransaction(startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	returns, err = r.getReturns(startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	taxes, err = r.getTaxSummary(startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	totalDiscount, promotions, err = r.getPromotionUsage(startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
 	soldsProduct, err = r.getMostSoldProduct(startDate, endDate)
 	if err != nil {
 		return nil, err
@@ -53,14 +97,165 @@ func (r *ReportsRepository) Report(startDate string, endDate string) (*entity.Re
 	}
 
 	report := entity.ReportTransaction{
-		TotalRevenue:      int64(totalRevenue),
-		TotalTransactions: totalTransaction,
-		MostSoldProduct:   soldsProduct,
+		TotalRevenue:       int64(totalRevenue),
+		TotalTransactions:  totalTransaction,
+		TotalVoidedAmount:  voids.amount,
+		TotalVoided:        voids.count,
+		TotalReturnAmount:  returns.amount,
+		TotalReturns:       returns.count,
+		NetRevenue:         int64(totalRevenue) - returns.amount,
+		TotalNetSales:      taxes.net,
+		TotalTax:           taxes.tax,
+		TotalServiceCharge: taxes.serviceCharge,
+		TotalDiscount:      totalDiscount,
+		Promotions:         promotions,
+		MostSoldProduct:    soldsProduct,
 	}
 
 	return &report, nil
 }
 
+// CashierReport breaks revenue, transaction count, average basket and voids down per cashier. Sales recorded
+// before cashiers were tracked are grouped under user id 0.
+func (r *ReportsRepository) CashierReport(startDate string, endDate string) ([]entity.CashierReport, error) {
+	var (
+		cashiers []entity.CashierReport
+		query    string
+		err      error
+	)
+
+	cashiers = make([]entity.CashierReport, 0)
+
+	if startDate == "" || endDate == "" {
+		return nil, errors.New(constants.ErrRequiredDate)
+	}
+
+	query = "SELECT COALESCE(a.user_id, 0), COALESCE(b.name, ''), COALESCE(SUM(a.total_amount) FILTER (WHERE a.status <> 'voided'), 0) AS total_revenue, COUNT(a.id) FILTER (WHERE a.status <> 'voided'), COALESCE(SUM(a.total_amount) FILTER (WHERE a.status = 'voided'), 0), COUNT(a.id) FILTER (WHERE a.status = 'voided') FROM transactions a LEFT JOIN users b ON a.user_id = b.id WHERE a.created_at BETWEEN $1 AND $2 GROUP BY a.user_id, b.name ORDER BY total_revenue DESC"
+
+	err = r.db.WithStmt(query, func(stmt *database.Stmt) error {
+		scanFn := func(rows *database.Rows) error {
+			var cashier entity.CashierReport
+			if err := rows.Scan(&cashier.UserID, &cashier.Name, &cashier.TotalRevenue, &cashier.TotalTransactions, &cashier.TotalVoidedAmount, &cashier.TotalVoided); err != nil {
+				return err
+			}
+
+			if cashier.TotalTransactions > 0 {
+				cashier.AverageBasket = int64(math.Round(float64(cashier.TotalRevenue) / float64(cashier.TotalTransactions)))
+			}
+
+			cashiers = append(cashiers, cashier)
+			return nil
+		}
+
+		return stmt.Query(scanFn, startDate, endDate)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return cashiers, nil
+}
+
+// ReceivablesAging ages the outstanding balance of every customer that owes money. The balance is spread over the
+// credit sales of the customer from the newest back, since repayments settle the oldest sales first. Sales that
+// were voided are left out, their charge was already reversed.
+func (r *ReportsRepository) ReceivablesAging() (*entity.ReceivablesAging, error) {
+	var (
+		customers []entity.CustomerAging
+		index     map[int]int
+		remaining map[int]int64
+		query     string
+		err       error
+	)
+
+	customers = make([]entity.CustomerAging, 0)
+	index = make(map[int]int)
+	remaining = make(map[int]int64)
+
+	query = "SELECT id, name, COALESCE(phone, ''), credit_limit, receivable_balance FROM customers WHERE receivable_balance > 0 ORDER BY receivable_balance DESC, id"
+	err = r.db.WithStmt(query, func(stmt *database.Stmt) error {
+		scanFn := func(rows *database.Rows) error {
+			var customer entity.CustomerAging
+			if err := rows.Scan(&customer.CustomerID, &customer.Name, &customer.Phone, &customer.CreditLimit, &customer.Balance); err != nil {
+				return err
+			}
+
+			index[customer.CustomerID] = len(customers)
+			remaining[customer.CustomerID] = customer.Balance
+			customers = append(customers, customer)
+			return nil
+		}
+
+		return stmt.Query(scanFn)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	query = "SELECT a.customer_id, a.amount, now()::date - COALESCE(b.created_at, a.created_at)::date FROM customer_receivables_ledger a JOIN customers c ON c.id = a.customer_id LEFT JOIN transactions b ON b.id = a.transaction_id WHERE a.type = $1 AND COALESCE(b.status, '') <> $2 AND c.receivable_balance > 0 ORDER BY a.customer_id, COALESCE(b.created_at, a.created_at) DESC, a.id DESC"
+	err = r.db.WithStmt(query, func(stmt *database.Stmt) error {
+		scanFn := func(rows *database.Rows) error {
+			var (
+				customerID int
+				amount     int64
+				days       int
+			)
+
+			if err := rows.Scan(&customerID, &amount, &days); err != nil {
+				return err
+			}
+
+			i, ok := index[customerID]
+			if !ok || remaining[customerID] == 0 {
+				return nil
+			}
+
+			unpaid := min(amount, remaining[customerID])
+			remaining[customerID] -= unpaid
+			addToBucket(&customers[i].Buckets, days, unpaid)
+			customers[i].OldestUnpaidDays = days
+			return nil
+		}
+
+		return stmt.Query(scanFn, constants.ReceivableTypeCharge, constants.TransactionStatusVoided)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	aging := entity.ReceivablesAging{Customers: customers, TotalCustomers: len(customers)}
+	for i := range customers {
+		// A balance not covered by any credit sale can only come from data fixed by hand; it is treated as the oldest.
+		if left := remaining[customers[i].CustomerID]; left > 0 {
+			addToBucket(&customers[i].Buckets, math.MaxInt32, left)
+		}
+
+		aging.TotalOutstanding += customers[i].Balance
+		aging.Buckets.Days0To30 += customers[i].Buckets.Days0To30
+		aging.Buckets.Days31To60 += customers[i].Buckets.Days31To60
+		aging.Buckets.Days61To90 += customers[i].Buckets.Days61To90
+		aging.Buckets.Over90 += customers[i].Buckets.Over90
+	}
+
+	return &aging, nil
+}
+
+func addToBucket(buckets *entity.AgingBuckets, days int, amount int64) {
+	switch {
+	case days <= 30:
+		buckets.Days0To30 += amount
+	case days <= 60:
+		buckets.Days31To60 += amount
+	case days <= 90:
+		buckets.Days61To90 += amount
+	default:
+		buckets.Over90 += amount
+	}
+}
+
 func (r *ReportsRepository) getRevenueAndTransaction(startDate string, endDate string) (int, int, error) {
 	var (
 		totalRevenue     int
@@ -73,7 +268,7 @@ func (r *ReportsRepository) getRevenueAndTransaction(startDate string, endDate s
 		return 0, 0, errors.New(constants.ErrRequiredDate)
 	}
 
-	query = "SELECT SUM(total_amount) AS total_revenue, COUNT(id) AS total_transaction FROM transactions WHERE created_at BETWEEN $1 AND $2"
+	query = "SELECT COALESCE(SUM(total_amount), 0) AS total_revenue, COUNT(id) AS total_transaction FROM transactions WHERE created_at BETWEEN $1 AND $2 AND status <> 'voided'"
 
 	err = r.db.WithStmt(query, func(stmt *database.Stmt) error {
 		scanFn := func(rows *database.Rows) error {
@@ -100,6 +295,125 @@ func (r *ReportsRepository) getRevenueAndTransaction(startDate string, endDate s
 	return totalRevenue, totalTransaction, nil
 }
 
+func (r *ReportsRepository) getVoidedTransaction(startDate string, endDate string) (voidSummary, error) {
+	var (
+		voids voidSummary
+		query string
+		err   error
+	)
+
+	query = "SELECT COALESCE(SUM(total_amount), 0) AS total_voided_amount, COUNT(id) AS total_voided FROM transactions WHERE created_at BETWEEN $1 AND $2 AND status = 'voided'"
+
+	err = r.db.WithStmt(query, func(stmt *database.Stmt) error {
+		scanFn := func(rows *database.Rows) error {
+			return rows.Scan(&voids.amount, &voids.count)
+		}
+
+		return stmt.Query(scanFn, startDate, endDate)
+	})
+
+	if err != nil {
+		return voidSummary{}, err
+	}
+
+	return voids, nil
+}
+
+func (r *ReportsRepository) getReturns(startDate string, endDate string) (returnSummary, error) {
+	var (
+		returns returnSummary
+		query   string
+		err     error
+	)
+
+	query = "SELECT COALESCE(SUM(a.refund_amount), 0) AS total_return_amount, COUNT(a.id) AS total_returns FROM transaction_returns a JOIN transactions b ON a.transaction_id = b.id WHERE a.created_at BETWEEN $1 AND $2 AND b.status <> 'voided'"
+
+	err = r.db.WithStmt(query, func(stmt *database.Stmt) error {
+		scanFn := func(rows *database.Rows) error {
+			return rows.Scan(&returns.amount, &returns.count)
+		}
+
+		return stmt.Query(scanFn, startDate, endDate)
+	})
+
+	if err != nil {
+		return returnSummary{}, err
+	}
+
+	return returns, nil
+}
+
+func (r *ReportsRepository) getTaxSummary(startDate string, endDate string) (taxSummary, error) {
+	var (
+		taxes taxSummary
+		query string
+		err   error
+	)
+
+	query = "SELECT COALESCE(SUM(net_amount), 0) AS total_net, COALESCE(SUM(tax_amount), 0) AS total_tax, COALESCE(SUM(service_charge_amount), 0) AS total_service_charge FROM transactions WHERE created_at BETWEEN $1 AND $2 AND status <> 'voided'"
+
+	err = r.db.WithStmt(query, func(stmt *database.Stmt) error {
+		scanFn := func(rows *database.Rows) error {
+			return rows.Scan(&taxes.net, &taxes.tax, &taxes.serviceCharge)
+		}
+
+		return stmt.Query(scanFn, startDate, endDate)
+	})
+
+	if err != nil {
+		return taxSummary{}, err
+	}
+
+	return taxes, nil
+}
+
+func (r *ReportsRepository) getPromotionUsage(startDate string, endDate string) (int64, []entity.PromotionUsage, error) {
+	var (
+		totalDiscount int64
+		promotions    []entity.PromotionUsage
+		query         string
+		err           error
+	)
+
+	promotions = make([]entity.PromotionUsage, 0)
+
+	query = "SELECT COALESCE(SUM(discount_amount), 0) AS total_discount FROM transactions WHERE created_at BETWEEN $1 AND $2 AND status <> 'voided'"
+
+	err = r.db.WithStmt(query, func(stmt *database.Stmt) error {
+		scanFn := func(rows *database.Rows) error {
+			return rows.Scan(&totalDiscount)
+		}
+
+		return stmt.Query(scanFn, startDate, endDate)
+	})
+
+	if err != nil {
+		return 0, nil, err
+	}
+
+	query = "SELECT a.promotion_id, a.promotion_name, COUNT(DISTINCT a.transaction_id) AS total_transactions, SUM(a.discount_amount) AS discount_amount FROM transaction_promotions a JOIN transactions b ON a.transaction_id = b.id WHERE b.created_at BETWEEN $1 AND $2 AND b.status <> 'voided' GROUP BY a.promotion_id, a.promotion_name ORDER BY discount_amount DESC"
+
+	err = r.db.WithStmt(query, func(stmt *database.Stmt) error {
+		scanFn := func(rows *database.Rows) error {
+			var promotion entity.PromotionUsage
+			if err := rows.Scan(&promotion.PromotionID, &promotion.PromotionName, &promotion.TotalTransactions, &promotion.DiscountAmount); err != nil {
+				return err
+			}
+
+			promotions = append(promotions, promotion)
+			return nil
+		}
+
+		return stmt.Query(scanFn, startDate, endDate)
+	})
+
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return totalDiscount, promotions, nil
+}
+
 func (r *ReportsRepository) getMostSoldProduct(startDate string, endDate string) ([]entity.MostSoldProduct, error) {
 	var (
 		soldsProduct []entity.MostSoldProduct
@@ -114,7 +428,7 @@ func (r *ReportsRepository) getMostSoldProduct(startDate string, endDate string)
 		return nil, errors.New(constants.ErrRequiredDate)
 	}
 
-	query = "SELECT c.name, SUM(a.quantity) AS sum_quantity FROM transaction_details a JOIN transactions b ON a.transaction_id = b.id JOIN products c ON a.product_id = c.id WHERE b.created_at >= $1 AND b.created_at < $2 GROUP BY a.product_id, c.name ORDER BY sum_quantity DESC;"
+	query = "SELECT a.product_name, SUM(a.quantity * a.unit_factor) AS sum_quantity FROM transaction_details a JOIN transactions b ON a.transaction_id = b.id WHERE b.created_at >= $1 AND b.created_at < $2 AND b.status <> 'voided' GROUP BY a.product_name ORDER BY sum_quantity DESC;"
 
 	err = r.db.WithStmt(query, func(stmt *database.Stmt) error {
 		scanFn := func(rows *database.Rows) error {
@@ -7,6 +7,8 @@ import (
 
 type IReportService interface {
 	Report(startDate string, endDate string) (*entity.ReportTransaction, error)
+	CashierReport(startDate string, endDate string) ([]entity.CashierReport, error)
+	ReceivablesAging() (*entity.ReceivablesAging, error)
 	API() entity.HealthCheck
 }
 
@@ -28,3 +30,11 @@ func (t *ReportService) API() entity.HealthCheck {
 func (s *ReportService) Report(startDate string, endDate string) (*entity.ReportTransaction, error) {
 	return s.transactionsRepository.Report(startDate, endDate)
 }
+
+func (s *ReportService) CashierReport(startDate string, endDate string) ([]entity.CashierReport, error) {
+	return s.transactionsRepository.CashierReport(startDate, endDate)
+}
+
+func (s *ReportService) ReceivablesAging() (*entity.ReceivablesAging, error) {
+	return s.transactionsRepository.ReceivablesAging()
+}
@@ -0,0 +1,161 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	constants "github.com/pandusatrianura/kasir_api_service/constant"
+	"github.com/pandusatrianura/kasir_api_service/internal/returns/entity"
+	"github.com/pandusatrianura/kasir_api_service/internal/returns/service"
+	"github.com/pandusatrianura/kasir_api_service/pkg/response"
+)
+
+type ReturnHandler struct {
+	service service.IReturnService
+}
+
+func NewReturnHandler(service service.IReturnService) *ReturnHandler {
+	return &ReturnHandler{service: service}
+}
+
+// API godoc
+// @Summary Get health status of returns API
+// @Description Get health status of returns API
+// @Tags returns
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 503 {object} map[string]string
+// @Router /api/returns/health [get]
+func (h *ReturnHandler) API(w http.ResponseWriter, r *http.Request) {
+	var result response.APIResponse
+	svcHealthCheckResult := h.service.API()
+
+	if svcHealthCheckResult.IsHealthy {
+		result.Code = strconv.Itoa(constants.SuccessCode)
+		result.Message = fmt.Sprintf("%s is healthy", svcHealthCheckResult.Name)
+		response.WriteJSONResponse(w, http.StatusOK, result)
+		return
+	}
+
+	result.Code = strconv.Itoa(constants.ErrorCode)
+	result.Message = fmt.Sprintf("%s is not healthy", svcHealthCheckResult.Name)
+	response.WriteJSONResponse(w, http.StatusServiceUnavailable, result)
+	return
+}
+
+// CreateReturn godoc
+// @Summary Return items of a transaction line
+// @Description Return a quantity of a transaction detail, restock the product and record the refund amount
+// @Tags returns
+// @Accept json
+// @Produce json
+// @Param X-API-Key header string true "your-secret-api-key-here"
+// @Param Authorization header string true "Bearer <token>"
+// @Param return body entity.RequestReturn true "Return Data"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/returns [post]
+func (h *ReturnHandler) CreateReturn(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		response.Error(w, http.StatusMethodNotAllowed, constants.ErrorCode, constants.ErrInvalidMethod, nil)
+		return
+	}
+
+	role := r.Header.Get("X-User-Roles")
+	if role != constants.KasirRole && role != constants.ManagerRole {
+		response.Error(w, http.StatusUnauthorized, constants.ErrorCode, constants.ErrRoleNotAuthorized, errors.New(fmt.Sprintf("%s", role)))
+		return
+	}
+
+	var request entity.RequestReturn
+	if err := response.ParseJSON(r, &request); err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidReturnRequest, err)
+		return
+	}
+
+	request.UserID, _ = strconv.Atoi(r.Header.Get("X-User-ID"))
+
+	returned, err := h.service.CreateReturn(&request)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, constants.ErrorCode, "Return created failed", err)
+		return
+	}
+
+	response.Success(w, http.StatusCreated, constants.SuccessCode, "Return created successfully", returned)
+}
+
+// GetReturnByID godoc
+// @Summary Get a return by ID
+// @Description Get a return document by ID
+// @Tags returns
+// @Accept json
+// @Produce json
+// @Param X-API-Key header string true "your-secret-api-key-here"
+// @Param Authorization header string true "Bearer <token>"
+// @Param id path int true "Return ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/returns/{id} [get]
+func (h *ReturnHandler) GetReturnByID(w http.ResponseWriter, r *http.Request) {
+	role := r.Header.Get("X-User-Roles")
+	if role == "" {
+		response.Error(w, http.StatusUnauthorized, constants.ErrorCode, constants.ErrRoleNotAuthorized, errors.New(fmt.Sprintf("%s", role)))
+		return
+	}
+
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidReturnID, err)
+		return
+	}
+
+	returned, err := h.service.GetReturnByID(id)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, constants.ErrorCode, "Return retrieved failed", err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, constants.SuccessCode, "Return retrieved successfully", returned)
+}
+
+// GetReturnsByTransactionID godoc
+// @Summary Get returns of a transaction
+// @Description Get every return document recorded against a transaction
+// @Tags returns
+// @Accept json
+// @Produce json
+// @Param X-API-Key header string true "your-secret-api-key-here"
+// @Param Authorization header string true "Bearer <token>"
+// @Param transaction_id query int true "Transaction ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/returns [get]
+func (h *ReturnHandler) GetReturnsByTransactionID(w http.ResponseWriter, r *http.Request) {
+	role := r.Header.Get("X-User-Roles")
+	if role == "" {
+		response.Error(w, http.StatusUnauthorized, constants.ErrorCode, constants.ErrRoleNotAuthorized, errors.New(fmt.Sprintf("%s", role)))
+		return
+	}
+
+	transactionID, err := strconv.Atoi(r.URL.Query().Get("transaction_id"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidTransactionID, err)
+		return
+	}
+
+	returns, err := h.service.GetReturnsByTransactionID(transactionID)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, constants.ErrorCode, "Returns retrieved failed", err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, constants.SuccessCode, "Returns retrieved successfully", returns)
+}
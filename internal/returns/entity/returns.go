@@ -0,0 +1,41 @@
+package entity
+
+type HealthCheck struct {
+	Name      string `json:"name"`
+	IsHealthy bool   `json:"is_healthy"`
+}
+
+type Return struct {
+	ID                  int    `json:"id"`
+	TransactionID       int    `json:"transaction_id"`
+	TransactionDetailID int    `json:"transaction_detail_id"`
+	ProductID           int    `json:"product_id"`
+	ProductName         string `json:"product_name"`
+	Quantity            int    `json:"quantity"`
+	RefundAmount        int    `json:"refund_amount"`
+	CreditAmount        int    `json:"credit_amount"`
+	Reason              string `json:"reason"`
+	UserID              int    `json:"user_id,omitempty"`
+	CreatedAt           string `json:"created_at,omitempty"`
+	UpdatedAt           string `json:"updated_at,omitempty"`
+}
+
+type RequestReturn struct {
+	UserID              int    `json:"-"`
+	TransactionDetailID int    `json:"transaction_detail_id"`
+	Quantity            int    `json:"quantity"`
+	Reason              string `json:"reason"`
+}
+
+type ReturnedDetail struct {
+	TransactionID  int
+	CustomerID     int
+	ProductID      int
+	VariantID      int
+	UnitFactor     int
+	Quantity       int
+	Subtotal       int
+	Status         string
+	ReturnedQty    int
+	ReturnedAmount int
+}
@@ -0,0 +1,252 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	constants "github.com/pandusatrianura/kasir_api_service/constant"
+	auditRepository "github.com/pandusatrianura/kasir_api_service/internal/audit/repository"
+	"github.com/pandusatrianura/kasir_api_service/internal/returns/entity"
+	"github.com/pandusatrianura/kasir_api_service/pkg/database"
+)
+
+const returnColumns = "transaction_returns.id, transaction_returns.transaction_id, transaction_returns.transaction_detail_id, COALESCE(transaction_returns.product_id, 0), transaction_details.product_name, transaction_returns.quantity, transaction_returns.refund_amount, transaction_returns.credit_amount, COALESCE(transaction_returns.reason, ''), COALESCE(transaction_returns.user_id, 0), transaction_returns.created_at, transaction_returns.updated_at"
+
+type IReturnRepository interface {
+	CreateReturn(request *entity.RequestReturn) (int, error)
+	GetReturnByID(id int) (*entity.Return, error)
+	GetReturnsByTransactionID(transactionID int) ([]entity.Return, error)
+}
+
+type ReturnRepository struct {
+	db *database.DB
+}
+
+func NewReturnRepository(db *database.DB) IReturnRepository {
+	return &ReturnRepository{db: db}
+}
+
+func (r *ReturnRepository) CreateReturn(request *entity.RequestReturn) (int, error) {
+	var (
+		detail       entity.ReturnedDetail
+		returnID     int
+		shiftID      int
+		creditAmount int
+		query        string
+		err          error
+	)
+
+	err = r.db.WithTx(func(tx *database.Tx) error {
+		// The sale is locked as well so a return and a void of the same sale wait for each other instead of both restocking.
+		query = "SELECT transaction_details.transaction_id, COALESCE(transaction_details.product_id, 0), COALESCE(transaction_details.variant_id, 0), transaction_details.unit_factor, transaction_details.quantity, transaction_details.subtotal, transactions.status, COALESCE(customers.id, 0) FROM transaction_details JOIN transactions ON transaction_details.transaction_id = transactions.id LEFT JOIN customers ON customers.id = transactions.customer_id WHERE transaction_details.id = $1 FOR UPDATE OF transaction_details, transactions"
+		err = tx.WithStmt(query, func(stmt *database.Stmt) error {
+			return stmt.QueryRow(request.TransactionDetailID).Scan(&detail.TransactionID, &detail.ProductID, &detail.VariantID, &detail.UnitFactor, &detail.Quantity, &detail.Subtotal, &detail.Status, &detail.CustomerID)
+		})
+
+		if errors.Is(err, sql.ErrNoRows) {
+			return errors.New(constants.ErrDetailNotFound)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if detail.Status == constants.TransactionStatusVoided {
+			return errors.New(constants.ErrTransactionVoided)
+		}
+
+		query = "SELECT COALESCE(SUM(quantity), 0), COALESCE(SUM(refund_amount), 0) FROM transaction_returns WHERE transaction_detail_id = $1"
+		err = tx.WithStmt(query, func(stmt *database.Stmt) error {
+			return stmt.QueryRow(request.TransactionDetailID).Scan(&detail.ReturnedQty, &detail.ReturnedAmount)
+		})
+
+		if err != nil {
+			return err
+		}
+
+		if request.Quantity > detail.Quantity-detail.ReturnedQty {
+			return errors.New(constants.ErrReturnQuantityExceeded)
+		}
+
+		refundAmount := detail.Subtotal * request.Quantity / detail.Quantity
+		if detail.ReturnedQty+request.Quantity == detail.Quantity {
+			// The last return of a line refunds whatever is left so rounding never loses or adds money.
+			refundAmount = detail.Subtotal - detail.ReturnedAmount
+		}
+
+		// The refund of a sale paid on credit first pays off the credit still owed for that sale, and only the rest is
+		// paid out in cash. The customer is locked before the products, the same order checkout uses.
+		creditAmount, err = r.creditReceivable(tx, detail, request.UserID, refundAmount)
+		if err != nil {
+			return err
+		}
+
+		// The refund is paid out of the drawer of the shift the user has open, if any.
+		query = "SELECT COALESCE(MAX(id), 0) FROM shifts WHERE user_id = $1 AND status = $2"
+		err = tx.WithStmt(query, func(stmt *database.Stmt) error {
+			return stmt.QueryRow(request.UserID, constants.ShiftStatusOpen).Scan(&shiftID)
+		})
+
+		if err != nil {
+			return err
+		}
+
+		query = "INSERT INTO transaction_returns (transaction_id, transaction_detail_id, product_id, shift_id, quantity, refund_amount, credit_amount, reason, user_id, created_at, updated_at) VALUES ($1, $2, NULLIF($3, 0), NULLIF($4, 0), $5, $6, $7, $8, $9, $10, $11) RETURNING id"
+		err = tx.WithStmt(query, func(stmt *database.Stmt) error {
+			return stmt.QueryRow(detail.TransactionID, request.TransactionDetailID, detail.ProductID, shiftID, request.Quantity, refundAmount, creditAmount, request.Reason, request.UserID, "now()", "now()").Scan(&returnID)
+		})
+
+		if err != nil {
+			return err
+		}
+
+		query = "UPDATE products SET stock = stock + $1, updated_at = $2 WHERE id = $3"
+		err = tx.WithStmt(query, func(stmt *database.Stmt) error {
+			_, err := stmt.Exec(request.Quantity*detail.UnitFactor, "now()", detail.ProductID)
+			return err
+		})
+
+		if err != nil {
+			return err
+		}
+
+		if detail.VariantID != 0 {
+			query = "UPDATE product_variants SET stock = stock + $1, updated_at = $2 WHERE id = $3"
+			err = tx.WithStmt(query, func(stmt *database.Stmt) error {
+				_, err := stmt.Exec(request.Quantity*detail.UnitFactor, "now()", detail.VariantID)
+				return err
+			})
+
+			if err != nil {
+				return err
+			}
+		}
+
+		return auditRepository.Append(tx, constants.ChainRecordReturn, detail.TransactionID, returnID)
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return returnID, nil
+}
+
+// creditReceivable takes the refund off the receivable balance of the customer, up to the credit of the sale that was
+// not already credited back by a void or an earlier return, and reports how much was credited.
+func (r *ReturnRepository) creditReceivable(tx *database.Tx, detail entity.ReturnedDetail, userID int, refundAmount int) (int, error) {
+	var (
+		outstanding int
+		balance     int
+		query       string
+		err         error
+	)
+
+	if detail.CustomerID == 0 {
+		return 0, nil
+	}
+
+	query = "SELECT COALESCE(SUM(amount), 0) FROM customer_receivables_ledger WHERE transaction_id = $1 AND type IN ($2, $3)"
+	err = tx.WithStmt(query, func(stmt *database.Stmt) error {
+		return stmt.QueryRow(detail.TransactionID, constants.ReceivableTypeCharge, constants.ReceivableTypeReturn).Scan(&outstanding)
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	amount := min(refundAmount, outstanding)
+	if amount <= 0 {
+		return 0, nil
+	}
+
+	query = "UPDATE customers SET receivable_balance = receivable_balance - $1, updated_at = $2 WHERE id = $3 RETURNING receivable_balance"
+	err = tx.WithStmt(query, func(stmt *database.Stmt) error {
+		return stmt.QueryRow(amount, "now()", detail.CustomerID).Scan(&balance)
+	})
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, errors.New(constants.ErrCustomerNotFound)
+	}
+
+	if err != nil {
+		return 0, err
+	}
+
+	query = "INSERT INTO customer_receivables_ledger (customer_id, transaction_id, user_id, type, amount, balance_after, created_at) VALUES ($1, $2, NULLIF($3, 0), $4, $5, $6, $7)"
+	err = tx.WithStmt(query, func(stmt *database.Stmt) error {
+		_, err := stmt.Exec(detail.CustomerID, detail.TransactionID, userID, constants.ReceivableTypeReturn, -amount, balance, "now()")
+		return err
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return amount, nil
+}
+
+func (r *ReturnRepository) GetReturnByID(id int) (*entity.Return, error) {
+	var (
+		returned entity.Return
+		query    string
+		err      error
+	)
+
+	query = fmt.Sprintf("SELECT %s FROM transaction_returns JOIN transaction_details ON transaction_returns.transaction_detail_id = transaction_details.id WHERE transaction_returns.id = $1", returnColumns)
+
+	err = r.db.WithStmt(query, func(stmt *database.Stmt) error {
+		scanFn := func(rows *database.Rows) error {
+			return scanReturn(rows, &returned)
+		}
+
+		return stmt.Query(scanFn, id)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if returned.ID == 0 {
+		return nil, errors.New(constants.ErrReturnNotFound)
+	}
+
+	return &returned, nil
+}
+
+func (r *ReturnRepository) GetReturnsByTransactionID(transactionID int) ([]entity.Return, error) {
+	var (
+		returns []entity.Return
+		query   string
+		err     error
+	)
+
+	returns = make([]entity.Return, 0)
+
+	query = fmt.Sprintf("SELECT %s FROM transaction_returns JOIN transaction_details ON transaction_returns.transaction_detail_id = transaction_details.id WHERE transaction_returns.transaction_id = $1 ORDER BY transaction_returns.id", returnColumns)
+
+	err = r.db.WithStmt(query, func(stmt *database.Stmt) error {
+		scanFn := func(rows *database.Rows) error {
+			var returned entity.Return
+			if err := scanReturn(rows, &returned); err != nil {
+				return err
+			}
+
+			returns = append(returns, returned)
+			return nil
+		}
+
+		return stmt.Query(scanFn, transactionID)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return returns, nil
+}
+
+func scanReturn(rows *database.Rows, returned *entity.Return) error {
+	return rows.Scan(&returned.ID, &returned.TransactionID, &returned.TransactionDetailID, &returned.ProductID, &returned.ProductName, &returned.Quantity, &returned.RefundAmount, &returned.CreditAmount, &returned.Reason, &returned.UserID, &returned.CreatedAt, &returned.UpdatedAt)
+}
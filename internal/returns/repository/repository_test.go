@@ -0,0 +1,52 @@
+package repository_test
+
+import (
+	"testing"
+
+	constants "github.com/pandusatrianura/kasir_api_service/constant"
+	"github.com/pandusatrianura/kasir_api_service/internal/returns/entity"
+	"github.com/pandusatrianura/kasir_api_service/internal/returns/repository"
+	transactionEntity "github.com/pandusatrianura/kasir_api_service/internal/transactions/entity"
+	transactionRepository "github.com/pandusatrianura/kasir_api_service/internal/transactions/repository"
+	"github.com/pandusatrianura/kasir_api_service/pkg/database/dbtest"
+)
+
+func TestCreateReturnRejectsMoreThanWasSold(t *testing.T) {
+	db := dbtest.Open(t)
+
+	userID := dbtest.QueryInt(t, db, "INSERT INTO users (name, email, password) VALUES ('Kasir', 'kasir@example.com', 'x') RETURNING id")
+	categoryID := dbtest.QueryInt(t, db, "INSERT INTO categories (name, description) VALUES ('Makanan', '') RETURNING id")
+	productID := dbtest.QueryInt(t, db, "INSERT INTO products (name, price, stock, category_id) VALUES ('Indomie', 3000, 10, $1) RETURNING id", categoryID)
+
+	sale, err := transactionRepository.NewTransactionsRepository(db).Checkout(transactionEntity.Checkout{
+		UserID:            userID,
+		TerminalID:        "KASIR-01",
+		InvoicePrefix:     "TEST",
+		LowStockThreshold: -1,
+		Checkouts:         []transactionEntity.CheckoutRequest{{ProductID: productID, Quantity: 2}},
+		Payments:          []transactionEntity.PaymentRequest{{Method: constants.PaymentMethodCash, Amount: 6000}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repo := repository.NewReturnRepository(db)
+	detailID := sale.CheckoutProducts[0].TransactionDetailID
+
+	if _, err = repo.CreateReturn(&entity.RequestReturn{UserID: userID, TransactionDetailID: detailID, Quantity: 1, Reason: "Rusak"}); err != nil {
+		t.Fatal(err)
+	}
+
+	returnID, err := repo.CreateReturn(&entity.RequestReturn{UserID: userID, TransactionDetailID: detailID, Quantity: 2, Reason: "Rusak"})
+	if err == nil || err.Error() != constants.ErrReturnQuantityExceeded {
+		t.Fatalf("returning more than is left returned %d, %v, want %s", returnID, err, constants.ErrReturnQuantityExceeded)
+	}
+
+	if stock := dbtest.QueryInt(t, db, "SELECT stock FROM products WHERE id = $1", productID); stock != 9 {
+		t.Fatalf("stock is %d after a rejected return, want 9", stock)
+	}
+
+	if returns := dbtest.QueryInt(t, db, "SELECT COUNT(id) FROM transaction_returns WHERE transaction_detail_id = $1", detailID); returns != 1 {
+		t.Fatalf("%d returns were recorded, want 1", returns)
+	}
+}
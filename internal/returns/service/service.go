@@ -0,0 +1,56 @@
+package service
+
+import (
+	"errors"
+
+	constants "github.com/pandusatrianura/kasir_api_service/constant"
+	"github.com/pandusatrianura/kasir_api_service/internal/returns/entity"
+	"github.com/pandusatrianura/kasir_api_service/internal/returns/repository"
+)
+
+type IReturnService interface {
+	CreateReturn(request *entity.RequestReturn) (*entity.Return, error)
+	GetReturnByID(id int) (*entity.Return, error)
+	GetReturnsByTransactionID(transactionID int) ([]entity.Return, error)
+	API() entity.HealthCheck
+}
+
+type ReturnService struct {
+	returnRepository repository.IReturnRepository
+}
+
+func NewReturnService(returnRepository repository.IReturnRepository) IReturnService {
+	return &ReturnService{returnRepository: returnRepository}
+}
+
+func (s *ReturnService) API() entity.HealthCheck {
+	return entity.HealthCheck{
+		Name:      "Returns API",
+		IsHealthy: true,
+	}
+}
+
+func (s *ReturnService) CreateReturn(request *entity.RequestReturn) (*entity.Return, error) {
+	if request.TransactionDetailID <= 0 {
+		return nil, errors.New(constants.ErrDetailNotFound)
+	}
+
+	if request.Quantity <= 0 {
+		return nil, errors.New(constants.ErrReturnQuantityInvalid)
+	}
+
+	id, err := s.returnRepository.CreateReturn(request)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.returnRepository.GetReturnByID(id)
+}
+
+func (s *ReturnService) GetReturnByID(id int) (*entity.Return, error) {
+	return s.returnRepository.GetReturnByID(id)
+}
+
+func (s *ReturnService) GetReturnsByTransactionID(transactionID int) ([]entity.Return, error) {
+	return s.returnRepository.GetReturnsByTransactionID(transactionID)
+}
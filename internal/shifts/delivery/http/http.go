@@ -0,0 +1,311 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	constants "github.com/pandusatrianura/kasir_api_service/constant"
+	"github.com/pandusatrianura/kasir_api_service/internal/shifts/entity"
+	"github.com/pandusatrianura/kasir_api_service/internal/shifts/service"
+	"github.com/pandusatrianura/kasir_api_service/pkg/pagination"
+	"github.com/pandusatrianura/kasir_api_service/pkg/response"
+)
+
+type ShiftHandler struct {
+	service service.IShiftService
+}
+
+func NewShiftHandler(service service.IShiftService) *ShiftHandler {
+	return &ShiftHandler{service: service}
+}
+
+// API godoc
+// @Summary Get health status of shifts API
+// @Description Get health status of shifts API
+// @Tags shifts
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 503 {object} map[string]string
+// @Router /api/shifts/health [get]
+func (h *ShiftHandler) API(w http.ResponseWriter, r *http.Request) {
+	var result response.APIResponse
+	svcHealthCheckResult := h.service.API()
+
+	if svcHealthCheckResult.IsHealthy {
+		result.Code = strconv.Itoa(constants.SuccessCode)
+		result.Message = fmt.Sprintf("%s is healthy", svcHealthCheckResult.Name)
+		response.WriteJSONResponse(w, http.StatusOK, result)
+		return
+	}
+
+	result.Code = strconv.Itoa(constants.ErrorCode)
+	result.Message = fmt.Sprintf("%s is not healthy", svcHealthCheckResult.Name)
+	response.WriteJSONResponse(w, http.StatusServiceUnavailable, result)
+	return
+}
+
+// OpenShift godoc
+// @Summary Open a shift
+// @Description Open a cash drawer shift for the logged in cashier with an opening float
+// @Tags shifts
+// @Accept json
+// @Produce json
+// @Param X-API-Key header string true "your-secret-api-key-here"
+// @Param Authorization header string true "Bearer <token>"
+// @Param X-Terminal-ID header string false "Terminal (register) the shift is opened on"
+// @Param shift body entity.RequestOpenShift true "Shift Data"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/shifts/open [post]
+func (h *ShiftHandler) OpenShift(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		response.Error(w, http.StatusMethodNotAllowed, constants.ErrorCode, constants.ErrInvalidMethod, nil)
+		return
+	}
+
+	role := r.Header.Get("X-User-Roles")
+	if role == "" {
+		response.Error(w, http.StatusUnauthorized, constants.ErrorCode, constants.ErrRoleNotAuthorized, errors.New(fmt.Sprintf("%s", role)))
+		return
+	}
+
+	var request entity.RequestOpenShift
+	if err := response.ParseJSON(r, &request); err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidShiftRequest, err)
+		return
+	}
+
+	request.UserID, _ = strconv.Atoi(r.Header.Get("X-User-ID"))
+	request.TerminalID = strings.TrimSpace(r.Header.Get("X-Terminal-ID"))
+
+	report, err := h.service.OpenShift(&request)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, constants.ErrorCode, "Shift opened failed", err)
+		return
+	}
+
+	response.Success(w, http.StatusCreated, constants.SuccessCode, "Shift opened successfully", report)
+}
+
+// GetCurrentShift godoc
+// @Summary Get the current shift
+// @Description Get the report of the open shift of the logged in cashier
+// @Tags shifts
+// @Accept json
+// @Produce json
+// @Param X-API-Key header string true "your-secret-api-key-here"
+// @Param Authorization header string true "Bearer <token>"
+// @Success 200 {object} map[string]interface{}
+// @Failure 500 {object} map[string]string
+// @Router /api/shifts/current [get]
+func (h *ShiftHandler) GetCurrentShift(w http.ResponseWriter, r *http.Request) {
+	role := r.Header.Get("X-User-Roles")
+	if role == "" {
+		response.Error(w, http.StatusUnauthorized, constants.ErrorCode, constants.ErrRoleNotAuthorized, errors.New(fmt.Sprintf("%s", role)))
+		return
+	}
+
+	report, err := h.service.GetCurrentShift(actorFromRequest(r))
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, constants.ErrorCode, "Shift retrieved failed", err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, constants.SuccessCode, "Shift retrieved successfully", report)
+}
+
+// GetShifts godoc
+// @Summary Get shifts
+// @Description List shifts, newest first. Cashiers only see their own shifts
+// @Tags shifts
+// @Accept json
+// @Produce json
+// @Param X-API-Key header string true "your-secret-api-key-here"
+// @Param Authorization header string true "Bearer <token>"
+// @Param page query int false "Page (default 1)"
+// @Param limit query int false "Limit per page (default 20, max 100)"
+// @Param user_id query int false "Cashier (user) ID, managers only"
+// @Param status query string false "open or closed"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/shifts [get]
+func (h *ShiftHandler) GetShifts(w http.ResponseWriter, r *http.Request) {
+	var (
+		filter entity.ShiftFilter
+		err    error
+	)
+
+	role := r.Header.Get("X-User-Roles")
+	if role == "" {
+		response.Error(w, http.StatusUnauthorized, constants.ErrorCode, constants.ErrRoleNotAuthorized, errors.New(fmt.Sprintf("%s", role)))
+		return
+	}
+
+	query := r.URL.Query()
+	filter.Page, filter.Limit = pagination.Parse(r)
+
+	if userID := query.Get("user_id"); userID != "" {
+		if filter.UserID, err = strconv.Atoi(userID); err != nil {
+			response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidShiftRequest, err)
+			return
+		}
+	}
+
+	filter.Status = query.Get("status")
+	if filter.Status != "" && filter.Status != constants.ShiftStatusOpen && filter.Status != constants.ShiftStatusClosed {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidShiftRequest, errors.New(filter.Status))
+		return
+	}
+
+	shifts, total, err := h.service.GetShifts(filter, actorFromRequest(r))
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, constants.ErrorCode, "Shifts retrieved failed", err)
+		return
+	}
+
+	response.SuccessWithMeta(w, http.StatusOK, constants.SuccessCode, "Shifts retrieved successfully", shifts, pagination.NewMeta(filter.Page, filter.Limit, total))
+}
+
+// GetShiftReport godoc
+// @Summary Get a shift report
+// @Description Get the sales, tenders, cash movements and expected versus counted cash of a shift
+// @Tags shifts
+// @Accept json
+// @Produce json
+// @Param X-API-Key header string true "your-secret-api-key-here"
+// @Param Authorization header string true "Bearer <token>"
+// @Param id path int true "Shift ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/shifts/{id} [get]
+func (h *ShiftHandler) GetShiftReport(w http.ResponseWriter, r *http.Request) {
+	role := r.Header.Get("X-User-Roles")
+	if role == "" {
+		response.Error(w, http.StatusUnauthorized, constants.ErrorCode, constants.ErrRoleNotAuthorized, errors.New(fmt.Sprintf("%s", role)))
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidShiftID, err)
+		return
+	}
+
+	report, err := h.service.GetShiftReport(id, actorFromRequest(r))
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, constants.ErrorCode, "Shift retrieved failed", err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, constants.SuccessCode, "Shift retrieved successfully", report)
+}
+
+// AddCashMovement godoc
+// @Summary Log a cash movement
+// @Description Log cash put into (in) or taken out of (out) the drawer during an open shift
+// @Tags shifts
+// @Accept json
+// @Produce json
+// @Param X-API-Key header string true "your-secret-api-key-here"
+// @Param Authorization header string true "Bearer <token>"
+// @Param id path int true "Shift ID"
+// @Param movement body entity.RequestCashMovement true "Cash Movement Data"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/shifts/{id}/cash-movements [post]
+func (h *ShiftHandler) AddCashMovement(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		response.Error(w, http.StatusMethodNotAllowed, constants.ErrorCode, constants.ErrInvalidMethod, nil)
+		return
+	}
+
+	role := r.Header.Get("X-User-Roles")
+	if role == "" {
+		response.Error(w, http.StatusUnauthorized, constants.ErrorCode, constants.ErrRoleNotAuthorized, errors.New(fmt.Sprintf("%s", role)))
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidShiftID, err)
+		return
+	}
+
+	var request entity.RequestCashMovement
+	if err := response.ParseJSON(r, &request); err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidShiftRequest, err)
+		return
+	}
+
+	report, err := h.service.AddCashMovement(id, &request, actorFromRequest(r))
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, constants.ErrorCode, "Cash movement recorded failed", err)
+		return
+	}
+
+	response.Success(w, http.StatusCreated, constants.SuccessCode, "Cash movement recorded successfully", report)
+}
+
+// CloseShift godoc
+// @Summary Close a shift
+// @Description Close a shift with the counted cash and get the expected versus actual (over/short) reconciliation
+// @Tags shifts
+// @Accept json
+// @Produce json
+// @Param X-API-Key header string true "your-secret-api-key-here"
+// @Param Authorization header string true "Bearer <token>"
+// @Param id path int true "Shift ID"
+// @Param shift body entity.RequestCloseShift true "Closing Data"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/shifts/{id}/close [post]
+func (h *ShiftHandler) CloseShift(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		response.Error(w, http.StatusMethodNotAllowed, constants.ErrorCode, constants.ErrInvalidMethod, nil)
+		return
+	}
+
+	role := r.Header.Get("X-User-Roles")
+	if role == "" {
+		response.Error(w, http.StatusUnauthorized, constants.ErrorCode, constants.ErrRoleNotAuthorized, errors.New(fmt.Sprintf("%s", role)))
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidShiftID, err)
+		return
+	}
+
+	var request entity.RequestCloseShift
+	if err := response.ParseJSON(r, &request); err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidShiftRequest, err)
+		return
+	}
+
+	report, err := h.service.CloseShift(id, &request, actorFromRequest(r))
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, constants.ErrorCode, "Shift closed failed", err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, constants.SuccessCode, "Shift closed successfully", report)
+}
+
+func actorFromRequest(r *http.Request) entity.Actor {
+	userID, _ := strconv.Atoi(r.Header.Get("X-User-ID"))
+	return entity.Actor{
+		UserID:  userID,
+		Manager: r.Header.Get("X-User-Roles") == constants.ManagerRole,
+	}
+}
@@ -0,0 +1,90 @@
+package entity
+
+type HealthCheck struct {
+	Name      string `json:"name"`
+	IsHealthy bool   `json:"is_healthy"`
+}
+
+type Shift struct {
+	ID           int     `json:"id"`
+	UserID       int     `json:"user_id"`
+	TerminalID   string  `json:"terminal_id,omitempty"`
+	Status       string  `json:"status"`
+	OpeningFloat int     `json:"opening_float"`
+	OpeningNote  string  `json:"opening_note,omitempty"`
+	CountedCash  *int    `json:"counted_cash,omitempty"`
+	ExpectedCash *int    `json:"expected_cash,omitempty"`
+	Difference   *int    `json:"difference,omitempty"`
+	ClosingNote  string  `json:"closing_note,omitempty"`
+	OpenedAt     string  `json:"opened_at"`
+	ClosedAt     *string `json:"closed_at,omitempty"`
+}
+
+type CashMovement struct {
+	ID        int    `json:"id"`
+	ShiftID   int    `json:"shift_id"`
+	UserID    int    `json:"user_id"`
+	Type      string `json:"type"`
+	Amount    int    `json:"amount"`
+	Reason    string `json:"reason"`
+	CreatedAt string `json:"created_at,omitempty"`
+}
+
+// Tender is the amount taken with one payment method during a shift.
+type Tender struct {
+	Method            string `json:"method"`
+	TotalTransactions int    `json:"total_transactions"`
+	Amount            int    `json:"amount"`
+}
+
+// ShiftReport reconciles the cash drawer of a shift. ExpectedCash is the opening float plus cash sales (cash
+// tendered minus change given) plus cash repayments of customer credit plus cash in minus cash out; Difference is the counted cash minus the expected
+// cash, positive when the drawer is over and negative when it is short.
+type ShiftReport struct {
+	Shift             Shift          `json:"shift"`
+	TotalTransactions int            `json:"total_transactions"`
+	TotalSales        int            `json:"total_sales"`
+	TotalVoided       int            `json:"total_voided"`
+	Tenders           []Tender       `json:"tenders"`
+	CashSales         int            `json:"cash_sales"`
+	CashRepayments    int            `json:"cash_repayments"`
+	CashRefunds       int            `json:"cash_refunds"`
+	CashVoids         int            `json:"cash_voids"`
+	CashIn            int            `json:"cash_in"`
+	CashOut           int            `json:"cash_out"`
+	ExpectedCash      int            `json:"expected_cash"`
+	CountedCash       *int           `json:"counted_cash,omitempty"`
+	Difference        *int           `json:"difference,omitempty"`
+	Movements         []CashMovement `json:"cash_movements"`
+}
+
+type RequestOpenShift struct {
+	UserID       int    `json:"-"`
+	TerminalID   string `json:"-"`
+	OpeningFloat int    `json:"opening_float"`
+	Note         string `json:"note"`
+}
+
+type RequestCloseShift struct {
+	CountedCash int    `json:"counted_cash"`
+	Note        string `json:"note"`
+}
+
+type RequestCashMovement struct {
+	Type   string `json:"type"`
+	Amount int    `json:"amount"`
+	Reason string `json:"reason"`
+}
+
+type ShiftFilter struct {
+	Page   int
+	Limit  int
+	UserID int
+	Status string
+}
+
+// Actor is the user working on a shift; managers may see and close every cashier's shifts.
+type Actor struct {
+	UserID  int
+	Manager bool
+}
@@ -0,0 +1,350 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+	constants "github.com/pandusatrianura/kasir_api_service/constant"
+	"github.com/pandusatrianura/kasir_api_service/internal/shifts/entity"
+	"github.com/pandusatrianura/kasir_api_service/pkg/database"
+	"github.com/pandusatrianura/kasir_api_service/pkg/pagination"
+)
+
+const shiftColumns = "id, user_id, COALESCE(terminal_id, ''), status, opening_float, COALESCE(opening_note, ''), counted_cash, expected_cash, difference, COALESCE(closing_note, ''), opened_at, closed_at"
+
+type IShiftRepository interface {
+	OpenShift(request *entity.RequestOpenShift) (int, error)
+	GetShiftByID(id int) (*entity.Shift, error)
+	GetOpenShift(userID int) (*entity.Shift, error)
+	GetShifts(filter entity.ShiftFilter) ([]entity.Shift, int64, error)
+	AddCashMovement(movement *entity.CashMovement) error
+	CloseShift(id int, request *entity.RequestCloseShift) error
+	GetShiftReport(id int) (*entity.ShiftReport, error)
+}
+
+type ShiftRepository struct {
+	db *database.DB
+}
+
+func NewShiftRepository(db *database.DB) IShiftRepository {
+	return &ShiftRepository{db: db}
+}
+
+func (s *ShiftRepository) OpenShift(request *entity.RequestOpenShift) (int, error) {
+	var (
+		shiftID int
+		query   string
+		err     error
+	)
+
+	query = "INSERT INTO shifts (user_id, terminal_id, status, opening_float, opening_note, opened_at, created_at, updated_at) VALUES ($1, NULLIF($2, ''), $3, $4, NULLIF($5, ''), $6, $7, $8) RETURNING id"
+	err = s.db.WithStmt(query, func(stmt *database.Stmt) error {
+		return stmt.QueryRow(request.UserID, request.TerminalID, constants.ShiftStatusOpen, request.OpeningFloat, request.Note, "now()", "now()", "now()").Scan(&shiftID)
+	})
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == database.UniqueViolation {
+		return 0, errors.New(constants.ErrShiftAlreadyOpen)
+	}
+
+	if err != nil {
+		return 0, err
+	}
+
+	return shiftID, nil
+}
+
+func (s *ShiftRepository) GetShiftByID(id int) (*entity.Shift, error) {
+	return s.getShift(s.db, "id = $1", id)
+}
+
+func (s *ShiftRepository) GetOpenShift(userID int) (*entity.Shift, error) {
+	shift, err := s.getShift(s.db, "user_id = $1 AND status = $2", userID, constants.ShiftStatusOpen)
+	if err != nil && err.Error() == constants.ErrShiftNotFound {
+		return nil, errors.New(constants.ErrShiftNotOpen)
+	}
+
+	return shift, err
+}
+
+func (s *ShiftRepository) GetShifts(filter entity.ShiftFilter) ([]entity.Shift, int64, error) {
+	var (
+		shifts     []entity.Shift
+		total      int64
+		conditions []string
+		args       []interface{}
+		query      string
+		err        error
+	)
+
+	shifts = make([]entity.Shift, 0)
+
+	if filter.UserID > 0 {
+		args = append(args, filter.UserID)
+		conditions = append(conditions, fmt.Sprintf("user_id = $%d", len(args)))
+	}
+
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		conditions = append(conditions, fmt.Sprintf("status = $%d", len(args)))
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	query = "SELECT COUNT(id) FROM shifts" + where
+	err = s.db.WithStmt(query, func(stmt *database.Stmt) error {
+		return stmt.QueryRow(args...).Scan(&total)
+	})
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query = fmt.Sprintf("SELECT %s FROM shifts%s ORDER BY opened_at DESC, id DESC LIMIT $%d OFFSET $%d", shiftColumns, where, len(args)+1, len(args)+2)
+	args = append(args, filter.Limit, pagination.Offset(filter.Page, filter.Limit))
+
+	err = s.db.WithStmt(query, func(stmt *database.Stmt) error {
+		scanFn := func(rows *database.Rows) error {
+			var shift entity.Shift
+			if err := scanShift(rows, &shift); err != nil {
+				return err
+			}
+
+			shifts = append(shifts, shift)
+			return nil
+		}
+
+		return stmt.Query(scanFn, args...)
+	})
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return shifts, total, nil
+}
+
+// AddCashMovement logs cash put into or taken out of the drawer. The shift is locked so a movement can never be
+// recorded after the shift has been closed.
+func (s *ShiftRepository) AddCashMovement(movement *entity.CashMovement) error {
+	return s.db.WithTx(func(tx *database.Tx) error {
+		if _, err := s.lockOpenShift(tx, movement.ShiftID, "FOR SHARE"); err != nil {
+			return err
+		}
+
+		query := "INSERT INTO shift_cash_movements (shift_id, user_id, type, amount, reason, created_at) VALUES ($1, $2, $3, $4, $5, $6)"
+		return tx.WithStmt(query, func(stmt *database.Stmt) error {
+			_, err := stmt.Exec(movement.ShiftID, movement.UserID, movement.Type, movement.Amount, movement.Reason, "now()")
+			return err
+		})
+	})
+}
+
+// CloseShift records the counted cash and freezes the expected cash and the over/short of the shift. Checkouts
+// hold a share lock on the open shift, so closing waits for sales that are still being recorded.
+func (s *ShiftRepository) CloseShift(id int, request *entity.RequestCloseShift) error {
+	return s.db.WithTx(func(tx *database.Tx) error {
+		shift, err := s.lockOpenShift(tx, id, "FOR UPDATE")
+		if err != nil {
+			return err
+		}
+
+		report, err := s.summarize(tx, *shift)
+		if err != nil {
+			return err
+		}
+
+		query := "UPDATE shifts SET status = $1, counted_cash = $2, expected_cash = $3, difference = $4, closing_note = NULLIF($5, ''), closed_at = $6, updated_at = $7 WHERE id = $8"
+		return tx.WithStmt(query, func(stmt *database.Stmt) error {
+			_, err := stmt.Exec(constants.ShiftStatusClosed, request.CountedCash, report.ExpectedCash, request.CountedCash-report.ExpectedCash, request.Note, "now()", "now()", id)
+			return err
+		})
+	})
+}
+
+// GetShiftReport reconciles a shift. Sales are read as they are now, but once a shift is closed its expected cash
+// and over/short stay as they were at closing.
+func (s *ShiftRepository) GetShiftReport(id int) (*entity.ShiftReport, error) {
+	shift, err := s.GetShiftByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := s.summarize(s.db, *shift)
+	if err != nil {
+		return nil, err
+	}
+
+	if shift.ExpectedCash != nil {
+		report.ExpectedCash = *shift.ExpectedCash
+	}
+
+	report.CountedCash = shift.CountedCash
+	report.Difference = shift.Difference
+
+	return report, nil
+}
+
+func (s *ShiftRepository) lockOpenShift(tx *database.Tx, id int, lock string) (*entity.Shift, error) {
+	shift, err := s.getShift(tx, "id = $1 "+lock, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if shift.Status != constants.ShiftStatusOpen {
+		return nil, errors.New(constants.ErrShiftClosed)
+	}
+
+	return shift, nil
+}
+
+func (s *ShiftRepository) getShift(q database.Querier, condition string, args ...interface{}) (*entity.Shift, error) {
+	var (
+		shift entity.Shift
+		query string
+		err   error
+	)
+
+	query = fmt.Sprintf("SELECT %s FROM shifts WHERE %s", shiftColumns, condition)
+	err = q.WithStmt(query, func(stmt *database.Stmt) error {
+		scanFn := func(rows *database.Rows) error {
+			return scanShift(rows, &shift)
+		}
+
+		return stmt.Query(scanFn, args...)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if shift.ID == 0 {
+		return nil, errors.New(constants.ErrShiftNotFound)
+	}
+
+	return &shift, nil
+}
+
+// summarize totals the sales, tenders, cash repayments, cash refunds, cash handed back for voids and cash movements
+// of a shift. The totals and tenders leave voided sales out, but the cash they took in still counts as cash sales of
+// the shift that made them, and what was left of it is handed back by the shift that voided them.
+func (s *ShiftRepository) summarize(q database.Querier, shift entity.Shift) (*entity.ShiftReport, error) {
+	var (
+		cashTendered int
+		changeGiven  int
+		query        string
+		err          error
+	)
+
+	report := entity.ShiftReport{
+		Shift:     shift,
+		Tenders:   make([]entity.Tender, 0),
+		Movements: make([]entity.CashMovement, 0),
+	}
+
+	query = "SELECT COUNT(id) FILTER (WHERE status <> $2), COALESCE(SUM(total_amount) FILTER (WHERE status <> $2), 0), COUNT(id) FILTER (WHERE status = $2), COALESCE(SUM(change_amount), 0) FROM transactions WHERE shift_id = $1"
+	err = q.WithStmt(query, func(stmt *database.Stmt) error {
+		return stmt.QueryRow(shift.ID, constants.TransactionStatusVoided).Scan(&report.TotalTransactions, &report.TotalSales, &report.TotalVoided, &changeGiven)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	query = "SELECT a.method, COUNT(DISTINCT a.transaction_id), SUM(a.amount) FROM transaction_payments a JOIN transactions b ON a.transaction_id = b.id WHERE b.shift_id = $1 AND b.status <> $2 GROUP BY a.method ORDER BY a.method"
+	err = q.WithStmt(query, func(stmt *database.Stmt) error {
+		scanFn := func(rows *database.Rows) error {
+			var tender entity.Tender
+			if err := rows.Scan(&tender.Method, &tender.TotalTransactions, &tender.Amount); err != nil {
+				return err
+			}
+
+			report.Tenders = append(report.Tenders, tender)
+			return nil
+		}
+
+		return stmt.Query(scanFn, shift.ID, constants.TransactionStatusVoided)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	query = "SELECT COALESCE(SUM(a.amount), 0) FROM transaction_payments a JOIN transactions b ON a.transaction_id = b.id WHERE b.shift_id = $1 AND a.method = $2"
+	err = q.WithStmt(query, func(stmt *database.Stmt) error {
+		return stmt.QueryRow(shift.ID, constants.PaymentMethodCash).Scan(&cashTendered)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	report.CashSales = cashTendered - changeGiven
+
+	// Repayments are stored as negative amounts on the receivables ledger.
+	query = "SELECT COALESCE(-SUM(amount), 0) FROM customer_receivables_ledger WHERE shift_id = $1 AND type = $2 AND method = $3"
+	err = q.WithStmt(query, func(stmt *database.Stmt) error {
+		return stmt.QueryRow(shift.ID, constants.ReceivableTypeRepayment, constants.PaymentMethodCash).Scan(&report.CashRepayments)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	query = "SELECT COALESCE(SUM(refund_amount - credit_amount), 0) FROM transaction_returns WHERE shift_id = $1"
+	err = q.WithStmt(query, func(stmt *database.Stmt) error {
+		return stmt.QueryRow(shift.ID).Scan(&report.CashRefunds)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	// A void hands back the cash the sale took in, less the change and the cash already refunded by its returns.
+	query = "SELECT COALESCE(SUM(GREATEST(COALESCE(paid.amount, 0) - a.change_amount - COALESCE(refunded.amount, 0), 0)), 0) FROM transactions a LEFT JOIN (SELECT transaction_id, SUM(amount) AS amount FROM transaction_payments WHERE method = $3 GROUP BY transaction_id) paid ON paid.transaction_id = a.id LEFT JOIN (SELECT transaction_id, SUM(refund_amount - credit_amount) AS amount FROM transaction_returns GROUP BY transaction_id) refunded ON refunded.transaction_id = a.id WHERE a.void_shift_id = $1 AND a.status = $2"
+	err = q.WithStmt(query, func(stmt *database.Stmt) error {
+		return stmt.QueryRow(shift.ID, constants.TransactionStatusVoided, constants.PaymentMethodCash).Scan(&report.CashVoids)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	query = "SELECT id, shift_id, user_id, type, amount, reason, created_at FROM shift_cash_movements WHERE shift_id = $1 ORDER BY id"
+	err = q.WithStmt(query, func(stmt *database.Stmt) error {
+		scanFn := func(rows *database.Rows) error {
+			var movement entity.CashMovement
+			if err := rows.Scan(&movement.ID, &movement.ShiftID, &movement.UserID, &movement.Type, &movement.Amount, &movement.Reason, &movement.CreatedAt); err != nil {
+				return err
+			}
+
+			if movement.Type == constants.CashMovementIn {
+				report.CashIn += movement.Amount
+			} else {
+				report.CashOut += movement.Amount
+			}
+
+			report.Movements = append(report.Movements, movement)
+			return nil
+		}
+
+		return stmt.Query(scanFn, shift.ID)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	report.ExpectedCash = shift.OpeningFloat + report.CashSales + report.CashRepayments - report.CashRefunds - report.CashVoids + report.CashIn - report.CashOut
+
+	return &report, nil
+}
+
+func scanShift(rows *database.Rows, shift *entity.Shift) error {
+	return rows.Scan(&shift.ID, &shift.UserID, &shift.TerminalID, &shift.Status, &shift.OpeningFloat, &shift.OpeningNote, &shift.CountedCash, &shift.ExpectedCash, &shift.Difference, &shift.ClosingNote, &shift.OpenedAt, &shift.ClosedAt)
+}
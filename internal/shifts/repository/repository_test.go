@@ -0,0 +1,98 @@
+package repository_test
+
+import (
+	"testing"
+
+	constants "github.com/pandusatrianura/kasir_api_service/constant"
+	"github.com/pandusatrianura/kasir_api_service/internal/shifts/entity"
+	"github.com/pandusatrianura/kasir_api_service/internal/shifts/repository"
+	transactionEntity "github.com/pandusatrianura/kasir_api_service/internal/transactions/entity"
+	transactionRepository "github.com/pandusatrianura/kasir_api_service/internal/transactions/repository"
+	"github.com/pandusatrianura/kasir_api_service/pkg/database/dbtest"
+)
+
+func TestVoidCashLeavesTheVoidingShift(t *testing.T) {
+	db := dbtest.Open(t)
+
+	cashierID := dbtest.QueryInt(t, db, "INSERT INTO users (name, email, password) VALUES ('Kasir', 'kasir@example.com', 'x') RETURNING id")
+	managerID := dbtest.QueryInt(t, db, "INSERT INTO users (name, email, password) VALUES ('Manager', 'manager@example.com', 'x') RETURNING id")
+	categoryID := dbtest.QueryInt(t, db, "INSERT INTO categories (name, description) VALUES ('Makanan', '') RETURNING id")
+	productID := dbtest.QueryInt(t, db, "INSERT INTO products (name, price, stock, category_id) VALUES ('Indomie', 3000, 10, $1) RETURNING id", categoryID)
+
+	shifts := repository.NewShiftRepository(db)
+	transactions := transactionRepository.NewTransactionsRepository(db)
+
+	saleShiftID, err := shifts.OpenShift(&entity.RequestOpenShift{UserID: cashierID, OpeningFloat: 100000})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	voidShiftID, err := shifts.OpenShift(&entity.RequestOpenShift{UserID: managerID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sale, err := transactions.Checkout(transactionEntity.Checkout{
+		UserID:            cashierID,
+		TerminalID:        "KASIR-01",
+		InvoicePrefix:     "TEST",
+		LowStockThreshold: -1,
+		Checkouts:         []transactionEntity.CheckoutRequest{{ProductID: productID, Quantity: 2}},
+		Payments:          []transactionEntity.PaymentRequest{{Method: constants.PaymentMethodCash, Amount: 10000}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = transactions.VoidTransaction(sale.Transaction.ID, transactionEntity.VoidRequest{UserID: managerID, Reason: "Salah input"}); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := shifts.GetShiftReport(saleShiftID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.TotalTransactions != 0 || report.TotalVoided != 1 || report.CashSales != 6000 || report.CashVoids != 0 || report.ExpectedCash != 106000 {
+		t.Fatalf("shift of the sale: %d sales, %d voided, cash sales %d, cash voids %d, expected cash %d; want 0, 1, 6000, 0, 106000", report.TotalTransactions, report.TotalVoided, report.CashSales, report.CashVoids, report.ExpectedCash)
+	}
+
+	report, err = shifts.GetShiftReport(voidShiftID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.CashSales != 0 || report.CashVoids != 6000 || report.ExpectedCash != -6000 {
+		t.Fatalf("shift of the void: cash sales %d, cash voids %d, expected cash %d; want 0, 6000, -6000", report.CashSales, report.CashVoids, report.ExpectedCash)
+	}
+}
+
+func TestClosedShiftRejectsMovementsAndClosing(t *testing.T) {
+	db := dbtest.Open(t)
+
+	userID := dbtest.QueryInt(t, db, "INSERT INTO users (name, email, password) VALUES ('Kasir', 'kasir@example.com', 'x') RETURNING id")
+
+	shifts := repository.NewShiftRepository(db)
+
+	shiftID, err := shifts.OpenShift(&entity.RequestOpenShift{UserID: userID, OpeningFloat: 100000})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = shifts.CloseShift(shiftID, &entity.RequestCloseShift{CountedCash: 100000}); err != nil {
+		t.Fatal(err)
+	}
+
+	movement := &entity.CashMovement{ShiftID: shiftID, UserID: userID, Type: constants.CashMovementOut, Amount: 5000, Reason: "Beli galon"}
+	if err = shifts.AddCashMovement(movement); err == nil || err.Error() != constants.ErrShiftClosed {
+		t.Fatalf("cash movement on a closed shift returned %v, want %s", err, constants.ErrShiftClosed)
+	}
+
+	if err = shifts.CloseShift(shiftID, &entity.RequestCloseShift{CountedCash: 90000}); err == nil || err.Error() != constants.ErrShiftClosed {
+		t.Fatalf("closing a closed shift returned %v, want %s", err, constants.ErrShiftClosed)
+	}
+
+	if counted := dbtest.QueryInt(t, db, "SELECT counted_cash FROM shifts WHERE id = $1", shiftID); counted != 100000 {
+		t.Fatalf("counted cash is %d after closing twice, want 100000", counted)
+	}
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"errors"
+	"strings"
+
+	constants "github.com/pandusatrianura/kasir_api_service/constant"
+	"github.com/pandusatrianura/kasir_api_service/internal/shifts/entity"
+	"github.com/pandusatrianura/kasir_api_service/internal/shifts/repository"
+)
+
+type IShiftService interface {
+	OpenShift(request *entity.RequestOpenShift) (*entity.ShiftReport, error)
+	GetCurrentShift(actor entity.Actor) (*entity.ShiftReport, error)
+	GetShiftReport(id int, actor entity.Actor) (*entity.ShiftReport, error)
+	GetShifts(filter entity.ShiftFilter, actor entity.Actor) ([]entity.Shift, int64, error)
+	AddCashMovement(id int, request *entity.RequestCashMovement, actor entity.Actor) (*entity.ShiftReport, error)
+	CloseShift(id int, request *entity.RequestCloseShift, actor entity.Actor) (*entity.ShiftReport, error)
+	API() entity.HealthCheck
+}
+
+type ShiftService struct {
+	shiftRepository repository.IShiftRepository
+}
+
+func NewShiftService(shiftRepository repository.IShiftRepository) IShiftService {
+	return &ShiftService{shiftRepository: shiftRepository}
+}
+
+func (s *ShiftService) API() entity.HealthCheck {
+	return entity.HealthCheck{
+		Name:      "Shifts API",
+		IsHealthy: true,
+	}
+}
+
+func (s *ShiftService) OpenShift(request *entity.RequestOpenShift) (*entity.ShiftReport, error) {
+	if request.OpeningFloat < 0 {
+		return nil, errors.New(constants.ErrInvalidOpeningFloat)
+	}
+
+	request.Note = strings.TrimSpace(request.Note)
+
+	id, err := s.shiftRepository.OpenShift(request)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.shiftRepository.GetShiftReport(id)
+}
+
+func (s *ShiftService) GetCurrentShift(actor entity.Actor) (*entity.ShiftReport, error) {
+	shift, err := s.shiftRepository.GetOpenShift(actor.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.shiftRepository.GetShiftReport(shift.ID)
+}
+
+func (s *ShiftService) GetShiftReport(id int, actor entity.Actor) (*entity.ShiftReport, error) {
+	if _, err := s.getOwnedShift(id, actor); err != nil {
+		return nil, err
+	}
+
+	return s.shiftRepository.GetShiftReport(id)
+}
+
+// GetShifts lists shifts; cashiers only see their own shifts.
+func (s *ShiftService) GetShifts(filter entity.ShiftFilter, actor entity.Actor) ([]entity.Shift, int64, error) {
+	if !actor.Manager {
+		filter.UserID = actor.UserID
+	}
+
+	return s.shiftRepository.GetShifts(filter)
+}
+
+func (s *ShiftService) AddCashMovement(id int, request *entity.RequestCashMovement, actor entity.Actor) (*entity.ShiftReport, error) {
+	if request.Type != constants.CashMovementIn && request.Type != constants.CashMovementOut {
+		return nil, errors.New(constants.ErrInvalidCashMovement)
+	}
+
+	if request.Amount <= 0 {
+		return nil, errors.New(constants.ErrInvalidMovementAmount)
+	}
+
+	request.Reason = strings.TrimSpace(request.Reason)
+	if request.Reason == "" {
+		return nil, errors.New(constants.ErrMovementReasonRequired)
+	}
+
+	if _, err := s.getOwnedShift(id, actor); err != nil {
+		return nil, err
+	}
+
+	movement := &entity.CashMovement{
+		ShiftID: id,
+		UserID:  actor.UserID,
+		Type:    request.Type,
+		Amount:  request.Amount,
+		Reason:  request.Reason,
+	}
+
+	if err := s.shiftRepository.AddCashMovement(movement); err != nil {
+		return nil, err
+	}
+
+	return s.shiftRepository.GetShiftReport(id)
+}
+
+func (s *ShiftService) CloseShift(id int, request *entity.RequestCloseShift, actor entity.Actor) (*entity.ShiftReport, error) {
+	if request.CountedCash < 0 {
+		return nil, errors.New(constants.ErrInvalidCountedCash)
+	}
+
+	request.Note = strings.TrimSpace(request.Note)
+
+	if _, err := s.getOwnedShift(id, actor); err != nil {
+		return nil, err
+	}
+
+	if err := s.shiftRepository.CloseShift(id, request); err != nil {
+		return nil, err
+	}
+
+	return s.shiftRepository.GetShiftReport(id)
+}
+
+// getOwnedShift loads a shift, hiding shifts of other cashiers unless the actor is a manager.
+func (s *ShiftService) getOwnedShift(id int, actor entity.Actor) (*entity.Shift, error) {
+	shift, err := s.shiftRepository.GetShiftByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !actor.Manager && shift.UserID != actor.UserID {
+		return nil, errors.New(constants.ErrShiftNotFound)
+	}
+
+	return shift, nil
+}
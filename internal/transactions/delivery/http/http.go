@@ -5,10 +5,15 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
+	"github.com/go-chi/chi/v5"
 	constants "github.com/pandusatrianura/kasir_api_service/constant"
 	"github.com/pandusatrianura/kasir_api_service/internal/transactions/entity"
 	"github.com/pandusatrianura/kasir_api_service/internal/transactions/service"
+	"github.com/pandusatrianura/kasir_api_service/pkg/datetime"
+	"github.com/pandusatrianura/kasir_api_service/pkg/pagination"
+	"github.com/pandusatrianura/kasir_api_service/pkg/receipt"
 	"github.com/pandusatrianura/kasir_api_service/pkg/response"
 )
 
@@ -54,9 +59,12 @@ func (h *TransactionHandler) API(w http.ResponseWriter, r *http.Request) {
 // @Produce json
 // @Param X-API-Key header string true "your-secret-api-key-here"
 // @Param Authorization header string true "Bearer <token>"
+// @Param X-Terminal-ID header string false "Terminal (register) the sale is rung up on"
+// @Param Idempotency-Key header string false "Unique key per sale; retries with the same key and body replay the original response"
 // @Param checkout body entity.Checkout true "Checkout Data"
 // @Success 201 {object} map[string]interface{}
 // @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /api/transactions/checkout [post]
 func (h *TransactionHandler) Checkout(w http.ResponseWriter, r *http.Request) {
@@ -66,10 +74,9 @@ func (h *TransactionHandler) Checkout(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var (
-		request   entity.Checkout
-		checkouts []entity.CheckoutRequest
-		err       error
-		resp      interface{}
+		request entity.Checkout
+		err     error
+		resp    *entity.CheckoutResponse
 	)
 
 	role := r.Header.Get("X-User-Roles")
@@ -88,12 +95,19 @@ func (h *TransactionHandler) Checkout(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	for _, checkout := range request.Checkouts {
-		checkouts = append(checkouts, checkout)
+	for _, line := range request.Checkouts {
+		if line.Quantity <= 0 {
+			response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidCheckoutRequest, errors.New(constants.ErrInvalidQuantity))
+			return
+		}
 	}
 
-	if resp, err = h.service.Checkout(checkouts); err != nil {
-		response.Error(w, http.StatusInternalServerError, constants.ErrorCode, "Checkout created failed", err)
+	request.UserID, _ = strconv.Atoi(r.Header.Get("X-User-ID"))
+	request.TerminalID = strings.TrimSpace(r.Header.Get("X-Terminal-ID"))
+	request.IdempotencyKey = strings.TrimSpace(r.Header.Get("Idempotency-Key"))
+
+	if resp, err = h.service.Checkout(request); err != nil {
+		response.Error(w, checkoutErrorStatus(err), constants.ErrorCode, "Checkout created failed", err)
 		return
 	}
 
@@ -102,5 +116,476 @@ func (h *TransactionHandler) Checkout(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if resp.Replayed {
+		w.Header().Set("Idempotent-Replayed", "true")
+	}
+
 	response.Success(w, http.StatusCreated, constants.SuccessCode, "Checkout created successfully", resp)
 }
+
+// Quote godoc
+// @Summary Quote a checkout
+// @Description Price a checkout exactly like POST /api/transactions/checkout, with promotions, points, tax and service charge, without recording the sale or touching stock. Payments are optional. Every reason the checkout would currently be rejected, such as insufficient stock, is returned in warnings.
+// @Tags transactions
+// @Accept json
+// @Produce json
+// @Param X-API-Key header string true "your-secret-api-key-here"
+// @Param Authorization header string true "Bearer <token>"
+// @Param checkout body entity.Checkout true "Checkout Data"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/transactions/quote [post]
+func (h *TransactionHandler) Quote(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		response.Error(w, http.StatusMethodNotAllowed, constants.ErrorCode, constants.ErrInvalidMethod, nil)
+		return
+	}
+
+	var (
+		request entity.Checkout
+		err     error
+		quote   *entity.QuoteResponse
+	)
+
+	role := r.Header.Get("X-User-Roles")
+	if role == "" {
+		response.Error(w, http.StatusUnauthorized, constants.ErrorCode, constants.ErrRoleNotAuthorized, errors.New(fmt.Sprintf("%s", role)))
+		return
+	}
+
+	if err = response.ParseJSON(r, &request); err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidCheckoutRequest, err)
+		return
+	}
+
+	if len(request.Checkouts) == 0 {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidCheckoutRequest, errors.New("checkouts is empty"))
+		return
+	}
+
+	for _, line := range request.Checkouts {
+		if line.Quantity <= 0 {
+			response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidCheckoutRequest, errors.New(constants.ErrInvalidQuantity))
+			return
+		}
+	}
+
+	request.UserID, _ = strconv.Atoi(r.Header.Get("X-User-ID"))
+
+	if quote, err = h.service.Quote(request); err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, "Checkout quote failed", err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, constants.SuccessCode, "Checkout quoted successfully", quote)
+}
+
+// GetTransactions godoc
+// @Summary Get transaction history
+// @Description Get transaction history with pagination, date range, cashier and amount filters
+// @Tags transactions
+// @Accept json
+// @Produce json
+// @Param X-API-Key header string true "your-secret-api-key-here"
+// @Param Authorization header string true "Bearer <token>"
+// @Param page query int false "Page (default 1)"
+// @Param limit query int false "Limit per page (default 20, max 100)"
+// @Param invoice_number query string false "Invoice number, or part of it"
+// @Param start_date query string false "Start Date (YYYY-MM-DD)"
+// @Param end_date query string false "End Date (YYYY-MM-DD)"
+// @Param cashier_id query int false "Cashier (user) ID"
+// @Param terminal_id query string false "Terminal ID"
+// @Param needs_review query bool false "Only offline sales flagged for review"
+// @Param min_amount query int false "Minimum total amount"
+// @Param max_amount query int false "Maximum total amount"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/transactions [get]
+func (h *TransactionHandler) GetTransactions(w http.ResponseWriter, r *http.Request) {
+	var (
+		filter entity.TransactionFilter
+		err    error
+	)
+
+	role := r.Header.Get("X-User-Roles")
+	if role == "" {
+		response.Error(w, http.StatusUnauthorized, constants.ErrorCode, constants.ErrRoleNotAuthorized, errors.New(fmt.Sprintf("%s", role)))
+		return
+	}
+
+	query := r.URL.Query()
+	filter.Page, filter.Limit = pagination.Parse(r)
+
+	if filter.StartDate, err = parseDateFilter(query.Get("start_date"), "00:00:00"); err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidTransactionList, err)
+		return
+	}
+
+	if filter.EndDate, err = parseDateFilter(query.Get("end_date"), "23:59:59"); err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidTransactionList, err)
+		return
+	}
+
+	if filter.StartDate != "" && filter.EndDate != "" && filter.StartDate > filter.EndDate {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidTransactionList, errors.New(constants.ErrStarDate))
+		return
+	}
+
+	if filter.CashierID, err = parseIntFilter(query.Get("cashier_id")); err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidTransactionList, err)
+		return
+	}
+
+	filter.TerminalID = strings.TrimSpace(query.Get("terminal_id"))
+	filter.InvoiceNumber = strings.TrimSpace(query.Get("invoice_number"))
+
+	if needsReview := query.Get("needs_review"); needsReview != "" {
+		if filter.NeedsReview, err = strconv.ParseBool(needsReview); err != nil {
+			response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidTransactionList, err)
+			return
+		}
+	}
+
+	if filter.MinAmount, err = parseIntFilter(query.Get("min_amount")); err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidTransactionList, err)
+		return
+	}
+
+	if filter.MaxAmount, err = parseIntFilter(query.Get("max_amount")); err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidTransactionList, err)
+		return
+	}
+
+	transactions, total, err := h.service.GetTransactions(filter)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, constants.ErrorCode, "Transactions retrieved failed", err)
+		return
+	}
+
+	response.SuccessWithMeta(w, http.StatusOK, constants.SuccessCode, "Transactions retrieved successfully", transactions, pagination.NewMeta(filter.Page, filter.Limit, total))
+}
+
+// GetTransactionByID godoc
+// @Summary Get a transaction by ID
+// @Description Get a transaction with its transaction details by ID
+// @Tags transactions
+// @Accept json
+// @Produce json
+// @Param X-API-Key header string true "your-secret-api-key-here"
+// @Param Authorization header string true "Bearer <token>"
+// @Param id path int true "Transaction ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/transactions/{id} [get]
+func (h *TransactionHandler) GetTransactionByID(w http.ResponseWriter, r *http.Request) {
+	role := r.Header.Get("X-User-Roles")
+	if role == "" {
+		response.Error(w, http.StatusUnauthorized, constants.ErrorCode, constants.ErrRoleNotAuthorized, errors.New(fmt.Sprintf("%s", role)))
+		return
+	}
+
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidTransactionID, err)
+		return
+	}
+
+	transaction, err := h.service.GetTransactionByID(id)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, constants.ErrorCode, "Transaction retrieved failed", err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, constants.SuccessCode, "Transaction retrieved successfully", transaction)
+}
+
+// VoidTransaction godoc
+// @Summary Void a transaction
+// @Description Void (fully refund) a completed transaction and return every line's quantity to stock
+// @Tags transactions
+// @Accept json
+// @Produce json
+// @Param X-API-Key header string true "your-secret-api-key-here"
+// @Param Authorization header string true "Bearer <token>"
+// @Param id path int true "Transaction ID"
+// @Param void body entity.VoidRequest true "Void Data"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/transactions/{id}/void [post]
+func (h *TransactionHandler) VoidTransaction(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		response.Error(w, http.StatusMethodNotAllowed, constants.ErrorCode, constants.ErrInvalidMethod, nil)
+		return
+	}
+
+	role := r.Header.Get("X-User-Roles")
+	if role != constants.ManagerRole {
+		response.Error(w, http.StatusUnauthorized, constants.ErrorCode, constants.ErrRoleNotAuthorized, errors.New(fmt.Sprintf("%s", role)))
+		return
+	}
+
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidTransactionID, err)
+		return
+	}
+
+	var request entity.VoidRequest
+	if err := response.ParseJSON(r, &request); err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidVoidRequest, err)
+		return
+	}
+
+	request.UserID, _ = strconv.Atoi(r.Header.Get("X-User-ID"))
+
+	transaction, err := h.service.VoidTransaction(id, request)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, constants.ErrorCode, "Transaction void failed", err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, constants.SuccessCode, "Transaction voided successfully", transaction)
+}
+
+// Sync godoc
+// @Summary Upload sales captured offline
+// @Description Record a batch of up to 100 sales captured while the terminal was offline. Every sale carries the UUID the terminal generated for it (client_id) and the time it was rung up; uploading a sale again returns it as a duplicate. Sales without enough stock are still recorded, take stock below zero and are flagged for review. The result of every sale is accepted, duplicate or rejected.
+// @Tags transactions
+// @Accept json
+// @Produce json
+// @Param X-API-Key header string true "your-secret-api-key-here"
+// @Param Authorization header string true "Bearer <token>"
+// @Param X-Terminal-ID header string false "Terminal (register) the sales were rung up on"
+// @Param sync body entity.SyncRequest true "Offline Sales"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/transactions/sync [post]
+func (h *TransactionHandler) Sync(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		response.Error(w, http.StatusMethodNotAllowed, constants.ErrorCode, constants.ErrInvalidMethod, nil)
+		return
+	}
+
+	role := r.Header.Get("X-User-Roles")
+	if role != constants.KasirRole {
+		response.Error(w, http.StatusUnauthorized, constants.ErrorCode, constants.ErrRoleNotAuthorized, errors.New(fmt.Sprintf("%s", role)))
+		return
+	}
+
+	var request entity.SyncRequest
+	if err := response.ParseJSON(r, &request); err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidSyncRequest, err)
+		return
+	}
+
+	request.UserID, _ = strconv.Atoi(r.Header.Get("X-User-ID"))
+	request.TerminalID = strings.TrimSpace(r.Header.Get("X-Terminal-ID"))
+
+	results, err := h.service.Sync(request)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidSyncRequest, err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, constants.SuccessCode, "Offline transactions synced", results)
+}
+
+// MarkReviewed godoc
+// @Summary Mark an offline sale as reviewed
+// @Description Clear the review flag of an offline sale that took stock below zero
+// @Tags transactions
+// @Accept json
+// @Produce json
+// @Param X-API-Key header string true "your-secret-api-key-here"
+// @Param Authorization header string true "Bearer <token>"
+// @Param id path int true "Transaction ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/transactions/{id}/review [post]
+func (h *TransactionHandler) MarkReviewed(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		response.Error(w, http.StatusMethodNotAllowed, constants.ErrorCode, constants.ErrInvalidMethod, nil)
+		return
+	}
+
+	role := r.Header.Get("X-User-Roles")
+	if role != constants.ManagerRole {
+		response.Error(w, http.StatusUnauthorized, constants.ErrorCode, constants.ErrRoleNotAuthorized, errors.New(fmt.Sprintf("%s", role)))
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidTransactionID, err)
+		return
+	}
+
+	transaction, err := h.service.MarkReviewed(id)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, constants.ErrorCode, "Transaction review failed", err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, constants.SuccessCode, "Transaction marked as reviewed", transaction)
+}
+
+// GetReceipt godoc
+// @Summary Get the receipt of a transaction
+// @Description Render the receipt of a transaction as plain text, raw ESC/POS bytes, HTML or PDF. Text, ESC/POS and PDF use a fixed width of 32 (58mm paper) or 48 (80mm paper) characters.
+// @Tags transactions
+// @Produce plain
+// @Produce html
+// @Produce octet-stream
+// @Produce application/pdf
+// @Param X-API-Key header string true "your-secret-api-key-here"
+// @Param Authorization header string true "Bearer <token>"
+// @Param id path int true "Transaction ID"
+// @Param format query string false "Receipt format: text, escpos, html or pdf (default text)"
+// @Param width query int false "Characters per line: 32 or 48 (default 32)"
+// @Success 200 {string} string
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/transactions/{id}/receipt [get]
+func (h *TransactionHandler) GetReceipt(w http.ResponseWriter, r *http.Request) {
+	role := r.Header.Get("X-User-Roles")
+	if role == "" {
+		response.Error(w, http.StatusUnauthorized, constants.ErrorCode, constants.ErrRoleNotAuthorized, errors.New(fmt.Sprintf("%s", role)))
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidTransactionID, err)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = receipt.FormatText
+	}
+
+	if !receipt.IsFormat(format) {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidReceiptFormat, errors.New(format))
+		return
+	}
+
+	width := receipt.Width58mm
+	if value := r.URL.Query().Get("width"); value != "" {
+		if width, err = strconv.Atoi(value); err != nil || (width != receipt.Width58mm && width != receipt.Width80mm) {
+			response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidReceiptWidth, errors.New(value))
+			return
+		}
+	}
+
+	rcpt, err := h.service.GetReceipt(id)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, constants.ErrorCode, "Receipt retrieved failed", err)
+		return
+	}
+
+	var (
+		body        []byte
+		contentType string
+	)
+
+	switch format {
+	case receipt.FormatESCPOS:
+		body, contentType = receipt.ESCPOS(*rcpt, width), "application/octet-stream"
+	case receipt.FormatHTML:
+		page, err := receipt.HTML(*rcpt)
+		if err != nil {
+			response.Error(w, http.StatusInternalServerError, constants.ErrorCode, "Receipt retrieved failed", err)
+			return
+		}
+		body, contentType = []byte(page), "text/html; charset=utf-8"
+	case receipt.FormatPDF:
+		body, contentType = receipt.PDF(*rcpt, width), "application/pdf"
+	default:
+		body, contentType = []byte(receipt.Text(*rcpt, width)), "text/plain; charset=utf-8"
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	if format == receipt.FormatESCPOS || format == receipt.FormatPDF {
+		w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=receipt-%d.%s", id, format))
+	}
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(body)
+}
+
+// checkoutErrorStatus answers a checkout the request itself got wrong with 400 and one that conflicts with the
+// current stock, points, credit, shift or an earlier request with 409. Anything else is a server error.
+func checkoutErrorStatus(err error) int {
+	badRequest := []string{
+		constants.ErrInvalidIdempotencyKey,
+		constants.ErrInvalidRedeemPoints,
+		constants.ErrRedeemNeedsCustomer,
+		constants.ErrRedeemExceedsTotal,
+		constants.ErrCreditNeedsCustomer,
+		constants.ErrCreditOverpayment,
+		constants.ErrPaymentRequired,
+		constants.ErrInvalidPaymentMethod,
+		constants.ErrInvalidPaymentAmount,
+		constants.ErrUnderpayment,
+		constants.ErrNonCashOverpayment,
+		constants.ErrInvalidQuantity,
+		constants.ErrInvalidBarcode,
+		constants.ErrProductNotFound,
+		constants.ErrVariantRequired,
+		constants.ErrUnitNotFound,
+		constants.ErrCustomerNotFound,
+	}
+
+	conflict := []string{
+		constants.ErrStockNotEnough,
+		constants.ErrPointsNotEnough,
+		constants.ErrCreditLimitExceeded,
+		constants.ErrShiftNotOpen,
+		constants.ErrIdempotencyKeyReused,
+		constants.ErrIdempotencyInProgress,
+		constants.ErrDuplicateClientID,
+	}
+
+	// Some errors carry details after a colon, e.g. "stock not enough: Indomie (requested 5 pcs, available 2 pcs)".
+	matches := func(messages []string) bool {
+		for _, message := range messages {
+			if err.Error() == message || strings.HasPrefix(err.Error(), message+": ") {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	switch {
+	case matches(badRequest):
+		return http.StatusBadRequest
+	case matches(conflict):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func parseDateFilter(date string, clock string) (string, error) {
+	if date == "" {
+		return "", nil
+	}
+
+	return datetime.ParseUTC(fmt.Sprintf("%s %s", date, clock))
+}
+
+func parseIntFilter(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	return strconv.Atoi(value)
+}
@@ -0,0 +1,33 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	constants "github.com/pandusatrianura/kasir_api_service/constant"
+)
+
+func TestCheckoutErrorStatus(t *testing.T) {
+	tests := []struct {
+		err  string
+		want int
+	}{
+		{constants.ErrUnderpayment, http.StatusBadRequest},
+		{constants.ErrInvalidPaymentMethod + ": cheque", http.StatusBadRequest},
+		{constants.ErrProductNotFound + ": id 9; " + constants.ErrStockNotEnough + ": Indomie (requested 5 pcs, available 2 pcs)", http.StatusBadRequest},
+		{constants.ErrStockNotEnough + ": Indomie (requested 5 pcs, available 2 pcs)", http.StatusConflict},
+		{constants.ErrStockNotEnough, http.StatusConflict},
+		{constants.ErrCreditLimitExceeded, http.StatusConflict},
+		{constants.ErrShiftNotOpen, http.StatusConflict},
+		{constants.ErrIdempotencyKeyReused, http.StatusConflict},
+		{"pq: deadlock detected", http.StatusInternalServerError},
+		{constants.ErrStockNotEnough + "ed", http.StatusInternalServerError},
+	}
+
+	for _, test := range tests {
+		if got := checkoutErrorStatus(errors.New(test.err)); got != test.want {
+			t.Errorf("checkoutErrorStatus(%q) = %d, want %d", test.err, got, test.want)
+		}
+	}
+}
@@ -1,15 +1,42 @@
 package entity
 
+import (
+	"time"
+
+	promotionEntity "github.com/pandusatrianura/kasir_api_service/internal/promotions/entity"
+)
+
 type HealthCheck struct {
 	Name      string `json:"name"`
 	IsHealthy bool   `json:"is_healthy"`
 }
 
 type Transaction struct {
-	ID          int    `json:"id"`
-	TotalAmount int    `json:"total_amount"`
-	CreatedAt   string `json:"created_at,omitempty"`
-	UpdatedAt   string `json:"updated_at,omitempty"`
+	ID             int     `json:"id"`
+	InvoiceNumber  string  `json:"invoice_number,omitempty"`
+	UserID         int     `json:"user_id,omitempty"`
+	TerminalID     string  `json:"terminal_id,omitempty"`
+	ShiftID        int     `json:"shift_id,omitempty"`
+	ClientID       string  `json:"client_id,omitempty"`
+	NeedsReview    bool    `json:"needs_review,omitempty"`
+	CustomerID     int     `json:"customer_id,omitempty"`
+	PointsEarned   int     `json:"points_earned,omitempty"`
+	PointsRedeemed int     `json:"points_redeemed,omitempty"`
+	PointsDiscount int     `json:"points_discount,omitempty"`
+	SubtotalAmount int     `json:"subtotal_amount"`
+	DiscountAmount int     `json:"discount_amount"`
+	NetAmount      int     `json:"net_amount"`
+	TaxAmount      int     `json:"tax_amount"`
+	ServiceCharge  int     `json:"service_charge"`
+	TotalAmount    int     `json:"total_amount"`
+	PaidAmount     int     `json:"paid_amount"`
+	Change         int     `json:"change"`
+	Status         string  `json:"status,omitempty"`
+	VoidReason     string  `json:"void_reason,omitempty"`
+	VoidedBy       int     `json:"voided_by,omitempty"`
+	VoidedAt       *string `json:"voided_at,omitempty"`
+	CreatedAt      string  `json:"created_at,omitempty"`
+	UpdatedAt      string  `json:"updated_at,omitempty"`
 }
 
 type TransactionDetail struct {
@@ -23,41 +50,237 @@ type TransactionDetail struct {
 }
 
 type Checkout struct {
-	Checkouts []CheckoutRequest `json:"checkout"`
+	UserID            int                         `json:"-"`
+	TerminalID        string                      `json:"-"`
+	IdempotencyKey    string                      `json:"-"`
+	InvoicePrefix     string                      `json:"-"`
+	ShiftRequired     bool                        `json:"-"`
+	ClientID          string                      `json:"-"`
+	SoldAt            time.Time                   `json:"-"`
+	AllowNegative     bool                        `json:"-"`
+	LowStockThreshold int                         `json:"-"`
+	CustomerID        int                         `json:"customer_id,omitempty"`
+	RedeemPoints      int                         `json:"redeem_points,omitempty"`
+	Checkouts         []CheckoutRequest           `json:"checkout"`
+	Payments          []PaymentRequest            `json:"payments"`
+	Promotions        []promotionEntity.Promotion `json:"-"`
+	Tax               TaxConfig                   `json:"-"`
+	Loyalty           LoyaltyConfig               `json:"-"`
+}
+
+// TaxConfig holds the store wide PPN rate and service charge. Rates are percentages; when Inclusive is set
+// product prices already contain the tax and it is extracted from them instead of added on top.
+type TaxConfig struct {
+	Rate              float64
+	Inclusive         bool
+	ServiceChargeRate float64
+}
+
+// LoyaltyConfig holds the loyalty program: a customer earns one point for every SpendPerPoint rupiah of the total
+// and every redeemed point takes PointValue rupiah off the sale.
+type LoyaltyConfig struct {
+	SpendPerPoint int
+	PointValue    int
+}
+
+type PaymentRequest struct {
+	Method    string `json:"method"`
+	Amount    int    `json:"amount"`
+	Reference string `json:"reference,omitempty"`
+}
+
+type Payment struct {
+	ID            int    `json:"id"`
+	TransactionID int    `json:"transaction_id"`
+	Method        string `json:"method"`
+	Amount        int    `json:"amount"`
+	Reference     string `json:"reference,omitempty"`
+	CreatedAt     string `json:"created_at,omitempty"`
 }
 
+// CheckoutRequest is one line of a sale. A line names its product either by product_id or by a scanned barcode;
+// the barcode is only used when product_id is not set, and a barcode of a variant sells that variant. A product
+// with variants is sold per variant. Quantity is counted in Unit, the base unit of the product when it is empty.
 type CheckoutRequest struct {
-	ProductID int `json:"product_id"`
-	Quantity  int `json:"quantity"`
+	ProductID int    `json:"product_id,omitempty"`
+	VariantID int    `json:"variant_id,omitempty"`
+	Barcode   string `json:"barcode,omitempty"`
+	Unit      string `json:"unit,omitempty"`
+	Quantity  int    `json:"quantity"`
+}
+
+// ScannedItem is the product, and the variant for a barcode of a variant, a scanned barcode belongs to.
+type ScannedItem struct {
+	ProductID int
+	VariantID int
 }
 
 type CheckoutProductDetail struct {
-	ID           int    `json:"product_id"`
-	Name         string `json:"product_name"`
-	Quantity     int    `json:"quantity"`
-	Price        int    `json:"price"`
-	Stock        int    `json:"stock"`
-	CategoryID   int    `json:"category_id"`
-	CategoryName string `json:"category_name"`
+	ID           int     `json:"product_id"`
+	VariantID    int     `json:"variant_id,omitempty"`
+	HasVariants  bool    `json:"-"`
+	Unit         string  `json:"unit"`
+	UnitFactor   int     `json:"unit_factor"`
+	BaseUnit     string  `json:"-"`
+	Name         string  `json:"product_name"`
+	SKU          string  `json:"sku,omitempty"`
+	Quantity     int     `json:"quantity"`
+	Price        int     `json:"price"`
+	Stock        int     `json:"stock"`
+	CategoryID   int     `json:"category_id"`
+	CategoryName string  `json:"category_name"`
+	TaxRate      float64 `json:"tax_rate"`
+	HasTaxRate   bool    `json:"-"`
+	TaxExempt    bool    `json:"tax_exempt"`
 }
 
 type CheckoutProduct struct {
-	ProductID           int    `json:"product_id"`
-	TransactionDetailID int    `json:"transaction_detail_id"`
-	TransactionID       int    `json:"transaction_id"`
-	Name                string `json:"product_name"`
-	Quantity            int    `json:"quantity"`
-	Subtotal            int    `json:"subtotal"`
-	CategoryID          int    `json:"category_id"`
-	CategoryName        string `json:"category_name"`
+	ProductID           int                `json:"product_id"`
+	VariantID           int                `json:"variant_id,omitempty"`
+	TransactionDetailID int                `json:"transaction_detail_id"`
+	TransactionID       int                `json:"transaction_id"`
+	Name                string             `json:"product_name"`
+	SKU                 string             `json:"sku,omitempty"`
+	Unit                string             `json:"unit,omitempty"`
+	UnitFactor          int                `json:"unit_factor,omitempty"`
+	Quantity            int                `json:"quantity"`
+	UnitPrice           int                `json:"unit_price"`
+	Subtotal            int                `json:"subtotal"`
+	DiscountAmount      int                `json:"discount_amount"`
+	NetAmount           int                `json:"net_amount"`
+	TaxRate             float64            `json:"tax_rate"`
+	TaxAmount           int                `json:"tax_amount"`
+	Promotions          []AppliedPromotion `json:"promotions,omitempty"`
+	CategoryID          int                `json:"category_id"`
+	CategoryName        string             `json:"category_name"`
+}
+
+type AppliedPromotion struct {
+	PromotionID    int    `json:"promotion_id"`
+	PromotionName  string `json:"promotion_name"`
+	DiscountAmount int    `json:"discount_amount"`
 }
 
 type CheckoutResponse struct {
 	Transaction      Transaction       `json:"transaction"`
 	CheckoutProducts []CheckoutProduct `json:"transaction_details"`
+	Payments         []Payment         `json:"payments"`
+	Replayed         bool              `json:"-"`
+}
+
+// QuoteResponse is the price of a sale that has not been recorded. Warnings lists every reason the same checkout
+// would currently be rejected.
+type QuoteResponse struct {
+	SubtotalAmount int               `json:"subtotal_amount"`
+	DiscountAmount int               `json:"discount_amount"`
+	PointsDiscount int               `json:"points_discount"`
+	NetAmount      int               `json:"net_amount"`
+	TaxAmount      int               `json:"tax_amount"`
+	ServiceCharge  int               `json:"service_charge"`
+	TotalAmount    int               `json:"total_amount"`
+	PaidAmount     int               `json:"paid_amount"`
+	Change         int               `json:"change"`
+	PointsEarned   int               `json:"points_earned"`
+	Lines          []CheckoutProduct `json:"lines"`
+	Warnings       []string          `json:"warnings"`
+}
+
+// IdempotencyKey remembers a checkout request per cashier so retries of the same request replay the sale it recorded.
+type IdempotencyKey struct {
+	UserID        int
+	Key           string
+	RequestHash   string
+	Status        string
+	TransactionID int
+	Response      string
+}
+
+// SyncRequest is a batch of sales captured while the terminal was offline.
+type SyncRequest struct {
+	UserID       int                  `json:"-"`
+	TerminalID   string               `json:"-"`
+	Transactions []OfflineTransaction `json:"transactions"`
+}
+
+// OfflineTransaction is a sale captured offline. ClientID is the UUID the terminal generated for the sale and
+// CreatedAt the RFC3339 time it was rung up.
+type OfflineTransaction struct {
+	ClientID     string            `json:"client_id"`
+	CreatedAt    string            `json:"created_at"`
+	CustomerID   int               `json:"customer_id,omitempty"`
+	RedeemPoints int               `json:"redeem_points,omitempty"`
+	Checkouts    []CheckoutRequest `json:"checkout"`
+	Payments     []PaymentRequest  `json:"payments"`
+}
+
+type SyncResult struct {
+	ClientID      string `json:"client_id"`
+	Status        string `json:"status"`
+	TransactionID int    `json:"transaction_id,omitempty"`
+	InvoiceNumber string `json:"invoice_number,omitempty"`
+	NeedsReview   bool   `json:"needs_review,omitempty"`
+	Error         string `json:"error,omitempty"`
+}
+
+// TransactionEvent is the payload of the transaction.created event.
+type TransactionEvent struct {
+	Transaction Transaction       `json:"transaction"`
+	Lines       []CheckoutProduct `json:"lines"`
+	Payments    []PaymentRequest  `json:"payments"`
+}
+
+// VoidEvent is the payload of the transaction.voided event.
+type VoidEvent struct {
+	TransactionID int    `json:"transaction_id"`
+	InvoiceNumber string `json:"invoice_number,omitempty"`
+	TotalAmount   int    `json:"total_amount"`
+	Reason        string `json:"reason"`
+	VoidedBy      int    `json:"voided_by"`
+}
+
+// StockLevel is the stock of a product, or of the variant sold, after a sale. It is the payload of the
+// product.stock_low event.
+type StockLevel struct {
+	ProductID int    `json:"product_id"`
+	VariantID int    `json:"variant_id,omitempty"`
+	Name      string `json:"product_name"`
+	Stock     int    `json:"stock"`
+	Sold      int    `json:"-"`
+	Threshold int    `json:"threshold"`
+}
+
+type VoidRequest struct {
+	UserID int    `json:"-"`
+	Reason string `json:"reason"`
+}
+
+type TransactionFilter struct {
+	Page          int
+	Limit         int
+	InvoiceNumber string
+	StartDate     string
+	EndDate       string
+	CashierID     int
+	TerminalID    string
+	NeedsReview   bool
+	MinAmount     int
+	MaxAmount     int
+}
+
+// ProductVariant is the part of a product variant a sale is priced with.
+type ProductVariant struct {
+	ID        int
+	ProductID int
+	Name      string
+	SKU       string
+	Price     int
+	Stock     int
 }
 
-type UpdatedProduct struct {
-	ID    int `json:"id"`
-	Stock int `json:"stock"`
+// ProductUnit is a unit a product is sold in besides its base unit.
+type ProductUnit struct {
+	ProductID int
+	Name      string
+	Factor    int
+	Price     int
 }
@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"github.com/lib/pq"
+	"github.com/pandusatrianura/kasir_api_service/internal/transactions/entity"
+	"github.com/pandusatrianura/kasir_api_service/pkg/database"
+)
+
+// GetItemsByBarcode maps normalized barcodes to the products, and for barcodes of a variant also the variants, they
+// belong to. Unknown barcodes are left out.
+func (t *TransactionsRepository) GetItemsByBarcode(codes []string) (map[string]entity.ScannedItem, error) {
+	var (
+		items map[string]entity.ScannedItem
+		query string
+		err   error
+	)
+
+	items = make(map[string]entity.ScannedItem, len(codes))
+
+	query = "SELECT code, product_id, COALESCE(variant_id, 0) FROM product_barcodes WHERE code = ANY($1)"
+	err = t.db.WithStmt(query, func(stmt *database.Stmt) error {
+		scanFn := func(rows *database.Rows) error {
+			var (
+				code string
+				item entity.ScannedItem
+			)
+
+			if err := rows.Scan(&code, &item.ProductID, &item.VariantID); err != nil {
+				return err
+			}
+
+			items[code] = item
+			return nil
+		}
+
+		return stmt.Query(scanFn, pq.Array(codes))
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
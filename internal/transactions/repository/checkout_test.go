@@ -0,0 +1,102 @@
+package repository_test
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	constants "github.com/pandusatrianura/kasir_api_service/constant"
+	"github.com/pandusatrianura/kasir_api_service/internal/transactions/entity"
+	"github.com/pandusatrianura/kasir_api_service/internal/transactions/repository"
+	"github.com/pandusatrianura/kasir_api_service/pkg/database/dbtest"
+)
+
+func TestCheckoutNeverOversells(t *testing.T) {
+	const (
+		stock     = 5
+		checkouts = 20
+		price     = 10000
+	)
+
+	db := dbtest.Open(t)
+
+	var userID, categoryID, productID int
+	if err := db.QueryRow("INSERT INTO users (name, email, password) VALUES ('Kasir', 'kasir@example.com', 'x') RETURNING id").Scan(&userID); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.QueryRow("INSERT INTO categories (name, description) VALUES ('Makanan', '') RETURNING id").Scan(&categoryID); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.QueryRow("INSERT INTO products (name, price, stock, category_id) VALUES ('Indomie', $1, $2, $3) RETURNING id", price, stock, categoryID).Scan(&productID); err != nil {
+		t.Fatal(err)
+	}
+
+	repo := repository.NewTransactionsRepository(db)
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		succeeded int
+		errs      []error
+	)
+
+	start := make(chan struct{})
+	for i := 0; i < checkouts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+
+			_, err := repo.Checkout(entity.Checkout{
+				UserID:            userID,
+				TerminalID:        "KASIR-01",
+				InvoicePrefix:     "TEST",
+				LowStockThreshold: -1,
+				Checkouts:         []entity.CheckoutRequest{{ProductID: productID, Quantity: 1}},
+				Payments:          []entity.PaymentRequest{{Method: constants.PaymentMethodCash, Amount: price}},
+			})
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if err != nil {
+				errs = append(errs, err)
+				return
+			}
+
+			succeeded++
+		}()
+	}
+
+	close(start)
+	wg.Wait()
+
+	if succeeded != stock {
+		t.Fatalf("%d checkouts succeeded, want %d", succeeded, stock)
+	}
+
+	for _, err := range errs {
+		if !strings.Contains(err.Error(), constants.ErrStockNotEnough) {
+			t.Fatalf("checkout failed with %v, want %s", err, constants.ErrStockNotEnough)
+		}
+	}
+
+	var remaining, sold int
+	if err := db.QueryRow("SELECT stock FROM products WHERE id = $1", productID).Scan(&remaining); err != nil {
+		t.Fatal(err)
+	}
+
+	if remaining != 0 {
+		t.Fatalf("stock is %d after the sales, want 0", remaining)
+	}
+
+	if err := db.QueryRow("SELECT COALESCE(SUM(quantity), 0) FROM transaction_details WHERE product_id = $1", productID).Scan(&sold); err != nil {
+		t.Fatal(err)
+	}
+
+	if sold != stock {
+		t.Fatalf("%d units were sold, want %d", sold, stock)
+	}
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	constants "github.com/pandusatrianura/kasir_api_service/constant"
+	"github.com/pandusatrianura/kasir_api_service/internal/transactions/entity"
+	"github.com/pandusatrianura/kasir_api_service/pkg/database"
+)
+
+// ReserveIdempotencyKey claims a key for a checkout request. It returns nil when the key is new (or its previous
+// use has expired) and the caller may run the checkout, otherwise it returns the key as it was stored earlier.
+func (t *TransactionsRepository) ReserveIdempotencyKey(key entity.IdempotencyKey, ttl time.Duration) (*entity.IdempotencyKey, error) {
+	var (
+		reservedID int
+		stored     entity.IdempotencyKey
+		query      string
+		err        error
+	)
+
+	query = "INSERT INTO idempotency_keys (user_id, idempotency_key, request_hash, status, created_at, expires_at) VALUES ($1, $2, $3, $4, now(), now() + $5::interval) ON CONFLICT (user_id, idempotency_key) DO UPDATE SET request_hash = EXCLUDED.request_hash, status = EXCLUDED.status, transaction_id = NULL, response = NULL, created_at = EXCLUDED.created_at, expires_at = EXCLUDED.expires_at WHERE idempotency_keys.expires_at < now() RETURNING id"
+	err = t.db.WithStmt(query, func(stmt *database.Stmt) error {
+		scanFn := func(rows *database.Rows) error {
+			return rows.Scan(&reservedID)
+		}
+
+		return stmt.Query(scanFn, key.UserID, key.Key, key.RequestHash, constants.IdempotencyStatusProcessing, fmt.Sprintf("%d seconds", int64(ttl.Seconds())))
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if reservedID != 0 {
+		return nil, nil
+	}
+
+	query = "SELECT user_id, idempotency_key, request_hash, status, COALESCE(transaction_id, 0), COALESCE(response, '') FROM idempotency_keys WHERE user_id = $1 AND idempotency_key = $2"
+	err = t.db.WithStmt(query, func(stmt *database.Stmt) error {
+		return stmt.QueryRow(key.UserID, key.Key).Scan(&stored.UserID, &stored.Key, &stored.RequestHash, &stored.Status, &stored.TransactionID, &stored.Response)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &stored, nil
+}
+
+// completeIdempotencyKey marks the key of a checkout completed with the sale it recorded. It runs inside the
+// checkout transaction, so the key is completed exactly when the sale commits.
+func completeIdempotencyKey(tx *database.Tx, checkout entity.Checkout, transactionID int) error {
+	if checkout.IdempotencyKey == "" {
+		return nil
+	}
+
+	query := "UPDATE idempotency_keys SET status = $1, transaction_id = $2 WHERE user_id = $3 AND idempotency_key = $4"
+	return tx.WithStmt(query, func(stmt *database.Stmt) error {
+		_, err := stmt.Exec(constants.IdempotencyStatusCompleted, transactionID, checkout.UserID, checkout.IdempotencyKey)
+		return err
+	})
+}
+
+// CompleteIdempotencyKey stores the checkout response so retries with the same key can replay it without reading
+// the sale back.
+func (t *TransactionsRepository) CompleteIdempotencyKey(key entity.IdempotencyKey) error {
+	query := "UPDATE idempotency_keys SET response = $1 WHERE user_id = $2 AND idempotency_key = $3"
+	return t.db.WithStmt(query, func(stmt *database.Stmt) error {
+		_, err := stmt.Exec(key.Response, key.UserID, key.Key)
+		return err
+	})
+}
+
+// ReleaseIdempotencyKey forgets a key whose checkout failed so the client can retry the request. A key whose sale
+// was recorded is already completed and is kept.
+func (t *TransactionsRepository) ReleaseIdempotencyKey(key entity.IdempotencyKey) error {
+	query := "DELETE FROM idempotency_keys WHERE user_id = $1 AND idempotency_key = $2 AND status = $3"
+	return t.db.WithStmt(query, func(stmt *database.Stmt) error {
+		_, err := stmt.Exec(key.UserID, key.Key, constants.IdempotencyStatusProcessing)
+		return err
+	})
+}
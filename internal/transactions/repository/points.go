@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+
+	constants "github.com/pandusatrianura/kasir_api_service/constant"
+	"github.com/pandusatrianura/kasir_api_service/internal/transactions/entity"
+	"github.com/pandusatrianura/kasir_api_service/pkg/database"
+)
+
+// checkPointsBalance makes sure the customer of a sale has the points being redeemed. With lock set the customer
+// stays locked until the checkout commits.
+func (t *TransactionsRepository) checkPointsBalance(q database.Querier, customerID int, redeemPoints int, lock bool) error {
+	var (
+		balance int
+		query   string
+		err     error
+	)
+
+	query = "SELECT points_balance FROM customers WHERE id = $1"
+	if lock {
+		query += " FOR UPDATE"
+	}
+
+	err = q.WithStmt(query, func(stmt *database.Stmt) error {
+		return stmt.QueryRow(customerID).Scan(&balance)
+	})
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return errors.New(constants.ErrCustomerNotFound)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if redeemPoints > balance {
+		return errors.New(constants.ErrPointsNotEnough)
+	}
+
+	return nil
+}
+
+// recordPoints writes the points redeemed and earned by a sale to the ledger of its customer.
+func (t *TransactionsRepository) recordPoints(tx *database.Tx, transaction entity.Transaction) error {
+	if transaction.CustomerID == 0 {
+		return nil
+	}
+
+	if transaction.PointsRedeemed > 0 {
+		if err := t.addPoints(tx, transaction.CustomerID, transaction.ID, constants.PointsTypeRedeem, -transaction.PointsRedeemed); err != nil {
+			return err
+		}
+	}
+
+	if transaction.PointsEarned > 0 {
+		return t.addPoints(tx, transaction.CustomerID, transaction.ID, constants.PointsTypeEarn, transaction.PointsEarned)
+	}
+
+	return nil
+}
+
+// reversePoints gives back the points a voided sale redeemed and takes back the points it earned. The balance
+// may go below zero when the earned points have already been spent.
+func (t *TransactionsRepository) reversePoints(tx *database.Tx, customerID int, transactionID int, earned int, redeemed int) error {
+	if customerID == 0 || earned == redeemed {
+		return nil
+	}
+
+	return t.addPoints(tx, customerID, transactionID, constants.PointsTypeVoid, redeemed-earned)
+}
+
+func (t *TransactionsRepository) addPoints(tx *database.Tx, customerID int, transactionID int, pointsType string, points int) error {
+	var (
+		balance int
+		query   string
+		err     error
+	)
+
+	query = "UPDATE customers SET points_balance = points_balance + $1, updated_at = $2 WHERE id = $3 RETURNING points_balance"
+	err = tx.WithStmt(query, func(stmt *database.Stmt) error {
+		return stmt.QueryRow(points, "now()", customerID).Scan(&balance)
+	})
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return errors.New(constants.ErrCustomerNotFound)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	query = "INSERT INTO customer_points_ledger (customer_id, transaction_id, type, points, balance_after, created_at) VALUES ($1, $2, $3, $4, $5, $6)"
+	return tx.WithStmt(query, func(stmt *database.Stmt) error {
+		_, err := stmt.Exec(customerID, transactionID, pointsType, points, balance, "now()")
+		return err
+	})
+}
+
+func (t *TransactionsRepository) GetCustomerName(customerID int) (string, error) {
+	var (
+		name  string
+		query string
+		err   error
+	)
+
+	query = "SELECT name FROM customers WHERE id = $1"
+	err = t.db.WithStmt(query, func(stmt *database.Stmt) error {
+		scanFn := func(rows *database.Rows) error {
+			return rows.Scan(&name)
+		}
+
+		return stmt.Query(scanFn, customerID)
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	return name, nil
+}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+
+	constants "github.com/pandusatrianura/kasir_api_service/constant"
+	"github.com/pandusatrianura/kasir_api_service/internal/transactions/entity"
+	"github.com/pandusatrianura/kasir_api_service/pkg/database"
+)
+
+// checkCreditLimit makes sure the receivable balance of the customer stays within its credit limit once the
+// credit part of a sale is added. With lock set the customer stays locked until the checkout commits.
+func (t *TransactionsRepository) checkCreditLimit(q database.Querier, customerID int, amount int, lock bool) error {
+	var (
+		creditLimit int
+		balance     int
+		query       string
+		err         error
+	)
+
+	query = "SELECT credit_limit, receivable_balance FROM customers WHERE id = $1"
+	if lock {
+		query += " FOR UPDATE"
+	}
+
+	err = q.WithStmt(query, func(stmt *database.Stmt) error {
+		return stmt.QueryRow(customerID).Scan(&creditLimit, &balance)
+	})
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return errors.New(constants.ErrCustomerNotFound)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if balance+amount > creditLimit {
+		return errors.New(constants.ErrCreditLimitExceeded)
+	}
+
+	return nil
+}
+
+// chargeCredit adds the credit part of a sale to the receivable balance of its customer.
+func (t *TransactionsRepository) chargeCredit(tx *database.Tx, transaction entity.Transaction, amount int) error {
+	if amount <= 0 {
+		return nil
+	}
+
+	return t.addReceivable(tx, transaction.CustomerID, transaction.ID, transaction.UserID, constants.ReceivableTypeCharge, amount)
+}
+
+// reverseCredit takes the credit part of a voided sale off the receivable balance of its customer, less what returns
+// of the sale already credited back. When the customer already paid it back the balance goes below zero, and the
+// next credit sale uses that deposit up first.
+func (t *TransactionsRepository) reverseCredit(tx *database.Tx, customerID int, transactionID int, userID int) error {
+	var (
+		amount int
+		query  string
+		err    error
+	)
+
+	if customerID == 0 {
+		return nil
+	}
+
+	query = "SELECT COALESCE(SUM(amount), 0) FROM customer_receivables_ledger WHERE transaction_id = $1 AND type IN ($2, $3)"
+	err = tx.WithStmt(query, func(stmt *database.Stmt) error {
+		return stmt.QueryRow(transactionID, constants.ReceivableTypeCharge, constants.ReceivableTypeReturn).Scan(&amount)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if amount <= 0 {
+		return nil
+	}
+
+	return t.addReceivable(tx, customerID, transactionID, userID, constants.ReceivableTypeVoid, -amount)
+}
+
+func (t *TransactionsRepository) addReceivable(tx *database.Tx, customerID int, transactionID int, userID int, receivableType string, amount int) error {
+	var (
+		balance int
+		query   string
+		err     error
+	)
+
+	query = "UPDATE customers SET receivable_balance = receivable_balance + $1, updated_at = $2 WHERE id = $3 RETURNING receivable_balance"
+	err = tx.WithStmt(query, func(stmt *database.Stmt) error {
+		return stmt.QueryRow(amount, "now()", customerID).Scan(&balance)
+	})
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return errors.New(constants.ErrCustomerNotFound)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	query = "INSERT INTO customer_receivables_ledger (customer_id, transaction_id, user_id, type, amount, balance_after, created_at) VALUES ($1, $2, NULLIF($3, 0), $4, $5, $6, $7)"
+	return tx.WithStmt(query, func(stmt *database.Stmt) error {
+		_, err := stmt.Exec(customerID, transactionID, userID, receivableType, amount, balance, "now()")
+		return err
+	})
+}
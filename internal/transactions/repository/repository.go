@@ -4,14 +4,37 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"math"
+	"strings"
+	"time"
 
+	"github.com/lib/pq"
 	constants "github.com/pandusatrianura/kasir_api_service/constant"
+	auditRepository "github.com/pandusatrianura/kasir_api_service/internal/audit/repository"
+	"github.com/pandusatrianura/kasir_api_service/internal/promotions/engine"
 	"github.com/pandusatrianura/kasir_api_service/internal/transactions/entity"
 	"github.com/pandusatrianura/kasir_api_service/pkg/database"
+	"github.com/pandusatrianura/kasir_api_service/pkg/datetime"
+	"github.com/pandusatrianura/kasir_api_service/pkg/outbox"
+	"github.com/pandusatrianura/kasir_api_service/pkg/pagination"
 )
 
+const transactionColumns = "id, COALESCE(invoice_number, ''), COALESCE(user_id, 0), COALESCE(terminal_id, ''), COALESCE(shift_id, 0), COALESCE(client_id::text, ''), needs_review, COALESCE(customer_id, 0), points_earned, points_redeemed, points_discount, subtotal_amount, discount_amount, net_amount, tax_amount, service_charge_amount, total_amount, paid_amount, change_amount, status, COALESCE(void_reason, ''), COALESCE(voided_by, 0), voided_at, created_at, updated_at"
+
 type ITransactionsRepository interface {
-	Checkout([]entity.CheckoutRequest) (*entity.CheckoutResponse, error)
+	Checkout(checkout entity.Checkout) (*entity.CheckoutResponse, error)
+	Quote(checkout entity.Checkout) (*entity.QuoteResponse, error)
+	GetTransactions(filter entity.TransactionFilter) ([]entity.Transaction, int64, error)
+	GetTransactionByID(id int) (*entity.CheckoutResponse, error)
+	GetTransactionByClientID(clientID string) (*entity.Transaction, error)
+	MarkReviewed(id int) error
+	VoidTransaction(id int, request entity.VoidRequest) error
+	GetCashierName(userID int) (string, error)
+	GetCustomerName(customerID int) (string, error)
+	GetItemsByBarcode(codes []string) (map[string]entity.ScannedItem, error)
+	ReserveIdempotencyKey(key entity.IdempotencyKey, ttl time.Duration) (*entity.IdempotencyKey, error)
+	CompleteIdempotencyKey(key entity.IdempotencyKey) error
+	ReleaseIdempotencyKey(key entity.IdempotencyKey) error
 }
 
 type TransactionsRepository struct {
@@ -24,261 +47,1176 @@ func NewTransactionsRepository(db *database.DB) ITransactionsRepository {
 	}
 }
 
-func (t *TransactionsRepository) Checkout(requests []entity.CheckoutRequest) (*entity.CheckoutResponse, error) {
+// Checkout prices and records a sale in one database transaction. The customer and product rows stay locked until
+// it commits, so concurrent sales can neither oversell stock, redeem the same points twice nor overrun a credit
+// limit; only sales uploaded from offline terminals (AllowNegative) may go below zero stock, and they are flagged
+// for review. The outbox events and the audit hash chain entry are written in the same transaction.
+func (t *TransactionsRepository) Checkout(checkout entity.Checkout) (*entity.CheckoutResponse, error) {
 	var (
-		totalAmount      int
-		subTotal         int
+		transaction      entity.Transaction
 		checkoutProducts []entity.CheckoutProduct
 		detailProducts   []entity.CheckoutProductDetail
-		updateProducts   []entity.UpdatedProduct
+		stockLevels      []entity.StockLevel
+		shiftID          int
+		credit           int
+		shortage         bool
 		err              error
 	)
 
-	totalAmount = 0
-	subTotal = 0
+	err = t.db.WithTx(func(tx *database.Tx) error {
+		shiftID, err = t.getOpenShiftID(tx, checkout.UserID, true)
+		if err != nil {
+			return err
+		}
 
-	detailProducts, err = t.getDetailProductByID(requests)
-	if err != nil {
-		return nil, err
-	}
+		if shiftID == 0 && checkout.ShiftRequired {
+			return errors.New(constants.ErrShiftNotOpen)
+		}
 
-	if detailProducts == nil || len(detailProducts) == 0 {
-		return nil, errors.New(constants.ErrProductNotFound)
-	}
+		if checkout.CustomerID > 0 {
+			if err = t.checkPointsBalance(tx, checkout.CustomerID, checkout.RedeemPoints, true); err != nil {
+				return err
+			}
+		}
 
-	for _, product := range detailProducts {
-		if product.ID == 0 {
-			return nil, errors.New(constants.ErrProductNotFound)
+		detailProducts, err = t.getDetailProductByID(tx, checkout.Checkouts, true)
+		if err != nil {
+			return err
 		}
 
-		if product.Stock < product.Quantity {
-			return nil, errors.New(constants.ErrStockNotEnough)
+		shortage, err = validateStock(checkout.Checkouts, detailProducts, checkout.AllowNegative)
+		if err != nil {
+			return err
 		}
 
-		if product.Stock == 0 {
-			return nil, errors.New(constants.ErrStockEmpty)
+		transaction, checkoutProducts, err = priceCheckout(checkout, detailProducts)
+		if err != nil {
+			return err
 		}
 
-		subTotal = product.Price * product.Quantity
-		totalAmount += subTotal
+		transaction.PaidAmount, transaction.Change, credit, err = settlePayments(transaction.TotalAmount, checkout.Payments)
+		if err != nil {
+			return err
+		}
+
+		if credit > 0 {
+			if err = t.checkCreditLimit(tx, checkout.CustomerID, credit, true); err != nil {
+				return err
+			}
+		}
+
+		transaction.ShiftID = shiftID
+		transaction.ClientID = checkout.ClientID
+		transaction.NeedsReview = shortage
 
-		checkoutProduct := entity.CheckoutProduct{
-			ProductID:    product.ID,
-			Name:         product.Name,
-			Quantity:     product.Quantity,
-			Subtotal:     subTotal,
-			CategoryID:   product.CategoryID,
-			CategoryName: product.CategoryName,
+		transaction.InvoiceNumber, err = t.nextInvoiceNumber(tx, checkout.InvoicePrefix)
+		if err != nil {
+			return err
+		}
+
+		transaction.ID, err = t.createTransaction(tx, transaction, saleTime(checkout), checkoutProducts, checkout.Payments)
+		if err != nil {
+			return err
 		}
 
-		updateProduct := entity.UpdatedProduct{
-			ID:    product.ID,
-			Stock: product.Stock - product.Quantity,
+		if err = t.recordPoints(tx, transaction); err != nil {
+			return err
 		}
 
-		updateProducts = append(updateProducts, updateProduct)
-		checkoutProducts = append(checkoutProducts, checkoutProduct)
-	}
+		if err = t.chargeCredit(tx, transaction, credit); err != nil {
+			return err
+		}
+
+		if err = completeIdempotencyKey(tx, checkout, transaction.ID); err != nil {
+			return err
+		}
+
+		stockLevels, err = t.updateProductsStock(tx, checkoutProducts, checkout.AllowNegative)
+		if err != nil {
+			return err
+		}
+
+		event := entity.TransactionEvent{Transaction: transaction, Lines: checkoutProducts, Payments: checkout.Payments}
+		if err = outbox.Write(tx, constants.EventTransactionCreated, event); err != nil {
+			return err
+		}
+
+		if err = writeStockLow(tx, stockLevels, checkout.LowStockThreshold); err != nil {
+			return err
+		}
+
+		return auditRepository.Append(tx, constants.ChainRecordSale, transaction.ID, 0)
+	})
 
-	transactionID, checkoutProducts, err := t.createTransaction(totalAmount, checkoutProducts, updateProducts)
 	if err != nil {
 		return nil, err
 	}
 
-	response := entity.CheckoutResponse{
-		Transaction: entity.Transaction{
-			ID:          transactionID,
-			TotalAmount: totalAmount,
-		},
-		CheckoutProducts: checkoutProducts,
-	}
-
-	return &response, nil
+	return t.getCheckoutResponse(transaction)
 }
 
-func (t *TransactionsRepository) getDetailProductByID(requests []entity.CheckoutRequest) ([]entity.CheckoutProductDetail, error) {
+// Quote prices a sale exactly like Checkout without writing or locking anything. Every reason the same checkout
+// would be rejected right now (no open shift, not enough points or stock, a payment that does not settle the
+// total, a sale over the credit limit) is returned as a warning instead of an error, so a customer display can
+// still show the totals.
+func (t *TransactionsRepository) Quote(checkout entity.Checkout) (*entity.QuoteResponse, error) {
 	var (
-		product  entity.CheckoutProductDetail
-		products []entity.CheckoutProductDetail
+		quote    entity.QuoteResponse
+		shiftID  int
+		shortage bool
 		err      error
-		query    string
 	)
 
-	products = make([]entity.CheckoutProductDetail, 0)
+	quote.Warnings = make([]string, 0)
+
+	shiftID, err = t.getOpenShiftID(t.db, checkout.UserID, false)
+	if err != nil {
+		return nil, err
+	}
 
-	query = "SELECT products.id, products.name, products.price, products.stock, categories.id as category_id, categories.name as category_name FROM products JOIN categories ON products.category_id = categories.id WHERE products.id = $1"
+	if shiftID == 0 && checkout.ShiftRequired {
+		quote.Warnings = append(quote.Warnings, constants.ErrShiftNotOpen)
+	}
 
-	for _, request := range requests {
-		err = t.db.WithStmt(query, func(stmt *database.Stmt) error {
-			scanFn := func(rows *database.Rows) error {
-				return rows.Scan(&product.ID, &product.Name, &product.Price, &product.Stock, &product.CategoryID, &product.CategoryName)
-			}
+	if checkout.CustomerID > 0 {
+		err = t.checkPointsBalance(t.db, checkout.CustomerID, checkout.RedeemPoints, false)
+		if err != nil && err.Error() != constants.ErrPointsNotEnough {
+			return nil, err
+		}
 
-			err = stmt.Query(scanFn, request.ProductID)
+		if err != nil {
+			quote.Warnings = append(quote.Warnings, err.Error())
+		}
+	}
 
-			if err != nil {
-				return err
-			}
+	detailProducts, err := t.getDetailProductByID(t.db, checkout.Checkouts, false)
+	if err != nil {
+		return nil, err
+	}
 
-			if errors.Is(sql.ErrNoRows, err) {
-				return errors.New(constants.ErrProductNotFound)
-			}
+	// With negative stock allowed only missing products are errors; the shortage is reported as a warning below.
+	shortage, err = validateStock(checkout.Checkouts, detailProducts, true)
+	if err != nil {
+		return nil, err
+	}
 
-			return nil
-		})
+	if shortage {
+		_, err = validateStock(checkout.Checkouts, detailProducts, false)
+		quote.Warnings = append(quote.Warnings, err.Error())
+	}
 
+	transaction, checkoutProducts, err := priceCheckout(checkout, detailProducts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Payments are optional in a quote; a display usually asks for the total before the customer pays.
+	if len(checkout.Payments) > 0 {
+		var credit int
+		transaction.PaidAmount, transaction.Change, credit, err = settlePayments(transaction.TotalAmount, checkout.Payments)
 		if err != nil {
-			return nil, err
+			quote.Warnings = append(quote.Warnings, err.Error())
 		}
 
-		product.Quantity = request.Quantity
-		products = append(products, product)
+		if credit > 0 {
+			err = t.checkCreditLimit(t.db, checkout.CustomerID, credit, false)
+			if err != nil && err.Error() != constants.ErrCreditLimitExceeded {
+				return nil, err
+			}
+
+			if err != nil {
+				quote.Warnings = append(quote.Warnings, err.Error())
+			}
+		}
 	}
 
-	return products, nil
+	quote.SubtotalAmount = transaction.SubtotalAmount
+	quote.DiscountAmount = transaction.DiscountAmount
+	quote.PointsDiscount = transaction.PointsDiscount
+	quote.NetAmount = transaction.NetAmount
+	quote.TaxAmount = transaction.TaxAmount
+	quote.ServiceCharge = transaction.ServiceCharge
+	quote.TotalAmount = transaction.TotalAmount
+	quote.PaidAmount = transaction.PaidAmount
+	quote.Change = transaction.Change
+	quote.PointsEarned = transaction.PointsEarned
+	quote.Lines = checkoutProducts
+
+	return &quote, nil
 }
 
-func (t *TransactionsRepository) createTransaction(totalAmount int, checkoutProducts []entity.CheckoutProduct, updateProducts []entity.UpdatedProduct) (int, []entity.CheckoutProduct, error) {
+// priceCheckout calculates discounts, tax and service charge of a sale.
+func priceCheckout(checkout entity.Checkout, detailProducts []entity.CheckoutProductDetail) (entity.Transaction, []entity.CheckoutProduct, error) {
 	var (
-		query          string
-		err            error
-		lastInsertId   int64
-		checkoutWithID []entity.CheckoutProduct
+		subtotalAmount   int
+		totalNet         int
+		totalTax         int
+		totalAmount      int
+		checkoutProducts []entity.CheckoutProduct
 	)
 
-	checkoutWithID = make([]entity.CheckoutProduct, 0)
-
-	query = "INSERT INTO transactions (total_amount, created_at, updated_at) VALUES ($1, $2, $3) RETURNING id;"
+	if len(detailProducts) == 0 {
+		return entity.Transaction{}, nil, errors.New(constants.ErrProductNotFound)
+	}
 
-	err = t.db.WithTx(func(tx *database.Tx) error {
-		rows := t.db.QueryRow(query, totalAmount, "now()", "now()")
-		if rows.Error() != "" {
-			return errors.New(rows.Error())
-		}
+	lines := make([]engine.Line, 0, len(detailProducts))
 
-		if err := rows.Scan(&lastInsertId); err != nil {
-			return err
-		}
+	for _, product := range detailProducts {
+		lines = append(lines, engine.Line{
+			ProductID:  product.ID,
+			CategoryID: product.CategoryID,
+			Quantity:   product.Quantity,
+			Factor:     product.UnitFactor,
+			UnitPrice:  product.Price,
+		})
+	}
 
-		err = t.createTransactionDetail(int(lastInsertId), checkoutProducts)
+	// Offline sales are priced with the promotions that were running when they were rung up.
+	now := checkout.SoldAt
+	if now.IsZero() {
+		current, err := datetime.ParseTime(time.Now().Format(time.RFC3339))
 		if err != nil {
-			return err
+			return entity.Transaction{}, nil, err
 		}
 
-		err = t.updateProductsStock(updateProducts)
-		if err != nil {
-			return err
+		now = current
+	}
+
+	discounts := engine.Apply(checkout.Promotions, lines, now)
+
+	pointsDiscount := checkout.RedeemPoints * checkout.Loyalty.PointValue
+	lineDiscounts, err := redeemPoints(lines, discounts, pointsDiscount)
+	if err != nil {
+		return entity.Transaction{}, nil, err
+	}
+
+	for i, product := range detailProducts {
+		taxRate := taxRateOf(product, checkout.Tax)
+		lineAmount := product.Price*product.Quantity - lineDiscounts[i]
+		netAmount, taxAmount, subTotal := applyTax(lineAmount, taxRate, checkout.Tax.Inclusive)
+
+		subtotalAmount += product.Price * product.Quantity
+		totalNet += netAmount
+		totalTax += taxAmount
+		totalAmount += subTotal
+
+		checkoutProducts = append(checkoutProducts, entity.CheckoutProduct{
+			ProductID:      product.ID,
+			VariantID:      product.VariantID,
+			Name:           product.Name,
+			SKU:            product.SKU,
+			Unit:           product.Unit,
+			UnitFactor:     product.UnitFactor,
+			Quantity:       product.Quantity,
+			UnitPrice:      product.Price,
+			Subtotal:       subTotal,
+			DiscountAmount: lineDiscounts[i],
+			NetAmount:      netAmount,
+			TaxRate:        taxRate,
+			TaxAmount:      taxAmount,
+			Promotions:     toAppliedPromotions(discounts.Applied[i]),
+			CategoryID:     product.CategoryID,
+			CategoryName:   product.CategoryName,
+		})
+	}
+
+	// The service charge is calculated on the net amount and, like any other service, is subject to PPN at the store rate.
+	serviceCharge := roundAmount(float64(totalNet) * checkout.Tax.ServiceChargeRate / 100)
+	serviceChargeTax := roundAmount(float64(serviceCharge) * checkout.Tax.Rate / 100)
+	totalTax += serviceChargeTax
+	totalAmount += serviceCharge + serviceChargeTax
+
+	transaction := entity.Transaction{
+		UserID:         checkout.UserID,
+		TerminalID:     checkout.TerminalID,
+		CustomerID:     checkout.CustomerID,
+		PointsRedeemed: checkout.RedeemPoints,
+		PointsDiscount: pointsDiscount,
+		SubtotalAmount: subtotalAmount,
+		DiscountAmount: discounts.TotalDiscount + pointsDiscount,
+		NetAmount:      totalNet,
+		TaxAmount:      totalTax,
+		ServiceCharge:  serviceCharge,
+		TotalAmount:    totalAmount,
+		Status:         constants.TransactionStatusCompleted,
+	}
+
+	if checkout.CustomerID > 0 && checkout.Loyalty.SpendPerPoint > 0 {
+		transaction.PointsEarned = totalAmount / checkout.Loyalty.SpendPerPoint
+	}
+
+	return transaction, checkoutProducts, nil
+}
+
+// redeemPoints spreads the value of the redeemed points over the lines like a basket discount, so the points
+// lower the taxable amount of every line. It returns the discount of every line including its promotions.
+func redeemPoints(lines []engine.Line, discounts engine.Result, amount int) ([]int, error) {
+	lineDiscounts := append([]int(nil), discounts.LineDiscounts...)
+	if amount <= 0 {
+		return lineDiscounts, nil
+	}
+
+	basket := 0
+	for i, line := range lines {
+		basket += line.UnitPrice*line.Quantity - lineDiscounts[i]
+	}
+
+	if amount > basket {
+		return nil, errors.New(constants.ErrRedeemExceedsTotal)
+	}
+
+	redemption := engine.Result{
+		Applied:       make([][]engine.Applied, len(lines)),
+		LineDiscounts: lineDiscounts,
+	}
+	engine.Prorate(lines, &redemption, engine.Applied{Amount: amount}, basket)
+
+	return redemption.LineDiscounts, nil
+}
+
+// taxRateOf returns the PPN rate of a product: exempt categories pay none, categories with their own rate override the store rate.
+func taxRateOf(product entity.CheckoutProductDetail, tax entity.TaxConfig) float64 {
+	if product.TaxExempt {
+		return 0
+	}
+
+	if product.HasTaxRate {
+		return product.TaxRate
+	}
+
+	return tax.Rate
+}
+
+// applyTax splits a line amount into its net, tax and gross parts.
+func applyTax(lineAmount int, rate float64, inclusive bool) (int, int, int) {
+	if rate <= 0 {
+		return lineAmount, 0, lineAmount
+	}
+
+	if inclusive {
+		net := roundAmount(float64(lineAmount) * 100 / (100 + rate))
+		return net, lineAmount - net, lineAmount
+	}
+
+	tax := roundAmount(float64(lineAmount) * rate / 100)
+	return lineAmount, tax, lineAmount + tax
+}
+
+func roundAmount(amount float64) int {
+	return int(math.Round(amount))
+}
+
+func toAppliedPromotions(applied []engine.Applied) []entity.AppliedPromotion {
+	promotions := make([]entity.AppliedPromotion, 0, len(applied))
+	for _, promotion := range applied {
+		promotions = append(promotions, entity.AppliedPromotion{
+			PromotionID:    promotion.PromotionID,
+			PromotionName:  promotion.PromotionName,
+			DiscountAmount: promotion.Amount,
+		})
+	}
+
+	return promotions
+}
+
+// settlePayments validates the tenders against the amount due and returns the amount paid, the change and the
+// part paid on credit. Only cash can be overpaid, so the change can never be larger than the cash handed over,
+// and a sale paid partly on credit is never overpaid at all, so no change is given out of a loan.
+func settlePayments(totalAmount int, payments []entity.PaymentRequest) (int, int, int, error) {
+	var (
+		paidAmount   int
+		cashAmount   int
+		creditAmount int
+	)
+
+	if len(payments) == 0 {
+		return 0, 0, 0, errors.New(constants.ErrPaymentRequired)
+	}
+
+	for _, payment := range payments {
+		switch payment.Method {
+		case constants.PaymentMethodCash:
+			cashAmount += payment.Amount
+		case constants.PaymentMethodCredit:
+			creditAmount += payment.Amount
+		case constants.PaymentMethodDebit, constants.PaymentMethodQRIS, constants.PaymentMethodEWallet, constants.PaymentMethodVoucher:
+		default:
+			return 0, 0, 0, fmt.Errorf("%s: %s", constants.ErrInvalidPaymentMethod, payment.Method)
 		}
 
-		checkoutWithID = t.getTransactionsDetailByTransactionID(int(lastInsertId))
-		if checkoutWithID == nil {
-			return errors.New(constants.ErrProductNotFound)
+		if payment.Amount <= 0 {
+			return 0, 0, 0, errors.New(constants.ErrInvalidPaymentAmount)
 		}
 
-		return nil
-	})
+		paidAmount += payment.Amount
+	}
 
-	if err != nil {
-		return 0, nil, err
+	if paidAmount < totalAmount {
+		return 0, 0, 0, errors.New(constants.ErrUnderpayment)
+	}
+
+	change := paidAmount - totalAmount
+	if change > cashAmount {
+		return 0, 0, 0, errors.New(constants.ErrNonCashOverpayment)
+	}
+
+	if change > 0 && creditAmount > 0 {
+		return 0, 0, 0, errors.New(constants.ErrCreditOverpayment)
 	}
 
-	return int(lastInsertId), checkoutWithID, nil
+	return paidAmount, change, creditAmount, nil
 }
 
-func (t *TransactionsRepository) createTransactionDetail(transactionId int, checkoutProducts []entity.CheckoutProduct) error {
+// getDetailProductByID loads, and with lock set locks, every requested product with a single query, and then every
+// requested variant. Rows are locked in ascending id order, products before variants, so two checkouts sharing
+// products always wait for each other instead of deadlocking. Requested products that do not exist are returned
+// with an ID of zero and variants that do not belong to their product with a VariantID of zero, so they can be
+// reported together with stock errors. A variant line is sold at the price and stock of the variant, and a line
+// in another unit than the base unit at the price of that unit, or the variant price times its factor; units the
+// product does not have get a factor of zero.
+func (t *TransactionsRepository) getDetailProductByID(q database.Querier, requests []entity.CheckoutRequest, lock bool) ([]entity.CheckoutProductDetail, error) {
 	var (
-		query string
-		err   error
-		args  []interface{}
+		products   []entity.CheckoutProductDetail
+		locked     map[int]entity.CheckoutProductDetail
+		variants   map[int]entity.ProductVariant
+		ids        []int64
+		variantIDs []int64
+		err        error
+		query      string
 	)
-	
-	numFields := 6
-	query = "INSERT INTO transaction_details (transaction_id, product_id, quantity, subtotal, created_at, updated_at) VALUES "
 
-	for i, product := range checkoutProducts {
-		p := i * numFields
-		query = fmt.Sprintf("%s ($%d, $%d, $%d, $%d, $%d, $%d)", query, p+1, p+2, p+3, p+4, p+5, p+6)
-		if i < len(checkoutProducts)-1 {
-			query += ","
+	products = make([]entity.CheckoutProductDetail, 0, len(requests))
+	locked = make(map[int]entity.CheckoutProductDetail)
+
+	for _, request := range requests {
+		ids = append(ids, int64(request.ProductID))
+		if request.VariantID != 0 {
+			variantIDs = append(variantIDs, int64(request.VariantID))
 		}
-		args = append(args, transactionId, product.ProductID, product.Quantity, product.Subtotal, "now()", "now()")
 	}
 
-	err = t.db.WithTx(func(tx *database.Tx) error {
-		_, err = t.db.Exec(query, args...)
-		if err != nil {
-			return err
+	query = "SELECT products.id, products.name, COALESCE(products.sku, ''), products.price, products.stock, EXISTS (SELECT 1 FROM product_variants WHERE product_variants.product_id = products.id), products.base_unit, categories.id as category_id, categories.name as category_name, COALESCE(categories.tax_rate, 0), categories.tax_rate IS NOT NULL, categories.tax_exempt FROM products JOIN categories ON products.category_id = categories.id WHERE products.id = ANY($1) ORDER BY products.id"
+	if lock {
+		query += " FOR UPDATE OF products"
+	}
+
+	err = q.WithStmt(query, func(stmt *database.Stmt) error {
+		scanFn := func(rows *database.Rows) error {
+			var product entity.CheckoutProductDetail
+			if err := rows.Scan(&product.ID, &product.Name, &product.SKU, &product.Price, &product.Stock, &product.HasVariants, &product.BaseUnit, &product.CategoryID, &product.CategoryName, &product.TaxRate, &product.HasTaxRate, &product.TaxExempt); err != nil {
+				return err
+			}
+
+			locked[product.ID] = product
+			return nil
 		}
 
-		return nil
+		return stmt.Query(scanFn, pq.Array(ids))
 	})
 
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
-}
+	variants, err = t.getVariantsByID(q, variantIDs, lock)
+	if err != nil {
+		return nil, err
+	}
 
-func (t *TransactionsRepository) updateProductsStock(updateProducts []entity.UpdatedProduct) error {
-	var (
-		err error
-	)
+	units, err := t.getUnitsByProductID(q, ids)
+	if err != nil {
+		return nil, err
+	}
 
-	if len(updateProducts) > 0 {
-		for _, product := range updateProducts {
-			err = t.updateStockByProductID(&product)
-			if err != nil {
-				return err
+	for _, request := range requests {
+		product := locked[request.ProductID]
+		product.Quantity = request.Quantity
+
+		if variant, ok := variants[request.VariantID]; ok && product.ID != 0 && variant.ProductID == product.ID {
+			product.VariantID = variant.ID
+			product.Name = fmt.Sprintf("%s (%s)", product.Name, variant.Name)
+			product.Price = variant.Price
+			product.Stock = variant.Stock
+
+			if variant.SKU != "" {
+				product.SKU = variant.SKU
+			}
+		}
+
+		product.Unit, product.UnitFactor = product.BaseUnit, 1
+		if name := strings.ToLower(strings.TrimSpace(request.Unit)); name != "" && name != product.BaseUnit {
+			product.Unit, product.UnitFactor = name, 0
+
+			if unit, ok := units[product.ID][name]; ok {
+				product.UnitFactor = unit.Factor
+
+				// Unit prices are set for the product, so a variant is sold in another unit at its own price per
+				// base unit times the factor.
+				if product.VariantID != 0 {
+					product.Price *= unit.Factor
+				} else {
+					product.Price = unit.Price
+				}
 			}
 		}
-	} else {
-		return errors.New(constants.ErrProductNotFound)
+
+		products = append(products, product)
 	}
 
-	return nil
+	return products, nil
 }
 
-func (t *TransactionsRepository) updateStockByProductID(product *entity.UpdatedProduct) error {
+// getUnitsByProductID loads the units of the requested products by product and unit name.
+func (t *TransactionsRepository) getUnitsByProductID(q database.Querier, ids []int64) (map[int]map[string]entity.ProductUnit, error) {
 	var (
+		units map[int]map[string]entity.ProductUnit
 		query string
 		err   error
 	)
 
-	query = "UPDATE products SET stock = $1, updated_at = $2 WHERE id = $3"
+	units = make(map[int]map[string]entity.ProductUnit)
 
-	err = t.db.WithTx(func(tx *database.Tx) error {
-		return tx.WithStmt(query, func(stmt *database.Stmt) error {
-			_, err := stmt.Exec(product.Stock, "now()", product.ID)
-			return err
-		})
+	query = "SELECT product_id, name, factor, price FROM product_units WHERE product_id = ANY($1)"
+	err = q.WithStmt(query, func(stmt *database.Stmt) error {
+		scanFn := func(rows *database.Rows) error {
+			var unit entity.ProductUnit
+			if err := rows.Scan(&unit.ProductID, &unit.Name, &unit.Factor, &unit.Price); err != nil {
+				return err
+			}
+
+			if units[unit.ProductID] == nil {
+				units[unit.ProductID] = make(map[string]entity.ProductUnit)
+			}
+
+			units[unit.ProductID][unit.Name] = unit
+			return nil
+		}
+
+		return stmt.Query(scanFn, pq.Array(ids))
 	})
 
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	return units, nil
 }
 
-func (t *TransactionsRepository) getTransactionsDetailByTransactionID(transactionId int) []entity.CheckoutProduct {
+// getVariantsByID loads, and with lock set locks, the requested variants in ascending id order.
+func (t *TransactionsRepository) getVariantsByID(q database.Querier, ids []int64, lock bool) (map[int]entity.ProductVariant, error) {
 	var (
-		checkoutProducts []entity.CheckoutProduct
-		checkoutProduct  entity.CheckoutProduct
-		query            string
-		err              error
+		variants map[int]entity.ProductVariant
+		query    string
+		err      error
 	)
 
-	checkoutProducts = make([]entity.CheckoutProduct, 0)
+	variants = make(map[int]entity.ProductVariant)
+	if len(ids) == 0 {
+		return variants, nil
+	}
 
-	query = "SELECT products.id, products.name, categories.id as category_id, categories.name as category_name, transaction_details.id, transaction_details.transaction_id, transaction_details.quantity, transaction_details.subtotal FROM transaction_details JOIN products ON transaction_details.product_id = products.id JOIN categories ON products.category_id = categories.id WHERE transaction_details.transaction_id = $1"
+	query = "SELECT id, product_id, name, COALESCE(sku, ''), price, stock FROM product_variants WHERE id = ANY($1) ORDER BY id"
+	if lock {
+		query += " FOR UPDATE"
+	}
 
-	err = t.db.WithStmt(query, func(stmt *database.Stmt) error {
+	err = q.WithStmt(query, func(stmt *database.Stmt) error {
 		scanFn := func(rows *database.Rows) error {
-			if err := rows.Scan(&checkoutProduct.ProductID, &checkoutProduct.Name, &checkoutProduct.CategoryID, &checkoutProduct.CategoryName, &checkoutProduct.TransactionDetailID, &checkoutProduct.TransactionID, &checkoutProduct.Quantity, &checkoutProduct.Subtotal); err != nil {
+			var variant entity.ProductVariant
+			if err := rows.Scan(&variant.ID, &variant.ProductID, &variant.Name, &variant.SKU, &variant.Price, &variant.Stock); err != nil {
 				return err
 			}
-			checkoutProducts = append(checkoutProducts, checkoutProduct)
+
+			variants[variant.ID] = variant
+			return nil
+		}
+
+		return stmt.Query(scanFn, pq.Array(ids))
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return variants, nil
+}
+
+// validateStock rejects lines without a positive quantity, then checks the whole basket at once and reports every
+// missing product or variant, every product that is sold per variant but was requested without one, every unit a
+// product does not have, and every product without enough stock in a single error. A product or variant scanned on
+// several lines, in any unit, must have stock for all of them together, counted in its base unit. When
+// allowNegative is set a shortage is not an error; it is reported as true so the sale can be flagged.
+func validateStock(requests []entity.CheckoutRequest, products []entity.CheckoutProductDetail, allowNegative bool) (bool, error) {
+	var (
+		missing      []string
+		noVariant    []string
+		noUnit       []string
+		insufficient []string
+		problems     []string
+	)
+
+	// A negative line would pass the stock check, put stock back and lower the total.
+	for _, request := range requests {
+		if request.Quantity <= 0 {
+			return false, errors.New(constants.ErrInvalidQuantity)
+		}
+	}
+
+	type stockKey struct {
+		productID int
+		variantID int
+	}
+
+	requested := make(map[stockKey]int)
+	for i, request := range requests {
+		requested[stockKey{request.ProductID, request.VariantID}] += request.Quantity * products[i].UnitFactor
+	}
+
+	reported := make(map[stockKey]bool)
+	reportedUnits := make(map[string]bool)
+	for i, product := range products {
+		key := stockKey{requests[i].ProductID, requests[i].VariantID}
+
+		if product.ID != 0 && product.UnitFactor == 0 {
+			unit := fmt.Sprintf("%s (%s)", product.Name, product.Unit)
+			if !reportedUnits[unit] {
+				reportedUnits[unit] = true
+				noUnit = append(noUnit, unit)
+			}
+			continue
+		}
+
+		if reported[key] {
+			continue
+		}
+
+		reported[key] = true
+
+		if product.ID == 0 {
+			missing = append(missing, fmt.Sprintf("id %d", key.productID))
+			continue
+		}
+
+		if key.variantID != 0 && product.VariantID == 0 {
+			missing = append(missing, fmt.Sprintf("variant id %d", key.variantID))
+			continue
+		}
+
+		if key.variantID == 0 && product.HasVariants {
+			noVariant = append(noVariant, product.Name)
+			continue
+		}
+
+		if product.Stock < requested[key] {
+			insufficient = append(insufficient, fmt.Sprintf("%s (requested %d %s, available %d %s)", product.Name, requested[key], product.BaseUnit, product.Stock, product.BaseUnit))
+		}
+	}
+
+	if len(missing) > 0 {
+		problems = append(problems, fmt.Sprintf("%s: %s", constants.ErrProductNotFound, strings.Join(missing, ", ")))
+	}
+
+	if len(noVariant) > 0 {
+		problems = append(problems, fmt.Sprintf("%s: %s", constants.ErrVariantRequired, strings.Join(noVariant, ", ")))
+	}
+
+	if len(noUnit) > 0 {
+		problems = append(problems, fmt.Sprintf("%s: %s", constants.ErrUnitNotFound, strings.Join(noUnit, ", ")))
+	}
+
+	if len(insufficient) > 0 && !allowNegative {
+		problems = append(problems, fmt.Sprintf("%s: %s", constants.ErrStockNotEnough, strings.Join(insufficient, ", ")))
+	}
+
+	if len(problems) > 0 {
+		return false, errors.New(strings.Join(problems, "; "))
+	}
+
+	return len(insufficient) > 0, nil
+}
+
+// saleTime is the created_at of a sale: the time an offline sale was rung up, otherwise now.
+func saleTime(checkout entity.Checkout) string {
+	if checkout.SoldAt.IsZero() {
+		return "now()"
+	}
+
+	return checkout.SoldAt.UTC().Format("2006-01-02 15:04:05")
+}
+
+// nextInvoiceNumber hands out the next invoice number of the day, e.g. INV/20261017/0001. The sequence row stays
+// locked until the checkout commits, so concurrent checkouts wait for each other and a rolled back checkout
+// gives its number back, keeping the numbers gap-free.
+func (t *TransactionsRepository) nextInvoiceNumber(tx *database.Tx, prefix string) (string, error) {
+	var (
+		number int
+		query  string
+	)
+
+	now, err := datetime.ParseTime(time.Now().Format(time.RFC3339))
+	if err != nil {
+		return "", err
+	}
+
+	query = "INSERT INTO invoice_sequences (prefix, sequence_date, last_number) VALUES ($1, $2, 1) ON CONFLICT (prefix, sequence_date) DO UPDATE SET last_number = invoice_sequences.last_number + 1 RETURNING last_number"
+	err = tx.WithStmt(query, func(stmt *database.Stmt) error {
+		return stmt.QueryRow(prefix, now.Format("2006-01-02")).Scan(&number)
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s/%s/%04d", prefix, now.Format("20060102"), number), nil
+}
+
+// getOpenShiftID returns the open shift of the cashier, or zero when none is open. With lock set the shift is share
+// locked until the checkout commits, so it cannot be closed while the sale is being recorded.
+func (t *TransactionsRepository) getOpenShiftID(q database.Querier, userID int, lock bool) (int, error) {
+	var (
+		shiftID int
+		query   string
+	)
+
+	query = "SELECT id FROM shifts WHERE user_id = $1 AND status = $2"
+	if lock {
+		query += " FOR SHARE"
+	}
+
+	err := q.WithStmt(query, func(stmt *database.Stmt) error {
+		scanFn := func(rows *database.Rows) error {
+			return rows.Scan(&shiftID)
+		}
+
+		return stmt.Query(scanFn, userID, constants.ShiftStatusOpen)
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return shiftID, nil
+}
+
+func (t *TransactionsRepository) createTransaction(tx *database.Tx, transaction entity.Transaction, createdAt string, checkoutProducts []entity.CheckoutProduct, payments []entity.PaymentRequest) (int, error) {
+	var (
+		query         string
+		err           error
+		transactionID int
+	)
+
+	query = "INSERT INTO transactions (invoice_number, user_id, terminal_id, shift_id, client_id, needs_review, customer_id, points_earned, points_redeemed, points_discount, subtotal_amount, discount_amount, net_amount, tax_amount, service_charge_amount, total_amount, paid_amount, change_amount, created_at, updated_at) VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, 0), NULLIF($5, '')::uuid, $6, NULLIF($7, 0), $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20) RETURNING id;"
+
+	err = tx.WithStmt(query, func(stmt *database.Stmt) error {
+		return stmt.QueryRow(transaction.InvoiceNumber, transaction.UserID, transaction.TerminalID, transaction.ShiftID, transaction.ClientID, transaction.NeedsReview, transaction.CustomerID, transaction.PointsEarned, transaction.PointsRedeemed, transaction.PointsDiscount, transaction.SubtotalAmount, transaction.DiscountAmount, transaction.NetAmount, transaction.TaxAmount, transaction.ServiceCharge, transaction.TotalAmount, transaction.PaidAmount, transaction.Change, createdAt, "now()").Scan(&transactionID)
+	})
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == database.UniqueViolation && transaction.ClientID != "" {
+		return 0, errors.New(constants.ErrDuplicateClientID)
+	}
+
+	if err != nil {
+		return 0, err
+	}
+
+	detailIDs, err := t.createTransactionDetail(tx, transactionID, checkoutProducts)
+	if err != nil {
+		return 0, err
+	}
+
+	err = t.createTransactionPromotions(tx, transactionID, detailIDs, checkoutProducts)
+	if err != nil {
+		return 0, err
+	}
+
+	err = t.createTransactionPayments(tx, transactionID, payments)
+	if err != nil {
+		return 0, err
+	}
+
+	return transactionID, nil
+}
+
+// getCheckoutResponse reads back the lines and payments of a committed sale.
+func (t *TransactionsRepository) getCheckoutResponse(transaction entity.Transaction) (*entity.CheckoutResponse, error) {
+	checkoutWithID := t.getTransactionsDetailByTransactionID(transaction.ID)
+	if checkoutWithID == nil {
+		return nil, errors.New(constants.ErrProductNotFound)
+	}
+
+	paymentsWithID, err := t.getPaymentsByTransactionID(transaction.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := entity.CheckoutResponse{
+		Transaction:      transaction,
+		CheckoutProducts: checkoutWithID,
+		Payments:         paymentsWithID,
+	}
+
+	return &response, nil
+}
+
+func (t *TransactionsRepository) createTransactionPayments(tx *database.Tx, transactionId int, payments []entity.PaymentRequest) error {
+	var (
+		query string
+		args  []interface{}
+	)
+
+	numFields := 6
+	query = "INSERT INTO transaction_payments (transaction_id, method, amount, reference, created_at, updated_at) VALUES "
+
+	for i, payment := range payments {
+		p := i * numFields
+		query = fmt.Sprintf("%s ($%d, $%d, $%d, $%d, $%d, $%d)", query, p+1, p+2, p+3, p+4, p+5, p+6)
+		if i < len(payments)-1 {
+			query += ","
+		}
+		args = append(args, transactionId, payment.Method, payment.Amount, payment.Reference, "now()", "now()")
+	}
+
+	return tx.WithStmt(query, func(stmt *database.Stmt) error {
+		_, err := stmt.Exec(args...)
+		return err
+	})
+}
+
+func (t *TransactionsRepository) getPaymentsByTransactionID(transactionId int) ([]entity.Payment, error) {
+	var (
+		payments []entity.Payment
+		query    string
+		err      error
+	)
+
+	payments = make([]entity.Payment, 0)
+
+	query = "SELECT id, transaction_id, method, amount, COALESCE(reference, ''), created_at FROM transaction_payments WHERE transaction_id = $1 ORDER BY id"
+
+	err = t.db.WithStmt(query, func(stmt *database.Stmt) error {
+		scanFn := func(rows *database.Rows) error {
+			var payment entity.Payment
+			if err := rows.Scan(&payment.ID, &payment.TransactionID, &payment.Method, &payment.Amount, &payment.Reference, &payment.CreatedAt); err != nil {
+				return err
+			}
+
+			payments = append(payments, payment)
+			return nil
+		}
+
+		return stmt.Query(scanFn, transactionId)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return payments, nil
+}
+
+func (t *TransactionsRepository) createTransactionDetail(tx *database.Tx, transactionId int, checkoutProducts []entity.CheckoutProduct) ([]int, error) {
+	var (
+		query     string
+		err       error
+		args      []interface{}
+		detailIDs []int
+	)
+
+	numFields := 18
+	query = "INSERT INTO transaction_details (transaction_id, product_id, variant_id, product_name, product_sku, category_id, category_name, unit, unit_factor, quantity, unit_price, subtotal, discount_amount, net_amount, tax_rate, tax_amount, created_at, updated_at) VALUES "
+
+	for i, product := range checkoutProducts {
+		p := i * numFields
+		query = fmt.Sprintf("%s ($%d, $%d, NULLIF($%d, 0), $%d, NULLIF($%d, ''), $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)", query, p+1, p+2, p+3, p+4, p+5, p+6, p+7, p+8, p+9, p+10, p+11, p+12, p+13, p+14, p+15, p+16, p+17, p+18)
+		if i < len(checkoutProducts)-1 {
+			query += ","
+		}
+		args = append(args, transactionId, product.ProductID, product.VariantID, product.Name, product.SKU, product.CategoryID, product.CategoryName, product.Unit, product.UnitFactor, product.Quantity, product.UnitPrice, product.Subtotal, product.DiscountAmount, product.NetAmount, product.TaxRate, product.TaxAmount, "now()", "now()")
+	}
+
+	// Rows of a multi-row insert are returned in the order of the VALUES list, which keeps the ids aligned with checkoutProducts.
+	query += " RETURNING id"
+
+	err = tx.WithStmt(query, func(stmt *database.Stmt) error {
+		scanFn := func(rows *database.Rows) error {
+			var id int
+			if err := rows.Scan(&id); err != nil {
+				return err
+			}
+
+			detailIDs = append(detailIDs, id)
+			return nil
+		}
+
+		return stmt.Query(scanFn, args...)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return detailIDs, nil
+}
+
+func (t *TransactionsRepository) createTransactionPromotions(tx *database.Tx, transactionId int, detailIDs []int, checkoutProducts []entity.CheckoutProduct) error {
+	var (
+		query string
+		args  []interface{}
+	)
+
+	numFields := 6
+	query = "INSERT INTO transaction_promotions (transaction_id, transaction_detail_id, promotion_id, promotion_name, discount_amount, created_at) VALUES "
+
+	for i, product := range checkoutProducts {
+		for _, promotion := range product.Promotions {
+			p := len(args)
+			if p > 0 {
+				query += ","
+			}
+			query = fmt.Sprintf("%s ($%d, $%d, $%d, $%d, $%d, $%d)", query, p+1, p+2, p+3, p+4, p+5, p+6)
+			args = append(args, transactionId, detailIDs[i], promotion.PromotionID, promotion.PromotionName, promotion.DiscountAmount, "now()")
+		}
+	}
+
+	if len(args) == 0 {
+		return nil
+	}
+
+	if len(args)%numFields != 0 {
+		return errors.New("invalid transaction promotions")
+	}
+
+	return tx.WithStmt(query, func(stmt *database.Stmt) error {
+		_, err := stmt.Exec(args...)
+		return err
+	})
+}
+
+func (t *TransactionsRepository) getPromotionsByTransactionID(transactionId int) (map[int][]entity.AppliedPromotion, error) {
+	var (
+		promotions map[int][]entity.AppliedPromotion
+		query      string
+		err        error
+	)
+
+	promotions = make(map[int][]entity.AppliedPromotion)
+
+	query = "SELECT transaction_detail_id, promotion_id, promotion_name, discount_amount FROM transaction_promotions WHERE transaction_id = $1 ORDER BY id"
+
+	err = t.db.WithStmt(query, func(stmt *database.Stmt) error {
+		scanFn := func(rows *database.Rows) error {
+			var (
+				detailID  int
+				promotion entity.AppliedPromotion
+			)
+
+			if err := rows.Scan(&detailID, &promotion.PromotionID, &promotion.PromotionName, &promotion.DiscountAmount); err != nil {
+				return err
+			}
+
+			promotions[detailID] = append(promotions[detailID], promotion)
+			return nil
+		}
+
+		return stmt.Query(scanFn, transactionId)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return promotions, nil
+}
+
+// updateProductsStock decrements the stock of every product in the basket with one statement, after the stock of
+// the variants sold. The decrement is guarded by stock >= quantity so stock never goes below zero even if the rows
+// were not locked beforehand, unless allowNegative is set for sales that already happened offline. The stock
+// levels returned are those of the variants sold and of the products sold without a variant.
+func (t *TransactionsRepository) updateProductsStock(tx *database.Tx, checkoutProducts []entity.CheckoutProduct, allowNegative bool) ([]entity.StockLevel, error) {
+	var (
+		ids        []int64
+		quantities []int64
+		levels     []entity.StockLevel
+		query      string
+		err        error
+	)
+
+	if len(checkoutProducts) == 0 {
+		return nil, errors.New(constants.ErrProductNotFound)
+	}
+
+	levels, err = t.updateVariantsStock(tx, checkoutProducts, allowNegative)
+	if err != nil {
+		return nil, err
+	}
+
+	soldAsVariant := make(map[int]bool)
+	for _, product := range checkoutProducts {
+		if product.VariantID != 0 {
+			soldAsVariant[product.ProductID] = true
+		}
+	}
+
+	index := make(map[int]int)
+	for _, product := range checkoutProducts {
+		i, ok := index[product.ProductID]
+		if !ok {
+			index[product.ProductID] = len(ids)
+			ids = append(ids, int64(product.ProductID))
+			quantities = append(quantities, int64(baseQuantity(product)))
+			continue
+		}
+
+		quantities[i] += int64(baseQuantity(product))
+	}
+
+	query = "UPDATE products SET stock = products.stock - deductions.quantity, updated_at = now() FROM (SELECT UNNEST($1::int[]) AS id, UNNEST($2::int[]) AS quantity) AS deductions WHERE products.id = deductions.id"
+	if !allowNegative {
+		query += " AND products.stock >= deductions.quantity"
+	}
+	query += " RETURNING products.id, products.name, products.stock, deductions.quantity"
+
+	updated := 0
+	err = tx.WithStmt(query, func(stmt *database.Stmt) error {
+		scanFn := func(rows *database.Rows) error {
+			var level entity.StockLevel
+			if err := rows.Scan(&level.ProductID, &level.Name, &level.Stock, &level.Sold); err != nil {
+				return err
+			}
+
+			updated++
+			if !soldAsVariant[level.ProductID] {
+				levels = append(levels, level)
+			}
+			return nil
+		}
+
+		return stmt.Query(scanFn, pq.Array(ids), pq.Array(quantities))
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if updated != len(ids) {
+		return nil, errors.New(constants.ErrStockNotEnough)
+	}
+
+	return levels, nil
+}
+
+// updateVariantsStock decrements the stock of every variant in the basket, guarded like updateProductsStock.
+func (t *TransactionsRepository) updateVariantsStock(tx *database.Tx, checkoutProducts []entity.CheckoutProduct, allowNegative bool) ([]entity.StockLevel, error) {
+	var (
+		ids        []int64
+		quantities []int64
+		levels     []entity.StockLevel
+		query      string
+		err        error
+	)
+
+	index := make(map[int]int)
+	for _, product := range checkoutProducts {
+		if product.VariantID == 0 {
+			continue
+		}
+
+		i, ok := index[product.VariantID]
+		if !ok {
+			index[product.VariantID] = len(ids)
+			ids = append(ids, int64(product.VariantID))
+			quantities = append(quantities, int64(baseQuantity(product)))
+			continue
+		}
+
+		quantities[i] += int64(baseQuantity(product))
+	}
+
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	query = "UPDATE product_variants SET stock = product_variants.stock - deductions.quantity, updated_at = now() FROM (SELECT UNNEST($1::int[]) AS id, UNNEST($2::int[]) AS quantity) AS deductions, products WHERE product_variants.id = deductions.id AND products.id = product_variants.product_id"
+	if !allowNegative {
+		query += " AND product_variants.stock >= deductions.quantity"
+	}
+	query += " RETURNING product_variants.product_id, product_variants.id, products.name || ' (' || product_variants.name || ')', product_variants.stock, deductions.quantity"
+
+	err = tx.WithStmt(query, func(stmt *database.Stmt) error {
+		scanFn := func(rows *database.Rows) error {
+			var level entity.StockLevel
+			if err := rows.Scan(&level.ProductID, &level.VariantID, &level.Name, &level.Stock, &level.Sold); err != nil {
+				return err
+			}
+
+			levels = append(levels, level)
+			return nil
+		}
+
+		return stmt.Query(scanFn, pq.Array(ids), pq.Array(quantities))
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(levels) != len(ids) {
+		return nil, errors.New(constants.ErrStockNotEnough)
+	}
+
+	return levels, nil
+}
+
+// baseQuantity is the quantity of a line in the base unit of its product.
+func baseQuantity(product entity.CheckoutProduct) int {
+	if product.UnitFactor <= 1 {
+		return product.Quantity
+	}
+
+	return product.Quantity * product.UnitFactor
+}
+
+// writeStockLow writes a product.stock_low event for every product whose stock fell to the threshold or below
+// with this sale. Products that were already low do not raise the event again. A negative threshold disables it.
+func writeStockLow(tx *database.Tx, levels []entity.StockLevel, threshold int) error {
+	if threshold < 0 {
+		return nil
+	}
+
+	for _, level := range levels {
+		if level.Stock > threshold || level.Stock+level.Sold <= threshold {
+			continue
+		}
+
+		level.Threshold = threshold
+		if err := outbox.Write(tx, constants.EventProductStockLow, level); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (t *TransactionsRepository) getTransactionsDetailByTransactionID(transactionId int) []entity.CheckoutProduct {
+	var (
+		checkoutProducts []entity.CheckoutProduct
+		checkoutProduct  entity.CheckoutProduct
+		query            string
+		err              error
+	)
+
+	checkoutProducts = make([]entity.CheckoutProduct, 0)
+
+	// Lines are read from the snapshot taken at checkout, never from the current catalog.
+	query = "SELECT COALESCE(product_id, 0), COALESCE(variant_id, 0), product_name, COALESCE(product_sku, ''), unit, unit_factor, COALESCE(category_id, 0), category_name, id, transaction_id, quantity, unit_price, subtotal, discount_amount, net_amount, tax_rate, tax_amount FROM transaction_details WHERE transaction_id = $1 ORDER BY id"
+
+	err = t.db.WithStmt(query, func(stmt *database.Stmt) error {
+		scanFn := func(rows *database.Rows) error {
+			if err := rows.Scan(&checkoutProduct.ProductID, &checkoutProduct.VariantID, &checkoutProduct.Name, &checkoutProduct.SKU, &checkoutProduct.Unit, &checkoutProduct.UnitFactor, &checkoutProduct.CategoryID, &checkoutProduct.CategoryName, &checkoutProduct.TransactionDetailID, &checkoutProduct.TransactionID, &checkoutProduct.Quantity, &checkoutProduct.UnitPrice, &checkoutProduct.Subtotal, &checkoutProduct.DiscountAmount, &checkoutProduct.NetAmount, &checkoutProduct.TaxRate, &checkoutProduct.TaxAmount); err != nil {
+				return err
+			}
+			checkoutProducts = append(checkoutProducts, checkoutProduct)
 			return nil
 		}
 
@@ -289,5 +1227,327 @@ func (t *TransactionsRepository) getTransactionsDetailByTransactionID(transactio
 		return nil
 	}
 
+	promotions, err := t.getPromotionsByTransactionID(transactionId)
+	if err != nil {
+		return nil
+	}
+
+	for i := range checkoutProducts {
+		checkoutProducts[i].Promotions = promotions[checkoutProducts[i].TransactionDetailID]
+	}
+
 	return checkoutProducts
 }
+
+func (t *TransactionsRepository) GetTransactions(filter entity.TransactionFilter) ([]entity.Transaction, int64, error) {
+	var (
+		transactions []entity.Transaction
+		total        int64
+		conditions   []string
+		args         []interface{}
+		query        string
+		err          error
+	)
+
+	transactions = make([]entity.Transaction, 0)
+
+	if filter.InvoiceNumber != "" {
+		args = append(args, "%"+filter.InvoiceNumber+"%")
+		conditions = append(conditions, fmt.Sprintf("invoice_number ILIKE $%d", len(args)))
+	}
+
+	if filter.StartDate != "" {
+		args = append(args, filter.StartDate)
+		conditions = append(conditions, fmt.Sprintf("created_at >= $%d", len(args)))
+	}
+
+	if filter.EndDate != "" {
+		args = append(args, filter.EndDate)
+		conditions = append(conditions, fmt.Sprintf("created_at <= $%d", len(args)))
+	}
+
+	if filter.CashierID > 0 {
+		args = append(args, filter.CashierID)
+		conditions = append(conditions, fmt.Sprintf("user_id = $%d", len(args)))
+	}
+
+	if filter.TerminalID != "" {
+		args = append(args, filter.TerminalID)
+		conditions = append(conditions, fmt.Sprintf("terminal_id = $%d", len(args)))
+	}
+
+	if filter.NeedsReview {
+		conditions = append(conditions, "needs_review")
+	}
+
+	if filter.MinAmount > 0 {
+		args = append(args, filter.MinAmount)
+		conditions = append(conditions, fmt.Sprintf("total_amount >= $%d", len(args)))
+	}
+
+	if filter.MaxAmount > 0 {
+		args = append(args, filter.MaxAmount)
+		conditions = append(conditions, fmt.Sprintf("total_amount <= $%d", len(args)))
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	query = "SELECT COUNT(id) FROM transactions" + where
+
+	err = t.db.WithStmt(query, func(stmt *database.Stmt) error {
+		return stmt.QueryRow(args...).Scan(&total)
+	})
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query = fmt.Sprintf("SELECT %s FROM transactions%s ORDER BY created_at DESC, id DESC LIMIT $%d OFFSET $%d", transactionColumns, where, len(args)+1, len(args)+2)
+	args = append(args, filter.Limit, pagination.Offset(filter.Page, filter.Limit))
+
+	err = t.db.WithStmt(query, func(stmt *database.Stmt) error {
+		scanFn := func(rows *database.Rows) error {
+			var transaction entity.Transaction
+			if err := scanTransaction(rows, &transaction); err != nil {
+				return err
+			}
+
+			transactions = append(transactions, transaction)
+			return nil
+		}
+
+		return stmt.Query(scanFn, args...)
+	})
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return transactions, total, nil
+}
+
+func (t *TransactionsRepository) GetTransactionByID(id int) (*entity.CheckoutResponse, error) {
+	var (
+		transaction entity.Transaction
+		query       string
+		err         error
+	)
+
+	query = fmt.Sprintf("SELECT %s FROM transactions WHERE id = $1", transactionColumns)
+
+	err = t.db.WithStmt(query, func(stmt *database.Stmt) error {
+		scanFn := func(rows *database.Rows) error {
+			return scanTransaction(rows, &transaction)
+		}
+
+		return stmt.Query(scanFn, id)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if transaction.ID == 0 {
+		return nil, errors.New(constants.ErrTransactionNotFound)
+	}
+
+	checkoutProducts := t.getTransactionsDetailByTransactionID(transaction.ID)
+	if checkoutProducts == nil {
+		return nil, errors.New(constants.ErrTransactionNotFound)
+	}
+
+	payments, err := t.getPaymentsByTransactionID(transaction.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := entity.CheckoutResponse{
+		Transaction:      transaction,
+		CheckoutProducts: checkoutProducts,
+		Payments:         payments,
+	}
+
+	return &response, nil
+}
+
+// GetTransactionByClientID returns the sale uploaded with the client id, or nil when there is none.
+func (t *TransactionsRepository) GetTransactionByClientID(clientID string) (*entity.Transaction, error) {
+	var (
+		transaction entity.Transaction
+		query       string
+		err         error
+	)
+
+	query = fmt.Sprintf("SELECT %s FROM transactions WHERE client_id = $1", transactionColumns)
+	err = t.db.WithStmt(query, func(stmt *database.Stmt) error {
+		scanFn := func(rows *database.Rows) error {
+			return scanTransaction(rows, &transaction)
+		}
+
+		return stmt.Query(scanFn, clientID)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if transaction.ID == 0 {
+		return nil, nil
+	}
+
+	return &transaction, nil
+}
+
+// MarkReviewed clears the review flag of an offline sale once the stock difference has been dealt with. The time it
+// was reviewed is kept so the audit chain still sees the sale as flagged.
+func (t *TransactionsRepository) MarkReviewed(id int) error {
+	query := "UPDATE transactions SET needs_review = false, reviewed_at = CASE WHEN needs_review THEN $1 ELSE reviewed_at END, updated_at = $2 WHERE id = $3"
+
+	return t.db.WithStmt(query, func(stmt *database.Stmt) error {
+		result, err := stmt.Exec("now()", "now()", id)
+		if err != nil {
+			return err
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if affected == 0 {
+			return errors.New(constants.ErrTransactionNotFound)
+		}
+
+		return nil
+	})
+}
+
+func (t *TransactionsRepository) VoidTransaction(id int, request entity.VoidRequest) error {
+	var (
+		event          entity.VoidEvent
+		status         string
+		customerID     int
+		shiftID        int
+		pointsEarned   int
+		pointsRedeemed int
+		query          string
+		err            error
+	)
+
+	err = t.db.WithTx(func(tx *database.Tx) error {
+		// The customer id is kept when the customer is deleted, so points and credit are only given back to a customer that
+		// still exists.
+		query = "SELECT transactions.status, COALESCE(transactions.invoice_number, ''), transactions.total_amount, COALESCE(customers.id, 0), transactions.points_earned, transactions.points_redeemed FROM transactions LEFT JOIN customers ON customers.id = transactions.customer_id WHERE transactions.id = $1 FOR UPDATE OF transactions"
+		err = tx.WithStmt(query, func(stmt *database.Stmt) error {
+			return stmt.QueryRow(id).Scan(&status, &event.InvoiceNumber, &event.TotalAmount, &customerID, &pointsEarned, &pointsRedeemed)
+		})
+
+		if errors.Is(err, sql.ErrNoRows) {
+			return errors.New(constants.ErrTransactionNotFound)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if status == constants.TransactionStatusVoided {
+			return errors.New(constants.ErrTransactionVoided)
+		}
+
+		// The cash of the sale is handed back out of the drawer of the shift the user has open, if any.
+		query = "SELECT COALESCE(MAX(id), 0) FROM shifts WHERE user_id = $1 AND status = $2"
+		err = tx.WithStmt(query, func(stmt *database.Stmt) error {
+			return stmt.QueryRow(request.UserID, constants.ShiftStatusOpen).Scan(&shiftID)
+		})
+
+		if err != nil {
+			return err
+		}
+
+		query = "UPDATE transactions SET status = $1, void_reason = $2, voided_by = $3, void_shift_id = NULLIF($4, 0), voided_at = $5, updated_at = $6 WHERE id = $7"
+		err = tx.WithStmt(query, func(stmt *database.Stmt) error {
+			_, err := stmt.Exec(constants.TransactionStatusVoided, request.Reason, request.UserID, shiftID, "now()", "now()", id)
+			return err
+		})
+
+		if err != nil {
+			return err
+		}
+
+		if err = t.reversePoints(tx, customerID, id, pointsEarned, pointsRedeemed); err != nil {
+			return err
+		}
+
+		if err = t.reverseCredit(tx, customerID, id, request.UserID); err != nil {
+			return err
+		}
+
+		// Lines that were already partially returned have been restocked, so only the remaining quantity goes back,
+		// counted in the base unit.
+		query = "UPDATE products SET stock = products.stock + details.quantity, updated_at = $1 FROM (SELECT transaction_details.product_id, SUM((transaction_details.quantity - COALESCE(returned.quantity, 0)) * transaction_details.unit_factor) AS quantity FROM transaction_details LEFT JOIN (SELECT transaction_detail_id, SUM(quantity) AS quantity FROM transaction_returns GROUP BY transaction_detail_id) returned ON returned.transaction_detail_id = transaction_details.id WHERE transaction_details.transaction_id = $2 GROUP BY transaction_details.product_id) details WHERE products.id = details.product_id"
+		err = tx.WithStmt(query, func(stmt *database.Stmt) error {
+			_, err := stmt.Exec("now()", id)
+			return err
+		})
+
+		if err != nil {
+			return err
+		}
+
+		query = "UPDATE product_variants SET stock = product_variants.stock + details.quantity, updated_at = $1 FROM (SELECT transaction_details.variant_id, SUM((transaction_details.quantity - COALESCE(returned.quantity, 0)) * transaction_details.unit_factor) AS quantity FROM transaction_details LEFT JOIN (SELECT transaction_detail_id, SUM(quantity) AS quantity FROM transaction_returns GROUP BY transaction_detail_id) returned ON returned.transaction_detail_id = transaction_details.id WHERE transaction_details.transaction_id = $2 AND transaction_details.variant_id IS NOT NULL GROUP BY transaction_details.variant_id) details WHERE product_variants.id = details.variant_id"
+		err = tx.WithStmt(query, func(stmt *database.Stmt) error {
+			_, err := stmt.Exec("now()", id)
+			return err
+		})
+
+		if err != nil {
+			return err
+		}
+
+		event.TransactionID = id
+		event.Reason = request.Reason
+		event.VoidedBy = request.UserID
+
+		if err = outbox.Write(tx, constants.EventTransactionVoided, event); err != nil {
+			return err
+		}
+
+		return auditRepository.Append(tx, constants.ChainRecordVoid, id, 0)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (t *TransactionsRepository) GetCashierName(userID int) (string, error) {
+	var (
+		name  string
+		query string
+		err   error
+	)
+
+	query = "SELECT name FROM users WHERE id = $1"
+	err = t.db.WithStmt(query, func(stmt *database.Stmt) error {
+		scanFn := func(rows *database.Rows) error {
+			return rows.Scan(&name)
+		}
+
+		return stmt.Query(scanFn, userID)
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	return name, nil
+}
+
+func scanTransaction(rows *database.Rows, transaction *entity.Transaction) error {
+	return rows.Scan(&transaction.ID, &transaction.InvoiceNumber, &transaction.UserID, &transaction.TerminalID, &transaction.ShiftID, &transaction.ClientID, &transaction.NeedsReview, &transaction.CustomerID, &transaction.PointsEarned, &transaction.PointsRedeemed, &transaction.PointsDiscount, &transaction.SubtotalAmount, &transaction.DiscountAmount, &transaction.NetAmount, &transaction.TaxAmount, &transaction.ServiceCharge, &transaction.TotalAmount, &transaction.PaidAmount, &transaction.Change, &transaction.Status, &transaction.VoidReason, &transaction.VoidedBy, &transaction.VoidedAt, &transaction.CreatedAt, &transaction.UpdatedAt)
+}
@@ -0,0 +1,64 @@
+package repository_test
+
+import (
+	"testing"
+
+	constants "github.com/pandusatrianura/kasir_api_service/constant"
+	"github.com/pandusatrianura/kasir_api_service/internal/transactions/entity"
+	"github.com/pandusatrianura/kasir_api_service/internal/transactions/repository"
+	"github.com/pandusatrianura/kasir_api_service/pkg/database"
+	"github.com/pandusatrianura/kasir_api_service/pkg/database/dbtest"
+)
+
+// seedVariantCarton adds a product sold per flavor and by the carton of 40, whose carton price only applies to the
+// product itself.
+func seedVariantCarton(t *testing.T, db *database.DB) (userID int, productID int, variantID int) {
+	t.Helper()
+
+	userID = dbtest.QueryInt(t, db, "INSERT INTO users (name, email, password) VALUES ('Kasir', 'kasir@example.com', 'x') RETURNING id")
+	categoryID := dbtest.QueryInt(t, db, "INSERT INTO categories (name, description) VALUES ('Makanan', '') RETURNING id")
+	productID = dbtest.QueryInt(t, db, "INSERT INTO products (name, price, stock, category_id, variant_axes) VALUES ('Indomie', 3000, 100, $1, '[\"flavor\"]') RETURNING id", categoryID)
+	variantID = dbtest.QueryInt(t, db, "INSERT INTO product_variants (product_id, name, options, price, stock) VALUES ($1, 'Pedas', '{\"flavor\": \"Pedas\"}', 3500, 100) RETURNING id", productID)
+	dbtest.QueryInt(t, db, "INSERT INTO product_units (product_id, name, factor, price) VALUES ($1, 'dus', 40, 100000) RETURNING id", productID)
+
+	return userID, productID, variantID
+}
+
+func TestVariantSoldByTheCarton(t *testing.T) {
+	const cartonPrice = 3500 * 40
+
+	db := dbtest.Open(t)
+	userID, productID, variantID := seedVariantCarton(t, db)
+	repo := repository.NewTransactionsRepository(db)
+
+	checkout := entity.Checkout{
+		UserID:            userID,
+		TerminalID:        "KASIR-01",
+		InvoicePrefix:     "TEST",
+		LowStockThreshold: -1,
+		Checkouts:         []entity.CheckoutRequest{{ProductID: productID, VariantID: variantID, Unit: "dus", Quantity: 2}},
+		Payments:          []entity.PaymentRequest{{Method: constants.PaymentMethodCash, Amount: 2 * cartonPrice}},
+	}
+
+	quote, err := repo.Quote(checkout)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(quote.Lines) != 1 || quote.Lines[0].UnitPrice != cartonPrice || quote.TotalAmount != 2*cartonPrice {
+		t.Fatalf("quote = %+v, want a unit price of %d and a total of %d", quote, cartonPrice, 2*cartonPrice)
+	}
+
+	sale, err := repo.Checkout(checkout)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sale.CheckoutProducts) != 1 || sale.CheckoutProducts[0].UnitPrice != cartonPrice || sale.Transaction.TotalAmount != 2*cartonPrice {
+		t.Fatalf("sale = %+v, want a unit price of %d and a total of %d", sale, cartonPrice, 2*cartonPrice)
+	}
+
+	if stock := dbtest.QueryInt(t, db, "SELECT stock FROM product_variants WHERE id = $1", variantID); stock != 20 {
+		t.Fatalf("variant stock is %d after selling 2 cartons of 40, want 20", stock)
+	}
+}
@@ -0,0 +1,49 @@
+package repository_test
+
+import (
+	"testing"
+
+	constants "github.com/pandusatrianura/kasir_api_service/constant"
+	"github.com/pandusatrianura/kasir_api_service/internal/transactions/entity"
+	"github.com/pandusatrianura/kasir_api_service/internal/transactions/repository"
+	"github.com/pandusatrianura/kasir_api_service/pkg/database/dbtest"
+)
+
+func TestVoidTransactionRejectsVoidedAndMissingSales(t *testing.T) {
+	db := dbtest.Open(t)
+
+	userID := dbtest.QueryInt(t, db, "INSERT INTO users (name, email, password) VALUES ('Kasir', 'kasir@example.com', 'x') RETURNING id")
+	categoryID := dbtest.QueryInt(t, db, "INSERT INTO categories (name, description) VALUES ('Makanan', '') RETURNING id")
+	productID := dbtest.QueryInt(t, db, "INSERT INTO products (name, price, stock, category_id) VALUES ('Indomie', 3000, 10, $1) RETURNING id", categoryID)
+
+	repo := repository.NewTransactionsRepository(db)
+
+	sale, err := repo.Checkout(entity.Checkout{
+		UserID:            userID,
+		TerminalID:        "KASIR-01",
+		InvoicePrefix:     "TEST",
+		LowStockThreshold: -1,
+		Checkouts:         []entity.CheckoutRequest{{ProductID: productID, Quantity: 2}},
+		Payments:          []entity.PaymentRequest{{Method: constants.PaymentMethodCash, Amount: 6000}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request := entity.VoidRequest{UserID: userID, Reason: "Salah input"}
+	if err = repo.VoidTransaction(sale.Transaction.ID, request); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = repo.VoidTransaction(sale.Transaction.ID, request); err == nil || err.Error() != constants.ErrTransactionVoided {
+		t.Fatalf("second void returned %v, want %s", err, constants.ErrTransactionVoided)
+	}
+
+	if stock := dbtest.QueryInt(t, db, "SELECT stock FROM products WHERE id = $1", productID); stock != 10 {
+		t.Fatalf("stock is %d after voiding twice, want 10", stock)
+	}
+
+	if err = repo.VoidTransaction(sale.Transaction.ID+1000, request); err == nil || err.Error() != constants.ErrTransactionNotFound {
+		t.Fatalf("void of a missing sale returned %v, want %s", err, constants.ErrTransactionNotFound)
+	}
+}
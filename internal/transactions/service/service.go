@@ -1,22 +1,65 @@
 package service
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	constants "github.com/pandusatrianura/kasir_api_service/constant"
+	promotionRepository "github.com/pandusatrianura/kasir_api_service/internal/promotions/repository"
 	"github.com/pandusatrianura/kasir_api_service/internal/transactions/entity"
 	"github.com/pandusatrianura/kasir_api_service/internal/transactions/repository"
+	"github.com/pandusatrianura/kasir_api_service/pkg/barcode"
+	"github.com/pandusatrianura/kasir_api_service/pkg/datetime"
+	"github.com/pandusatrianura/kasir_api_service/pkg/receipt"
+	"github.com/spf13/viper"
+)
+
+const (
+	defaultTaxRate           = 11
+	defaultIdempotencyTTL    = 24 * time.Hour
+	maxIdempotencyKeyLen     = 255
+	defaultStoreName         = "Kasir"
+	defaultInvoicePrefix     = "INV"
+	defaultSpendPerPoint     = 10000
+	defaultPointValue        = 100
+	defaultLowStockThreshold = 5
+	maxSyncBatch             = 100
+
+	// maxClockSkew is how far ahead of the server clock an offline terminal's clock may run.
+	maxClockSkew = 5 * time.Minute
 )
 
+var uuidPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+
 type ITransactionsService interface {
-	Checkout([]entity.CheckoutRequest) (*entity.CheckoutResponse, error)
+	Checkout(checkout entity.Checkout) (*entity.CheckoutResponse, error)
+	Quote(checkout entity.Checkout) (*entity.QuoteResponse, error)
+	GetTransactions(filter entity.TransactionFilter) ([]entity.Transaction, int64, error)
+	GetTransactionByID(id int) (*entity.CheckoutResponse, error)
+	VoidTransaction(id int, request entity.VoidRequest) (*entity.CheckoutResponse, error)
+	GetReceipt(id int) (*receipt.Receipt, error)
+	Sync(request entity.SyncRequest) ([]entity.SyncResult, error)
+	MarkReviewed(id int) (*entity.CheckoutResponse, error)
 	API() entity.HealthCheck
 }
 
 type TransactionsService struct {
 	transactionsRepository repository.ITransactionsRepository
+	promotionRepository    promotionRepository.IPromotionRepository
 }
 
-func NewTransactionsService(repo repository.ITransactionsRepository) ITransactionsService {
+func NewTransactionsService(repo repository.ITransactionsRepository, promotionRepository promotionRepository.IPromotionRepository) ITransactionsService {
 	return &TransactionsService{
 		transactionsRepository: repo,
+		promotionRepository:    promotionRepository,
 	}
 }
 
@@ -27,12 +70,502 @@ func (t *TransactionsService) API() entity.HealthCheck {
 	}
 }
 
-func (t *TransactionsService) Checkout(requests []entity.CheckoutRequest) (*entity.CheckoutResponse, error) {
+// Checkout records a sale. When the request carries an idempotency key, a retry with the same body replays
+// the original response instead of creating a second sale, and reusing the key for another body is rejected.
+func (t *TransactionsService) Checkout(checkout entity.Checkout) (*entity.CheckoutResponse, error) {
+	if checkout.IdempotencyKey == "" {
+		return t.checkout(checkout)
+	}
+
+	if len(checkout.IdempotencyKey) > maxIdempotencyKeyLen {
+		return nil, errors.New(constants.ErrInvalidIdempotencyKey)
+	}
+
+	requestHash, err := hashCheckout(checkout)
+	if err != nil {
+		return nil, err
+	}
+
+	key := entity.IdempotencyKey{
+		UserID:      checkout.UserID,
+		Key:         checkout.IdempotencyKey,
+		RequestHash: requestHash,
+	}
+
+	stored, err := t.transactionsRepository.ReserveIdempotencyKey(key, idempotencyTTL())
+	if err != nil {
+		return nil, err
+	}
+
+	if stored != nil {
+		return t.replayCheckout(stored, requestHash)
+	}
+
+	response, err := t.checkout(checkout)
+	if err != nil {
+		// Only a key still processing is released; when the sale committed and only reading it back failed, the
+		// key was completed with the sale and a retry replays it.
+		if releaseErr := t.transactionsRepository.ReleaseIdempotencyKey(key); releaseErr != nil {
+			log.Printf("Failed to release idempotency key %q: %v", key.Key, releaseErr)
+		}
+
+		return nil, err
+	}
+
+	body, err := json.Marshal(response)
+	if err != nil {
+		return nil, err
+	}
+
+	// The key is already completed by the sale itself; storing the response only spares replays reading it back.
+	key.Response = string(body)
+	if err = t.transactionsRepository.CompleteIdempotencyKey(key); err != nil {
+		log.Printf("Failed to store response for idempotency key %q: %v", key.Key, err)
+	}
+
+	return response, nil
+}
+
+func (t *TransactionsService) checkout(checkout entity.Checkout) (*entity.CheckoutResponse, error) {
+	checkout, err := t.prepareCheckout(checkout)
+	if err != nil {
+		return nil, err
+	}
 
-	response, err := t.transactionsRepository.Checkout(requests)
+	response, err := t.transactionsRepository.Checkout(checkout)
 	if err != nil {
 		return nil, err
 	}
 
 	return response, nil
 }
+
+// Quote prices a sale with the same promotions, tax and loyalty settings as Checkout without recording it.
+func (t *TransactionsService) Quote(checkout entity.Checkout) (*entity.QuoteResponse, error) {
+	checkout, err := t.prepareCheckout(checkout)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.transactionsRepository.Quote(checkout)
+}
+
+// prepareCheckout validates the points and credit of a sale, resolves scanned barcodes to their products and loads
+// the store settings it is priced with.
+func (t *TransactionsService) prepareCheckout(checkout entity.Checkout) (entity.Checkout, error) {
+	if checkout.RedeemPoints < 0 {
+		return checkout, errors.New(constants.ErrInvalidRedeemPoints)
+	}
+
+	if checkout.RedeemPoints > 0 && checkout.CustomerID <= 0 {
+		return checkout, errors.New(constants.ErrRedeemNeedsCustomer)
+	}
+
+	if creditAmount(checkout.Payments) > 0 && checkout.CustomerID <= 0 {
+		return checkout, errors.New(constants.ErrCreditNeedsCustomer)
+	}
+
+	checkouts, err := t.resolveBarcodes(checkout.Checkouts)
+	if err != nil {
+		return checkout, err
+	}
+
+	checkout.Checkouts = checkouts
+
+	promotions, err := t.promotionRepository.GetAllPromotions(true)
+	if err != nil {
+		return checkout, err
+	}
+
+	checkout.Promotions = promotions
+	checkout.Tax = taxConfig()
+	checkout.InvoicePrefix = invoicePrefix()
+	checkout.Loyalty = loyaltyConfig()
+	checkout.ShiftRequired = viper.GetBool("SHIFT_REQUIRED")
+	checkout.LowStockThreshold = lowStockThreshold()
+
+	return checkout, nil
+}
+
+func (t *TransactionsService) GetTransactions(filter entity.TransactionFilter) ([]entity.Transaction, int64, error) {
+	return t.transactionsRepository.GetTransactions(filter)
+}
+
+func (t *TransactionsService) GetTransactionByID(id int) (*entity.CheckoutResponse, error) {
+	return t.transactionsRepository.GetTransactionByID(id)
+}
+
+func (t *TransactionsService) VoidTransaction(id int, request entity.VoidRequest) (*entity.CheckoutResponse, error) {
+	request.Reason = strings.TrimSpace(request.Reason)
+	if request.Reason == "" {
+		return nil, errors.New(constants.ErrVoidReasonRequired)
+	}
+
+	if err := t.transactionsRepository.VoidTransaction(id, request); err != nil {
+		return nil, err
+	}
+
+	return t.transactionsRepository.GetTransactionByID(id)
+}
+
+// Sync records a batch of sales captured offline, one database transaction per sale, and reports for every sale
+// whether it was accepted, had already been uploaded (duplicate) or was rejected.
+func (t *TransactionsService) Sync(request entity.SyncRequest) ([]entity.SyncResult, error) {
+	if len(request.Transactions) == 0 || len(request.Transactions) > maxSyncBatch {
+		return nil, errors.New(constants.ErrInvalidSyncBatch)
+	}
+
+	results := make([]entity.SyncResult, 0, len(request.Transactions))
+	for _, offline := range request.Transactions {
+		results = append(results, t.syncTransaction(request, offline))
+	}
+
+	return results, nil
+}
+
+func (t *TransactionsService) syncTransaction(request entity.SyncRequest, offline entity.OfflineTransaction) entity.SyncResult {
+	clientID := strings.ToLower(strings.TrimSpace(offline.ClientID))
+	result := entity.SyncResult{ClientID: offline.ClientID}
+
+	if !uuidPattern.MatchString(clientID) {
+		return rejected(result, errors.New(constants.ErrInvalidClientID))
+	}
+
+	soldAt, err := datetime.ParseTime(offline.CreatedAt)
+	if err != nil || soldAt.After(time.Now().Add(maxClockSkew)) {
+		return rejected(result, errors.New(constants.ErrInvalidSaleTime))
+	}
+
+	if len(offline.Checkouts) == 0 {
+		return rejected(result, errors.New(constants.ErrInvalidCheckoutRequest))
+	}
+
+	existing, err := t.transactionsRepository.GetTransactionByClientID(clientID)
+	if err != nil {
+		return rejected(result, err)
+	}
+
+	if existing != nil {
+		return synced(result, constants.SyncStatusDuplicate, *existing)
+	}
+
+	response, err := t.checkout(entity.Checkout{
+		UserID:        request.UserID,
+		TerminalID:    request.TerminalID,
+		ClientID:      clientID,
+		SoldAt:        soldAt,
+		AllowNegative: true,
+		CustomerID:    offline.CustomerID,
+		RedeemPoints:  offline.RedeemPoints,
+		Checkouts:     offline.Checkouts,
+		Payments:      offline.Payments,
+	})
+
+	if err != nil && err.Error() == constants.ErrDuplicateClientID {
+		// Another upload of the same batch recorded the sale first.
+		if existing, lookupErr := t.transactionsRepository.GetTransactionByClientID(clientID); lookupErr == nil && existing != nil {
+			return synced(result, constants.SyncStatusDuplicate, *existing)
+		}
+	}
+
+	if err != nil {
+		return rejected(result, err)
+	}
+
+	return synced(result, constants.SyncStatusAccepted, response.Transaction)
+}
+
+func synced(result entity.SyncResult, status string, transaction entity.Transaction) entity.SyncResult {
+	result.Status = status
+	result.TransactionID = transaction.ID
+	result.InvoiceNumber = transaction.InvoiceNumber
+	result.NeedsReview = transaction.NeedsReview
+	return result
+}
+
+func rejected(result entity.SyncResult, err error) entity.SyncResult {
+	result.Status = constants.SyncStatusRejected
+	result.Error = err.Error()
+	return result
+}
+
+func (t *TransactionsService) MarkReviewed(id int) (*entity.CheckoutResponse, error) {
+	if err := t.transactionsRepository.MarkReviewed(id); err != nil {
+		return nil, err
+	}
+
+	return t.transactionsRepository.GetTransactionByID(id)
+}
+
+// taxConfig reads the store tax rules; PPN defaults to 11% on prices that exclude tax.
+func taxConfig() entity.TaxConfig {
+	rate := defaultTaxRate * 1.0
+	if viper.IsSet("TAX_RATE") {
+		rate = viper.GetFloat64("TAX_RATE")
+	}
+
+	return entity.TaxConfig{
+		Rate:              rate,
+		Inclusive:         viper.GetBool("TAX_PRICE_INCLUSIVE"),
+		ServiceChargeRate: viper.GetFloat64("SERVICE_CHARGE_RATE"),
+	}
+}
+
+// loyaltyConfig reads the loyalty program; by default one point is earned per Rp10.000 spent and a point is worth Rp100.
+// LOYALTY_SPEND_PER_POINT=0 stops customers from earning points.
+func loyaltyConfig() entity.LoyaltyConfig {
+	loyalty := entity.LoyaltyConfig{
+		SpendPerPoint: defaultSpendPerPoint,
+		PointValue:    defaultPointValue,
+	}
+
+	if viper.IsSet("LOYALTY_SPEND_PER_POINT") {
+		loyalty.SpendPerPoint = viper.GetInt("LOYALTY_SPEND_PER_POINT")
+	}
+
+	if viper.IsSet("LOYALTY_POINT_VALUE") {
+		loyalty.PointValue = viper.GetInt("LOYALTY_POINT_VALUE")
+	}
+
+	return loyalty
+}
+
+// lowStockThreshold is the stock at or below which a sale raises the product.stock_low event (LOW_STOCK_THRESHOLD).
+func lowStockThreshold() int {
+	if viper.IsSet("LOW_STOCK_THRESHOLD") {
+		return viper.GetInt("LOW_STOCK_THRESHOLD")
+	}
+
+	return defaultLowStockThreshold
+}
+
+// GetReceipt builds the receipt of a transaction with the store settings (STORE_NAME, STORE_ADDRESS, STORE_PHONE
+// and RECEIPT_FOOTER).
+func (t *TransactionsService) GetReceipt(id int) (*receipt.Receipt, error) {
+	transaction, err := t.transactionsRepository.GetTransactionByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	r := receipt.Receipt{
+		Store: receipt.Store{
+			Name:    viper.GetString("STORE_NAME"),
+			Address: viper.GetString("STORE_ADDRESS"),
+			Phone:   viper.GetString("STORE_PHONE"),
+			Footer:  viper.GetString("RECEIPT_FOOTER"),
+		},
+		Number:         transaction.Transaction.InvoiceNumber,
+		Date:           transaction.Transaction.CreatedAt,
+		Terminal:       transaction.Transaction.TerminalID,
+		Voided:         transaction.Transaction.Status == constants.TransactionStatusVoided,
+		Subtotal:       transaction.Transaction.SubtotalAmount,
+		Discount:       transaction.Transaction.DiscountAmount - transaction.Transaction.PointsDiscount,
+		PointsDiscount: transaction.Transaction.PointsDiscount,
+		PointsEarned:   transaction.Transaction.PointsEarned,
+		NetAmount:      transaction.Transaction.NetAmount,
+		Tax:            transaction.Transaction.TaxAmount,
+		ServiceCharge:  transaction.Transaction.ServiceCharge,
+		Total:          transaction.Transaction.TotalAmount,
+		Paid:           transaction.Transaction.PaidAmount,
+		Change:         transaction.Transaction.Change,
+	}
+
+	if r.Store.Name == "" {
+		r.Store.Name = defaultStoreName
+	}
+
+	if r.Number == "" {
+		r.Number = strconv.Itoa(transaction.Transaction.ID)
+	}
+
+	if createdAt, err := datetime.ParseTime(transaction.Transaction.CreatedAt); err == nil {
+		r.Date = createdAt.Format("02-01-2006 15:04")
+	}
+
+	if transaction.Transaction.UserID > 0 {
+		if r.Cashier, err = t.transactionsRepository.GetCashierName(transaction.Transaction.UserID); err != nil {
+			return nil, err
+		}
+	}
+
+	if transaction.Transaction.CustomerID > 0 {
+		if r.Customer, err = t.transactionsRepository.GetCustomerName(transaction.Transaction.CustomerID); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, product := range transaction.CheckoutProducts {
+		line := receipt.Line{
+			Name:      product.Name,
+			Quantity:  product.Quantity,
+			Unit:      product.Unit,
+			UnitPrice: product.UnitPrice,
+		}
+
+		for _, promotion := range product.Promotions {
+			line.Discounts = append(line.Discounts, receipt.Discount{Name: promotion.PromotionName, Amount: promotion.DiscountAmount})
+		}
+
+		r.Lines = append(r.Lines, line)
+	}
+
+	for _, payment := range transaction.Payments {
+		r.Payments = append(r.Payments, receipt.Payment{Method: paymentLabel(payment.Method), Amount: payment.Amount})
+	}
+
+	return &r, nil
+}
+
+func paymentLabel(method string) string {
+	switch method {
+	case constants.PaymentMethodCash:
+		return "Tunai"
+	case constants.PaymentMethodDebit:
+		return "Debit"
+	case constants.PaymentMethodQRIS:
+		return "QRIS"
+	case constants.PaymentMethodEWallet:
+		return "E-Wallet"
+	case constants.PaymentMethodVoucher:
+		return "Voucher"
+	case constants.PaymentMethodCredit:
+		return "Kasbon"
+	default:
+		return method
+	}
+}
+
+// resolveBarcodes fills in the product of every line that names it by barcode instead of product_id, and the variant
+// when the barcode is one of a variant and the line does not name a variant itself. All unknown barcodes are
+// reported in a single error, in the same form as unknown product ids.
+func (t *TransactionsService) resolveBarcodes(requests []entity.CheckoutRequest) ([]entity.CheckoutRequest, error) {
+	var (
+		codes   []string
+		missing []string
+	)
+
+	resolved := make([]entity.CheckoutRequest, len(requests))
+	copy(resolved, requests)
+
+	for i, request := range resolved {
+		if request.ProductID != 0 || request.Barcode == "" {
+			continue
+		}
+
+		code, err := barcode.Normalize(request.Barcode)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", constants.ErrInvalidBarcode, request.Barcode)
+		}
+
+		resolved[i].Barcode = code
+		codes = append(codes, code)
+	}
+
+	if len(codes) == 0 {
+		return resolved, nil
+	}
+
+	items, err := t.transactionsRepository.GetItemsByBarcode(codes)
+	if err != nil {
+		return nil, err
+	}
+
+	reported := make(map[string]bool)
+	for i, request := range resolved {
+		if request.ProductID != 0 || request.Barcode == "" {
+			continue
+		}
+
+		item, ok := items[request.Barcode]
+		if !ok {
+			if !reported[request.Barcode] {
+				reported[request.Barcode] = true
+				missing = append(missing, "barcode "+request.Barcode)
+			}
+			continue
+		}
+
+		resolved[i].ProductID = item.ProductID
+		if request.VariantID == 0 {
+			resolved[i].VariantID = item.VariantID
+		}
+	}
+
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%s: %s", constants.ErrProductNotFound, strings.Join(missing, ", "))
+	}
+
+	return resolved, nil
+}
+
+// creditAmount is the part of a sale paid on credit.
+func creditAmount(payments []entity.PaymentRequest) int {
+	var amount int
+	for _, payment := range payments {
+		if payment.Method == constants.PaymentMethodCredit {
+			amount += payment.Amount
+		}
+	}
+
+	return amount
+}
+
+func hashCheckout(checkout entity.Checkout) (string, error) {
+	body, err := json.Marshal(checkout)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// replayCheckout answers a retried checkout with the stored response, or with the recorded sale when the response
+// could not be stored.
+func (t *TransactionsService) replayCheckout(stored *entity.IdempotencyKey, requestHash string) (*entity.CheckoutResponse, error) {
+	if stored.RequestHash != requestHash {
+		return nil, errors.New(constants.ErrIdempotencyKeyReused)
+	}
+
+	if stored.Status != constants.IdempotencyStatusCompleted {
+		return nil, errors.New(constants.ErrIdempotencyInProgress)
+	}
+
+	if stored.Response == "" {
+		response, err := t.transactionsRepository.GetTransactionByID(stored.TransactionID)
+		if err != nil {
+			return nil, err
+		}
+
+		response.Replayed = true
+		return response, nil
+	}
+
+	var response entity.CheckoutResponse
+	if err := json.Unmarshal([]byte(stored.Response), &response); err != nil {
+		return nil, err
+	}
+
+	response.Replayed = true
+	return &response, nil
+}
+
+// idempotencyTTL reads how long checkout idempotency keys are kept; 24h by default.
+func idempotencyTTL() time.Duration {
+	ttl := viper.GetDuration("IDEMPOTENCY_KEY_TTL")
+	if ttl <= 0 {
+		return defaultIdempotencyTTL
+	}
+
+	return ttl
+}
+
+// invoicePrefix reads the INVOICE_PREFIX of the store; stores sharing a database should each use their own prefix.
+func invoicePrefix() string {
+	prefix := strings.TrimSpace(viper.GetString("INVOICE_PREFIX"))
+	if prefix == "" {
+		return defaultInvoicePrefix
+	}
+
+	return prefix
+}
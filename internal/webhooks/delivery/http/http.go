@@ -0,0 +1,322 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	constants "github.com/pandusatrianura/kasir_api_service/constant"
+	"github.com/pandusatrianura/kasir_api_service/internal/webhooks/entity"
+	"github.com/pandusatrianura/kasir_api_service/internal/webhooks/service"
+	"github.com/pandusatrianura/kasir_api_service/pkg/pagination"
+	"github.com/pandusatrianura/kasir_api_service/pkg/response"
+)
+
+type WebhookHandler struct {
+	service service.IWebhookService
+}
+
+func NewWebhookHandler(service service.IWebhookService) *WebhookHandler {
+	return &WebhookHandler{service: service}
+}
+
+// API godoc
+// @Summary Get health status of webhooks API
+// @Description Get health status of webhooks API
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 503 {object} map[string]string
+// @Router /api/webhooks/health [get]
+func (h *WebhookHandler) API(w http.ResponseWriter, r *http.Request) {
+	var result response.APIResponse
+	svcHealthCheckResult := h.service.API()
+
+	if svcHealthCheckResult.IsHealthy {
+		result.Code = strconv.Itoa(constants.SuccessCode)
+		result.Message = fmt.Sprintf("%s is healthy", svcHealthCheckResult.Name)
+		response.WriteJSONResponse(w, http.StatusOK, result)
+		return
+	}
+
+	result.Code = strconv.Itoa(constants.ErrorCode)
+	result.Message = fmt.Sprintf("%s is not healthy", svcHealthCheckResult.Name)
+	response.WriteJSONResponse(w, http.StatusServiceUnavailable, result)
+	return
+}
+
+// CreateWebhook godoc
+// @Summary Register a webhook
+// @Description Register a URL that receives sales events signed with HMAC-SHA256. Without a secret one is generated; it is only returned in this response. An empty event_types subscribes to every event.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param X-API-Key header string true "your-secret-api-key-here"
+// @Param Authorization header string true "Bearer <token>"
+// @Param webhook body entity.RequestWebhook true "Webhook Data"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/webhooks [post]
+func (h *WebhookHandler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		response.Error(w, http.StatusMethodNotAllowed, constants.ErrorCode, constants.ErrInvalidMethod, nil)
+		return
+	}
+
+	role := r.Header.Get("X-User-Roles")
+	if role != constants.ManagerRole {
+		response.Error(w, http.StatusUnauthorized, constants.ErrorCode, constants.ErrRoleNotAuthorized, errors.New(fmt.Sprintf("%s", role)))
+		return
+	}
+
+	var request entity.RequestWebhook
+	if err := response.ParseJSON(r, &request); err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidWebhookRequest, err)
+		return
+	}
+
+	webhook, err := h.service.CreateWebhook(&request)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, constants.ErrorCode, "Webhook created failed", err)
+		return
+	}
+
+	response.Success(w, http.StatusCreated, constants.SuccessCode, "Webhook created successfully", webhook)
+}
+
+// UpdateWebhook godoc
+// @Summary Update a webhook
+// @Description Update the URL, events and status of a webhook. The secret is only replaced when a new one is given.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param X-API-Key header string true "your-secret-api-key-here"
+// @Param Authorization header string true "Bearer <token>"
+// @Param id path int true "Webhook ID"
+// @Param webhook body entity.RequestWebhook true "Webhook Data"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/webhooks/{id} [put]
+func (h *WebhookHandler) UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		response.Error(w, http.StatusMethodNotAllowed, constants.ErrorCode, constants.ErrInvalidMethod, nil)
+		return
+	}
+
+	role := r.Header.Get("X-User-Roles")
+	if role != constants.ManagerRole {
+		response.Error(w, http.StatusUnauthorized, constants.ErrorCode, constants.ErrRoleNotAuthorized, errors.New(fmt.Sprintf("%s", role)))
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidWebhookID, err)
+		return
+	}
+
+	var request entity.RequestWebhook
+	if err := response.ParseJSON(r, &request); err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidWebhookRequest, err)
+		return
+	}
+
+	webhook, err := h.service.UpdateWebhook(id, &request)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, constants.ErrorCode, "Webhook updated failed", err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, constants.SuccessCode, "Webhook updated successfully", webhook)
+}
+
+// DeleteWebhook godoc
+// @Summary Delete a webhook
+// @Description Delete a webhook together with its deliveries
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param X-API-Key header string true "your-secret-api-key-here"
+// @Param Authorization header string true "Bearer <token>"
+// @Param id path int true "Webhook ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/webhooks/{id} [delete]
+func (h *WebhookHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		response.Error(w, http.StatusMethodNotAllowed, constants.ErrorCode, constants.ErrInvalidMethod, nil)
+		return
+	}
+
+	role := r.Header.Get("X-User-Roles")
+	if role != constants.ManagerRole {
+		response.Error(w, http.StatusUnauthorized, constants.ErrorCode, constants.ErrRoleNotAuthorized, errors.New(fmt.Sprintf("%s", role)))
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidWebhookID, err)
+		return
+	}
+
+	if err := h.service.DeleteWebhook(id); err != nil {
+		response.Error(w, http.StatusInternalServerError, constants.ErrorCode, "Webhook delete failed", err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, constants.SuccessCode, "Webhook deleted successfully", nil)
+}
+
+// GetWebhookByID godoc
+// @Summary Get a webhook by ID
+// @Description Get a webhook and the events it subscribes to
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param X-API-Key header string true "your-secret-api-key-here"
+// @Param Authorization header string true "Bearer <token>"
+// @Param id path int true "Webhook ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/webhooks/{id} [get]
+func (h *WebhookHandler) GetWebhookByID(w http.ResponseWriter, r *http.Request) {
+	role := r.Header.Get("X-User-Roles")
+	if role != constants.ManagerRole {
+		response.Error(w, http.StatusUnauthorized, constants.ErrorCode, constants.ErrRoleNotAuthorized, errors.New(fmt.Sprintf("%s", role)))
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidWebhookID, err)
+		return
+	}
+
+	webhook, err := h.service.GetWebhookByID(id)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, constants.ErrorCode, "Webhook retrieved failed", err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, constants.SuccessCode, "Webhook retrieved successfully", webhook)
+}
+
+// GetWebhooks godoc
+// @Summary Get webhooks
+// @Description List every registered webhook
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param X-API-Key header string true "your-secret-api-key-here"
+// @Param Authorization header string true "Bearer <token>"
+// @Success 200 {object} map[string]interface{}
+// @Failure 500 {object} map[string]string
+// @Router /api/webhooks [get]
+func (h *WebhookHandler) GetWebhooks(w http.ResponseWriter, r *http.Request) {
+	role := r.Header.Get("X-User-Roles")
+	if role != constants.ManagerRole {
+		response.Error(w, http.StatusUnauthorized, constants.ErrorCode, constants.ErrRoleNotAuthorized, errors.New(fmt.Sprintf("%s", role)))
+		return
+	}
+
+	webhooks, err := h.service.GetWebhooks()
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, constants.ErrorCode, "Webhooks retrieved failed", err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, constants.SuccessCode, "Webhooks retrieved successfully", webhooks)
+}
+
+// GetDeliveries godoc
+// @Summary Get the deliveries of a webhook
+// @Description List the deliveries of a webhook, newest first. Use status=dead to see the dead-lettered ones.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param X-API-Key header string true "your-secret-api-key-here"
+// @Param Authorization header string true "Bearer <token>"
+// @Param id path int true "Webhook ID"
+// @Param status query string false "pending, delivered or dead"
+// @Param page query int false "Page (default 1)"
+// @Param limit query int false "Limit per page (default 20, max 100)"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/webhooks/{id}/deliveries [get]
+func (h *WebhookHandler) GetDeliveries(w http.ResponseWriter, r *http.Request) {
+	var (
+		filter entity.DeliveryFilter
+		err    error
+	)
+
+	role := r.Header.Get("X-User-Roles")
+	if role != constants.ManagerRole {
+		response.Error(w, http.StatusUnauthorized, constants.ErrorCode, constants.ErrRoleNotAuthorized, errors.New(fmt.Sprintf("%s", role)))
+		return
+	}
+
+	filter.WebhookID, err = strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidWebhookID, err)
+		return
+	}
+
+	filter.Page, filter.Limit = pagination.Parse(r)
+	filter.Status = r.URL.Query().Get("status")
+
+	deliveries, total, err := h.service.GetDeliveries(filter)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, constants.ErrorCode, "Webhook deliveries retrieved failed", err)
+		return
+	}
+
+	response.SuccessWithMeta(w, http.StatusOK, constants.SuccessCode, "Webhook deliveries retrieved successfully", deliveries, pagination.NewMeta(filter.Page, filter.Limit, total))
+}
+
+// RetryDelivery godoc
+// @Summary Retry a dead-lettered delivery
+// @Description Put a delivery that ran out of attempts back in the queue with a fresh set of attempts
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param X-API-Key header string true "your-secret-api-key-here"
+// @Param Authorization header string true "Bearer <token>"
+// @Param id path int true "Delivery ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/webhooks/deliveries/{id}/retry [post]
+func (h *WebhookHandler) RetryDelivery(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		response.Error(w, http.StatusMethodNotAllowed, constants.ErrorCode, constants.ErrInvalidMethod, nil)
+		return
+	}
+
+	role := r.Header.Get("X-User-Roles")
+	if role != constants.ManagerRole {
+		response.Error(w, http.StatusUnauthorized, constants.ErrorCode, constants.ErrRoleNotAuthorized, errors.New(fmt.Sprintf("%s", role)))
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidDeliveryID, err)
+		return
+	}
+
+	if err := h.service.RetryDelivery(id); err != nil {
+		response.Error(w, http.StatusInternalServerError, constants.ErrorCode, "Webhook delivery retry failed", err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, constants.SuccessCode, "Webhook delivery queued for retry", nil)
+}
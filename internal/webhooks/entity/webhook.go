@@ -0,0 +1,73 @@
+package entity
+
+import "encoding/json"
+
+type HealthCheck struct {
+	Name      string `json:"name"`
+	IsHealthy bool   `json:"is_healthy"`
+}
+
+// Webhook is an endpoint that receives domain events. An empty EventTypes subscribes to every event. The secret
+// is only returned when the webhook is created.
+type Webhook struct {
+	ID          int      `json:"id"`
+	URL         string   `json:"url"`
+	Secret      string   `json:"secret,omitempty"`
+	EventTypes  []string `json:"event_types"`
+	Description string   `json:"description,omitempty"`
+	IsActive    bool     `json:"is_active"`
+	CreatedAt   string   `json:"created_at,omitempty"`
+	UpdatedAt   string   `json:"updated_at,omitempty"`
+}
+
+type RequestWebhook struct {
+	URL         string   `json:"url"`
+	Secret      string   `json:"secret,omitempty"`
+	EventTypes  []string `json:"event_types"`
+	Description string   `json:"description,omitempty"`
+	IsActive    *bool    `json:"is_active,omitempty"`
+}
+
+// Delivery is one event sent, or still to be sent, to one webhook.
+type Delivery struct {
+	ID             int     `json:"id"`
+	WebhookID      int     `json:"webhook_id"`
+	EventID        int     `json:"event_id"`
+	EventType      string  `json:"event_type"`
+	Status         string  `json:"status"`
+	Attempts       int     `json:"attempts"`
+	LastStatusCode int     `json:"last_status_code,omitempty"`
+	LastError      string  `json:"last_error,omitempty"`
+	NextAttemptAt  string  `json:"next_attempt_at,omitempty"`
+	DeliveredAt    *string `json:"delivered_at,omitempty"`
+	CreatedAt      string  `json:"created_at,omitempty"`
+	UpdatedAt      string  `json:"updated_at,omitempty"`
+}
+
+type DeliveryFilter struct {
+	Page      int
+	Limit     int
+	WebhookID int
+	Status    string
+}
+
+// PendingDelivery is a delivery claimed by the dispatcher together with everything needed to send it.
+type PendingDelivery struct {
+	ID        int
+	WebhookID int
+	EventID   int
+	Attempts  int
+	URL       string
+	Secret    string
+	EventType string
+	Payload   string
+	CreatedAt string
+}
+
+// Envelope is the JSON body posted to a webhook.
+type Envelope struct {
+	ID        int             `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt string          `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
@@ -0,0 +1,364 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	constants "github.com/pandusatrianura/kasir_api_service/constant"
+	"github.com/pandusatrianura/kasir_api_service/internal/webhooks/entity"
+	"github.com/pandusatrianura/kasir_api_service/pkg/database"
+	"github.com/pandusatrianura/kasir_api_service/pkg/pagination"
+)
+
+const (
+	webhookColumns  = "id, url, array_to_string(event_types, ','), COALESCE(description, ''), is_active, created_at, updated_at"
+	deliveryColumns = "webhook_deliveries.id, webhook_deliveries.webhook_id, webhook_deliveries.event_id, outbox_events.event_type, webhook_deliveries.status, webhook_deliveries.attempts, COALESCE(webhook_deliveries.last_status_code, 0), COALESCE(webhook_deliveries.last_error, ''), webhook_deliveries.next_attempt_at, webhook_deliveries.delivered_at, webhook_deliveries.created_at, webhook_deliveries.updated_at"
+)
+
+type IWebhookRepository interface {
+	CreateWebhook(webhook *entity.Webhook) (int, error)
+	UpdateWebhook(id int, webhook *entity.Webhook) error
+	DeleteWebhook(id int) error
+	GetWebhookByID(id int) (*entity.Webhook, error)
+	GetWebhooks() ([]entity.Webhook, error)
+	GetDeliveries(filter entity.DeliveryFilter) ([]entity.Delivery, int64, error)
+	RetryDelivery(id int) error
+	FanOutEvents(limit int) (int, error)
+	ClaimDeliveries(limit int, lease time.Duration) ([]entity.PendingDelivery, error)
+	MarkDelivered(id int, statusCode int) error
+	MarkFailed(id int, statusCode int, message string, retryIn time.Duration, dead bool) error
+}
+
+type WebhookRepository struct {
+	db *database.DB
+}
+
+func NewWebhookRepository(db *database.DB) IWebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+func (w *WebhookRepository) CreateWebhook(webhook *entity.Webhook) (int, error) {
+	var (
+		webhookID int
+		query     string
+		err       error
+	)
+
+	query = "INSERT INTO webhooks (url, secret, event_types, description, is_active, created_at, updated_at) VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, $7) RETURNING id"
+	err = w.db.WithStmt(query, func(stmt *database.Stmt) error {
+		return stmt.QueryRow(webhook.URL, webhook.Secret, pq.Array(webhook.EventTypes), webhook.Description, webhook.IsActive, "now()", "now()").Scan(&webhookID)
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return webhookID, nil
+}
+
+// UpdateWebhook changes a webhook; its secret is only replaced when a new one is given.
+func (w *WebhookRepository) UpdateWebhook(id int, webhook *entity.Webhook) error {
+	query := "UPDATE webhooks SET url = $1, secret = COALESCE(NULLIF($2, ''), secret), event_types = $3, description = NULLIF($4, ''), is_active = $5, updated_at = $6 WHERE id = $7"
+
+	return w.db.WithStmt(query, func(stmt *database.Stmt) error {
+		result, err := stmt.Exec(webhook.URL, webhook.Secret, pq.Array(webhook.EventTypes), webhook.Description, webhook.IsActive, "now()", id)
+		if err != nil {
+			return err
+		}
+
+		return requireRow(result, constants.ErrWebhookNotFound)
+	})
+}
+
+func (w *WebhookRepository) DeleteWebhook(id int) error {
+	query := "DELETE FROM webhooks WHERE id = $1"
+
+	return w.db.WithStmt(query, func(stmt *database.Stmt) error {
+		result, err := stmt.Exec(id)
+		if err != nil {
+			return err
+		}
+
+		return requireRow(result, constants.ErrWebhookNotFound)
+	})
+}
+
+func (w *WebhookRepository) GetWebhookByID(id int) (*entity.Webhook, error) {
+	var (
+		webhook entity.Webhook
+		query   string
+		err     error
+	)
+
+	query = fmt.Sprintf("SELECT %s FROM webhooks WHERE id = $1", webhookColumns)
+	err = w.db.WithStmt(query, func(stmt *database.Stmt) error {
+		scanFn := func(rows *database.Rows) error {
+			return scanWebhook(rows, &webhook)
+		}
+
+		return stmt.Query(scanFn, id)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if webhook.ID == 0 {
+		return nil, errors.New(constants.ErrWebhookNotFound)
+	}
+
+	return &webhook, nil
+}
+
+func (w *WebhookRepository) GetWebhooks() ([]entity.Webhook, error) {
+	var (
+		webhooks []entity.Webhook
+		query    string
+		err      error
+	)
+
+	webhooks = make([]entity.Webhook, 0)
+
+	query = fmt.Sprintf("SELECT %s FROM webhooks ORDER BY id", webhookColumns)
+	err = w.db.WithStmt(query, func(stmt *database.Stmt) error {
+		scanFn := func(rows *database.Rows) error {
+			var webhook entity.Webhook
+			if err := scanWebhook(rows, &webhook); err != nil {
+				return err
+			}
+
+			webhooks = append(webhooks, webhook)
+			return nil
+		}
+
+		return stmt.Query(scanFn)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return webhooks, nil
+}
+
+func (w *WebhookRepository) GetDeliveries(filter entity.DeliveryFilter) ([]entity.Delivery, int64, error) {
+	var (
+		deliveries []entity.Delivery
+		total      int64
+		conditions []string
+		args       []interface{}
+		query      string
+		err        error
+	)
+
+	deliveries = make([]entity.Delivery, 0)
+
+	args = append(args, filter.WebhookID)
+	conditions = append(conditions, fmt.Sprintf("webhook_deliveries.webhook_id = $%d", len(args)))
+
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		conditions = append(conditions, fmt.Sprintf("webhook_deliveries.status = $%d", len(args)))
+	}
+
+	where := " WHERE " + strings.Join(conditions, " AND ")
+
+	query = "SELECT COUNT(webhook_deliveries.id) FROM webhook_deliveries" + where
+	err = w.db.WithStmt(query, func(stmt *database.Stmt) error {
+		return stmt.QueryRow(args...).Scan(&total)
+	})
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query = fmt.Sprintf("SELECT %s FROM webhook_deliveries JOIN outbox_events ON webhook_deliveries.event_id = outbox_events.id%s ORDER BY webhook_deliveries.id DESC LIMIT $%d OFFSET $%d", deliveryColumns, where, len(args)+1, len(args)+2)
+	args = append(args, filter.Limit, pagination.Offset(filter.Page, filter.Limit))
+
+	err = w.db.WithStmt(query, func(stmt *database.Stmt) error {
+		scanFn := func(rows *database.Rows) error {
+			var delivery entity.Delivery
+			if err := rows.Scan(&delivery.ID, &delivery.WebhookID, &delivery.EventID, &delivery.EventType, &delivery.Status, &delivery.Attempts, &delivery.LastStatusCode, &delivery.LastError, &delivery.NextAttemptAt, &delivery.DeliveredAt, &delivery.CreatedAt, &delivery.UpdatedAt); err != nil {
+				return err
+			}
+
+			deliveries = append(deliveries, delivery)
+			return nil
+		}
+
+		return stmt.Query(scanFn, args...)
+	})
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return deliveries, total, nil
+}
+
+// RetryDelivery puts a dead-lettered delivery back in the queue with a fresh set of attempts.
+func (w *WebhookRepository) RetryDelivery(id int) error {
+	return w.db.WithTx(func(tx *database.Tx) error {
+		var status string
+
+		query := "SELECT status FROM webhook_deliveries WHERE id = $1 FOR UPDATE"
+		err := tx.WithStmt(query, func(stmt *database.Stmt) error {
+			return stmt.QueryRow(id).Scan(&status)
+		})
+
+		if errors.Is(err, sql.ErrNoRows) {
+			return errors.New(constants.ErrDeliveryNotFound)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if status != constants.DeliveryStatusDead {
+			return errors.New(constants.ErrDeliveryNotDead)
+		}
+
+		query = "UPDATE webhook_deliveries SET status = $1, attempts = 0, next_attempt_at = now(), updated_at = now() WHERE id = $2"
+		return tx.WithStmt(query, func(stmt *database.Stmt) error {
+			_, err := stmt.Exec(constants.DeliveryStatusPending, id)
+			return err
+		})
+	})
+}
+
+// FanOutEvents turns outbox events into one pending delivery per active webhook subscribed to them and marks the
+// events dispatched. Events nobody subscribes to are only marked. SKIP LOCKED lets several instances share the work.
+func (w *WebhookRepository) FanOutEvents(limit int) (int, error) {
+	var eventIDs []int64
+
+	err := w.db.WithTx(func(tx *database.Tx) error {
+		query := "SELECT id FROM outbox_events WHERE dispatched_at IS NULL ORDER BY id LIMIT $1 FOR UPDATE SKIP LOCKED"
+		err := tx.WithStmt(query, func(stmt *database.Stmt) error {
+			scanFn := func(rows *database.Rows) error {
+				var id int64
+				if err := rows.Scan(&id); err != nil {
+					return err
+				}
+
+				eventIDs = append(eventIDs, id)
+				return nil
+			}
+
+			return stmt.Query(scanFn, limit)
+		})
+
+		if err != nil || len(eventIDs) == 0 {
+			return err
+		}
+
+		query = "INSERT INTO webhook_deliveries (webhook_id, event_id, status, next_attempt_at, created_at, updated_at) SELECT webhooks.id, outbox_events.id, $2::varchar, now(), now(), now() FROM outbox_events JOIN webhooks ON webhooks.is_active AND (cardinality(webhooks.event_types) = 0 OR outbox_events.event_type = ANY(webhooks.event_types)) WHERE outbox_events.id = ANY($1) ON CONFLICT (webhook_id, event_id) DO NOTHING"
+		err = tx.WithStmt(query, func(stmt *database.Stmt) error {
+			_, err := stmt.Exec(pq.Array(eventIDs), constants.DeliveryStatusPending)
+			return err
+		})
+
+		if err != nil {
+			return err
+		}
+
+		query = "UPDATE outbox_events SET dispatched_at = now() WHERE id = ANY($1)"
+		return tx.WithStmt(query, func(stmt *database.Stmt) error {
+			_, err := stmt.Exec(pq.Array(eventIDs))
+			return err
+		})
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return len(eventIDs), nil
+}
+
+// ClaimDeliveries picks the pending deliveries that are due and pushes their next attempt out by the lease, so no
+// other dispatcher sends them meanwhile. A dispatcher that dies mid-delivery leaves them to be retried after the lease.
+func (w *WebhookRepository) ClaimDeliveries(limit int, lease time.Duration) ([]entity.PendingDelivery, error) {
+	var (
+		deliveries []entity.PendingDelivery
+		query      string
+		err        error
+	)
+
+	query = "WITH claimed AS (UPDATE webhook_deliveries SET next_attempt_at = now() + $3::interval, updated_at = now() WHERE id IN (SELECT webhook_deliveries.id FROM webhook_deliveries JOIN webhooks ON webhook_deliveries.webhook_id = webhooks.id WHERE webhook_deliveries.status = $1 AND webhook_deliveries.next_attempt_at <= now() AND webhooks.is_active ORDER BY webhook_deliveries.next_attempt_at, webhook_deliveries.id LIMIT $2 FOR UPDATE OF webhook_deliveries SKIP LOCKED) RETURNING id, webhook_id, event_id, attempts) " +
+		"SELECT claimed.id, claimed.webhook_id, claimed.event_id, claimed.attempts, webhooks.url, webhooks.secret, outbox_events.event_type, outbox_events.payload::text, outbox_events.created_at FROM claimed JOIN webhooks ON claimed.webhook_id = webhooks.id JOIN outbox_events ON claimed.event_id = outbox_events.id ORDER BY claimed.id"
+
+	err = w.db.WithStmt(query, func(stmt *database.Stmt) error {
+		scanFn := func(rows *database.Rows) error {
+			var delivery entity.PendingDelivery
+			if err := rows.Scan(&delivery.ID, &delivery.WebhookID, &delivery.EventID, &delivery.Attempts, &delivery.URL, &delivery.Secret, &delivery.EventType, &delivery.Payload, &delivery.CreatedAt); err != nil {
+				return err
+			}
+
+			deliveries = append(deliveries, delivery)
+			return nil
+		}
+
+		return stmt.Query(scanFn, constants.DeliveryStatusPending, limit, fmt.Sprintf("%d seconds", int64(lease.Seconds())))
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+func (w *WebhookRepository) MarkDelivered(id int, statusCode int) error {
+	query := "UPDATE webhook_deliveries SET status = $1, attempts = attempts + 1, last_status_code = $2, last_error = NULL, delivered_at = now(), updated_at = now() WHERE id = $3"
+
+	return w.db.WithStmt(query, func(stmt *database.Stmt) error {
+		_, err := stmt.Exec(constants.DeliveryStatusDelivered, statusCode, id)
+		return err
+	})
+}
+
+// MarkFailed records a failed attempt and schedules the next one, or dead-letters the delivery.
+func (w *WebhookRepository) MarkFailed(id int, statusCode int, message string, retryIn time.Duration, dead bool) error {
+	status := constants.DeliveryStatusPending
+	if dead {
+		status = constants.DeliveryStatusDead
+	}
+
+	query := "UPDATE webhook_deliveries SET status = $1, attempts = attempts + 1, last_status_code = NULLIF($2, 0), last_error = $3, next_attempt_at = now() + $4::interval, updated_at = now() WHERE id = $5"
+
+	return w.db.WithStmt(query, func(stmt *database.Stmt) error {
+		_, err := stmt.Exec(status, statusCode, message, fmt.Sprintf("%d seconds", int64(retryIn.Seconds())), id)
+		return err
+	})
+}
+
+func scanWebhook(rows *database.Rows, webhook *entity.Webhook) error {
+	var eventTypes string
+	if err := rows.Scan(&webhook.ID, &webhook.URL, &eventTypes, &webhook.Description, &webhook.IsActive, &webhook.CreatedAt, &webhook.UpdatedAt); err != nil {
+		return err
+	}
+
+	webhook.EventTypes = make([]string, 0)
+	if eventTypes != "" {
+		webhook.EventTypes = strings.Split(eventTypes, ",")
+	}
+
+	return nil
+}
+
+func requireRow(result sql.Result, notFound string) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return errors.New(notFound)
+	}
+
+	return nil
+}
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pandusatrianura/kasir_api_service/internal/webhooks/entity"
+	"github.com/pandusatrianura/kasir_api_service/internal/webhooks/repository"
+	"github.com/spf13/viper"
+)
+
+const (
+	defaultDispatchInterval = 5 * time.Second
+	defaultDeliveryTimeout  = 10 * time.Second
+	defaultRetryBase        = 30 * time.Second
+	defaultMaxAttempts      = 8
+	maxRetryDelay           = 6 * time.Hour
+	dispatchBatchSize       = 50
+)
+
+// Dispatcher moves events from the outbox to the webhooks. Every event is delivered at least once; receivers
+// should use the event id in X-Webhook-ID to ignore duplicates.
+type Dispatcher struct {
+	webhookRepository repository.IWebhookRepository
+	client            *http.Client
+	interval          time.Duration
+	retryBase         time.Duration
+	maxAttempts       int
+}
+
+// NewDispatcher configures a dispatcher from WEBHOOK_DISPATCH_INTERVAL, WEBHOOK_TIMEOUT, WEBHOOK_RETRY_BASE and
+// WEBHOOK_MAX_ATTEMPTS.
+func NewDispatcher(webhookRepository repository.IWebhookRepository) *Dispatcher {
+	return &Dispatcher{
+		webhookRepository: webhookRepository,
+		client:            &http.Client{Timeout: durationConfig("WEBHOOK_TIMEOUT", defaultDeliveryTimeout)},
+		interval:          durationConfig("WEBHOOK_DISPATCH_INTERVAL", defaultDispatchInterval),
+		retryBase:         durationConfig("WEBHOOK_RETRY_BASE", defaultRetryBase),
+		maxAttempts:       maxAttemptsConfig(),
+	}
+}
+
+// Run dispatches on every tick of the interval until the context is cancelled.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := d.Dispatch(); err != nil {
+			log.Printf("Failed to dispatch webhooks: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Dispatch fans new outbox events out to the webhooks and sends one batch of due deliveries.
+func (d *Dispatcher) Dispatch() error {
+	if _, err := d.webhookRepository.FanOutEvents(dispatchBatchSize); err != nil {
+		return err
+	}
+
+	// The lease outlives the request timeout, so a delivery is never sent twice at the same time.
+	deliveries, err := d.webhookRepository.ClaimDeliveries(dispatchBatchSize, 2*d.client.Timeout)
+	if err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	for _, delivery := range deliveries {
+		wg.Add(1)
+		go func(delivery entity.PendingDelivery) {
+			defer wg.Done()
+			d.deliver(delivery)
+		}(delivery)
+	}
+
+	wg.Wait()
+	return nil
+}
+
+func (d *Dispatcher) deliver(delivery entity.PendingDelivery) {
+	statusCode, err := d.send(delivery)
+	if err == nil {
+		if err = d.webhookRepository.MarkDelivered(delivery.ID, statusCode); err != nil {
+			log.Printf("Failed to mark webhook delivery %d delivered: %v", delivery.ID, err)
+		}
+
+		return
+	}
+
+	attempts := delivery.Attempts + 1
+	dead := attempts >= d.maxAttempts
+	if err = d.webhookRepository.MarkFailed(delivery.ID, statusCode, err.Error(), retryDelay(d.retryBase, attempts), dead); err != nil {
+		log.Printf("Failed to record webhook delivery %d failure: %v", delivery.ID, err)
+	}
+}
+
+// send posts the event to the webhook and returns the response status. Only 2xx responses count as delivered.
+func (d *Dispatcher) send(delivery entity.PendingDelivery) (int, error) {
+	body, err := json.Marshal(entity.Envelope{
+		ID:        delivery.EventID,
+		Type:      delivery.EventType,
+		CreatedAt: delivery.CreatedAt,
+		Data:      json.RawMessage(delivery.Payload),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	request, err := http.NewRequest(http.MethodPost, delivery.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", "Kasir-Webhooks/1.0")
+	request.Header.Set("X-Webhook-ID", strconv.Itoa(delivery.EventID))
+	request.Header.Set("X-Webhook-Event", delivery.EventType)
+	request.Header.Set("X-Webhook-Timestamp", timestamp)
+	request.Header.Set("X-Webhook-Signature", "sha256="+Sign(delivery.Secret, timestamp, body))
+
+	response, err := d.client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		reply, _ := io.ReadAll(io.LimitReader(response.Body, 256))
+		return response.StatusCode, fmt.Errorf("unexpected status %d: %s", response.StatusCode, reply)
+	}
+
+	return response.StatusCode, nil
+}
+
+// Sign returns the hex encoded HMAC-SHA256 of "<timestamp>.<body>" with the webhook secret. Receivers recompute it
+// to check that a request came from us and was not altered, and reject old timestamps to stop replays.
+func Sign(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// retryDelay doubles the wait after every failed attempt: base, 2*base, 4*base and so on, capped at six hours.
+func retryDelay(base time.Duration, attempts int) time.Duration {
+	delay := base
+	for i := 1; i < attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+
+	if delay > maxRetryDelay {
+		return maxRetryDelay
+	}
+
+	return delay
+}
+
+func durationConfig(key string, fallback time.Duration) time.Duration {
+	value := viper.GetDuration(key)
+	if value <= 0 {
+		return fallback
+	}
+
+	return value
+}
+
+func maxAttemptsConfig() int {
+	if attempts := viper.GetInt("WEBHOOK_MAX_ATTEMPTS"); attempts > 0 {
+		return attempts
+	}
+
+	return defaultMaxAttempts
+}
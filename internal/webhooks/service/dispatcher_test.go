@@ -0,0 +1,273 @@
+package service
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pandusatrianura/kasir_api_service/internal/webhooks/entity"
+	"github.com/pandusatrianura/kasir_api_service/internal/webhooks/repository"
+)
+
+type failure struct {
+	id         int
+	statusCode int
+	message    string
+	retryIn    time.Duration
+	dead       bool
+}
+
+// fakeRepository hands the dispatcher a fixed batch of deliveries and records how each one ended.
+type fakeRepository struct {
+	repository.IWebhookRepository
+
+	mu         sync.Mutex
+	pending    []entity.PendingDelivery
+	delivered  map[int]int
+	failures   []failure
+	fannedOut  int
+	claimLease time.Duration
+}
+
+func (f *fakeRepository) FanOutEvents(limit int) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.fannedOut++
+	return 0, nil
+}
+
+func (f *fakeRepository) ClaimDeliveries(limit int, lease time.Duration) ([]entity.PendingDelivery, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	claimed := f.pending
+	f.pending = nil
+	f.claimLease = lease
+	return claimed, nil
+}
+
+func (f *fakeRepository) MarkDelivered(id int, statusCode int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.delivered == nil {
+		f.delivered = make(map[int]int)
+	}
+
+	f.delivered[id] = statusCode
+	return nil
+}
+
+func (f *fakeRepository) MarkFailed(id int, statusCode int, message string, retryIn time.Duration, dead bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.failures = append(f.failures, failure{id: id, statusCode: statusCode, message: message, retryIn: retryIn, dead: dead})
+	return nil
+}
+
+func newTestDispatcher(repo repository.IWebhookRepository, timeout time.Duration) *Dispatcher {
+	return &Dispatcher{
+		webhookRepository: repo,
+		client:            &http.Client{Timeout: timeout},
+		interval:          time.Millisecond,
+		retryBase:         30 * time.Second,
+		maxAttempts:       3,
+	}
+}
+
+func pendingDelivery(url string, attempts int) entity.PendingDelivery {
+	return entity.PendingDelivery{
+		ID:        7,
+		WebhookID: 1,
+		EventID:   42,
+		Attempts:  attempts,
+		URL:       url,
+		Secret:    "whsec_test",
+		EventType: "transaction.created",
+		Payload:   `{"transaction":{"id":1}}`,
+		CreatedAt: "2026-10-17T10:00:00Z",
+	}
+}
+
+func TestSign(t *testing.T) {
+	got := Sign("whsec_test", "1700000000", []byte(`{"id":1}`))
+	want := "2f441ba4b3b2d50d28a9ab9d9fd8880376ecd1eb5d0435401553f5d8d0a5dcf8"
+
+	if got != want {
+		t.Fatalf("Sign() = %s, want %s", got, want)
+	}
+
+	if Sign("other", "1700000000", []byte(`{"id":1}`)) == want {
+		t.Fatal("Sign() ignores the secret")
+	}
+
+	if Sign("whsec_test", "1700000001", []byte(`{"id":1}`)) == want {
+		t.Fatal("Sign() ignores the timestamp")
+	}
+}
+
+func TestDispatchSendsSignedEnvelope(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		header   http.Header
+		body     []byte
+		requests int
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		requests++
+		header = r.Header.Clone()
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	repo := &fakeRepository{pending: []entity.PendingDelivery{pendingDelivery(server.URL, 0)}}
+	if err := newTestDispatcher(repo, time.Second).Dispatch(); err != nil {
+		t.Fatal(err)
+	}
+
+	if requests != 1 {
+		t.Fatalf("webhook received %d requests, want 1", requests)
+	}
+
+	timestamp := header.Get("X-Webhook-Timestamp")
+	if signature := header.Get("X-Webhook-Signature"); signature != "sha256="+Sign("whsec_test", timestamp, body) {
+		t.Fatalf("X-Webhook-Signature = %q does not match the body", signature)
+	}
+
+	if got := header.Get("X-Webhook-ID"); got != "42" {
+		t.Fatalf("X-Webhook-ID = %q, want 42", got)
+	}
+
+	if got := header.Get("X-Webhook-Event"); got != "transaction.created" {
+		t.Fatalf("X-Webhook-Event = %q, want transaction.created", got)
+	}
+
+	var envelope entity.Envelope
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		t.Fatal(err)
+	}
+
+	if envelope.ID != 42 || envelope.Type != "transaction.created" || string(envelope.Data) != `{"transaction":{"id":1}}` {
+		t.Fatalf("unexpected envelope %+v", envelope)
+	}
+
+	if repo.delivered[7] != http.StatusNoContent {
+		t.Fatalf("delivery marked delivered with %d, want %d", repo.delivered[7], http.StatusNoContent)
+	}
+
+	if len(repo.failures) != 0 {
+		t.Fatalf("delivery marked failed: %+v", repo.failures)
+	}
+
+	if repo.fannedOut != 1 {
+		t.Fatalf("events fanned out %d times, want 1", repo.fannedOut)
+	}
+
+	if repo.claimLease != 2*time.Second {
+		t.Fatalf("deliveries leased for %s, want twice the timeout", repo.claimLease)
+	}
+}
+
+func TestDispatchRecordsFailures(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		attempts   int
+		wantStatus int
+		wantRetry  time.Duration
+		wantDead   bool
+	}{
+		{name: "server error is retried", status: http.StatusServiceUnavailable, attempts: 0, wantStatus: http.StatusServiceUnavailable, wantRetry: 30 * time.Second},
+		{name: "retry backs off", status: http.StatusInternalServerError, attempts: 1, wantStatus: http.StatusInternalServerError, wantRetry: time.Minute},
+		{name: "client error is retried", status: http.StatusNotFound, attempts: 0, wantStatus: http.StatusNotFound, wantRetry: 30 * time.Second},
+		{name: "last attempt is dead-lettered", status: http.StatusBadGateway, attempts: 2, wantStatus: http.StatusBadGateway, wantRetry: 2 * time.Minute, wantDead: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "unavailable", test.status)
+			}))
+			defer server.Close()
+
+			repo := &fakeRepository{pending: []entity.PendingDelivery{pendingDelivery(server.URL, test.attempts)}}
+			if err := newTestDispatcher(repo, time.Second).Dispatch(); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(repo.delivered) != 0 {
+				t.Fatalf("delivery marked delivered: %v", repo.delivered)
+			}
+
+			if len(repo.failures) != 1 {
+				t.Fatalf("%d failures recorded, want 1", len(repo.failures))
+			}
+
+			got := repo.failures[0]
+			if got.id != 7 || got.statusCode != test.wantStatus || got.retryIn != test.wantRetry || got.dead != test.wantDead {
+				t.Fatalf("failure = %+v, want status %d, retry in %s, dead %v", got, test.wantStatus, test.wantRetry, test.wantDead)
+			}
+
+			if !strings.Contains(got.message, "unexpected status") {
+				t.Fatalf("failure message = %q", got.message)
+			}
+		})
+	}
+}
+
+func TestDispatchRetriesOnTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	repo := &fakeRepository{pending: []entity.PendingDelivery{pendingDelivery(server.URL, 0)}}
+	if err := newTestDispatcher(repo, 50*time.Millisecond).Dispatch(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(repo.failures) != 1 {
+		t.Fatalf("%d failures recorded, want 1", len(repo.failures))
+	}
+
+	got := repo.failures[0]
+	if got.statusCode != 0 || got.retryIn != 30*time.Second || got.dead {
+		t.Fatalf("failure = %+v, want no status, retry in 30s and not dead", got)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 1, want: 30 * time.Second},
+		{attempts: 2, want: time.Minute},
+		{attempts: 3, want: 2 * time.Minute},
+		{attempts: 8, want: 64 * time.Minute},
+		{attempts: 20, want: maxRetryDelay},
+	}
+
+	for _, test := range tests {
+		if got := retryDelay(30*time.Second, test.attempts); got != test.want {
+			t.Fatalf("retryDelay(30s, %d) = %s, want %s", test.attempts, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	constants "github.com/pandusatrianura/kasir_api_service/constant"
+	"github.com/pandusatrianura/kasir_api_service/internal/webhooks/entity"
+	"github.com/pandusatrianura/kasir_api_service/internal/webhooks/repository"
+)
+
+// eventTypes are the events a webhook can subscribe to.
+var eventTypes = map[string]bool{
+	constants.EventTransactionCreated: true,
+	constants.EventTransactionVoided:  true,
+	constants.EventProductStockLow:    true,
+	constants.EventProductUpdated:     true,
+}
+
+type IWebhookService interface {
+	CreateWebhook(request *entity.RequestWebhook) (*entity.Webhook, error)
+	UpdateWebhook(id int, request *entity.RequestWebhook) (*entity.Webhook, error)
+	DeleteWebhook(id int) error
+	GetWebhookByID(id int) (*entity.Webhook, error)
+	GetWebhooks() ([]entity.Webhook, error)
+	GetDeliveries(filter entity.DeliveryFilter) ([]entity.Delivery, int64, error)
+	RetryDelivery(id int) error
+	API() entity.HealthCheck
+}
+
+type WebhookService struct {
+	webhookRepository repository.IWebhookRepository
+}
+
+func NewWebhookService(webhookRepository repository.IWebhookRepository) IWebhookService {
+	return &WebhookService{webhookRepository: webhookRepository}
+}
+
+func (s *WebhookService) API() entity.HealthCheck {
+	return entity.HealthCheck{
+		Name:      "Webhooks API",
+		IsHealthy: true,
+	}
+}
+
+// CreateWebhook registers a webhook. Without a secret one is generated; it is only returned here, so the receiver
+// must store it to verify signatures.
+func (s *WebhookService) CreateWebhook(request *entity.RequestWebhook) (*entity.Webhook, error) {
+	webhook, err := newWebhook(request)
+	if err != nil {
+		return nil, err
+	}
+
+	if webhook.Secret == "" {
+		if webhook.Secret, err = generateSecret(); err != nil {
+			return nil, err
+		}
+	}
+
+	id, err := s.webhookRepository.CreateWebhook(webhook)
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := s.webhookRepository.GetWebhookByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	created.Secret = webhook.Secret
+	return created, nil
+}
+
+func (s *WebhookService) UpdateWebhook(id int, request *entity.RequestWebhook) (*entity.Webhook, error) {
+	webhook, err := newWebhook(request)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.webhookRepository.UpdateWebhook(id, webhook); err != nil {
+		return nil, err
+	}
+
+	return s.webhookRepository.GetWebhookByID(id)
+}
+
+func (s *WebhookService) DeleteWebhook(id int) error {
+	return s.webhookRepository.DeleteWebhook(id)
+}
+
+func (s *WebhookService) GetWebhookByID(id int) (*entity.Webhook, error) {
+	return s.webhookRepository.GetWebhookByID(id)
+}
+
+func (s *WebhookService) GetWebhooks() ([]entity.Webhook, error) {
+	return s.webhookRepository.GetWebhooks()
+}
+
+func (s *WebhookService) GetDeliveries(filter entity.DeliveryFilter) ([]entity.Delivery, int64, error) {
+	switch filter.Status {
+	case "", constants.DeliveryStatusPending, constants.DeliveryStatusDelivered, constants.DeliveryStatusDead:
+	default:
+		return nil, 0, errors.New(constants.ErrInvalidDeliveryStatus)
+	}
+
+	if _, err := s.webhookRepository.GetWebhookByID(filter.WebhookID); err != nil {
+		return nil, 0, err
+	}
+
+	return s.webhookRepository.GetDeliveries(filter)
+}
+
+func (s *WebhookService) RetryDelivery(id int) error {
+	return s.webhookRepository.RetryDelivery(id)
+}
+
+func newWebhook(request *entity.RequestWebhook) (*entity.Webhook, error) {
+	webhook := &entity.Webhook{
+		URL:         strings.TrimSpace(request.URL),
+		Secret:      strings.TrimSpace(request.Secret),
+		EventTypes:  make([]string, 0, len(request.EventTypes)),
+		Description: strings.TrimSpace(request.Description),
+		IsActive:    true,
+	}
+
+	target, err := url.Parse(webhook.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return nil, errors.New(constants.ErrInvalidWebhookURL)
+	}
+
+	seen := make(map[string]bool)
+	for _, eventType := range request.EventTypes {
+		eventType = strings.TrimSpace(eventType)
+		if !eventTypes[eventType] {
+			return nil, fmt.Errorf("%s: %s", constants.ErrInvalidEventType, eventType)
+		}
+
+		if !seen[eventType] {
+			seen[eventType] = true
+			webhook.EventTypes = append(webhook.EventTypes, eventType)
+		}
+	}
+
+	if request.IsActive != nil {
+		webhook.IsActive = *request.IsActive
+	}
+
+	return webhook, nil
+}
+
+func generateSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(secret), nil
+}
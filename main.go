@@ -1,11 +1,14 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"log"
+	"os"
 
 	"github.com/common-nighthawk/go-figure"
 	"github.com/pandusatrianura/kasir_api_service/api"
+	auditRepository "github.com/pandusatrianura/kasir_api_service/internal/audit/repository"
 	"github.com/pandusatrianura/kasir_api_service/pkg/config"
 	"github.com/pandusatrianura/kasir_api_service/pkg/database"
 	"github.com/spf13/viper"
@@ -39,8 +42,34 @@ func main() {
 		}
 	}(db)
 
+	if len(os.Args) > 1 && os.Args[1] == "verify-chain" {
+		if !verifyChain(db) {
+			_ = db.Close()
+			os.Exit(1)
+		}
+
+		return
+	}
+
 	server := api.NewAPIServer(fmt.Sprintf(":%s", port), db)
 	if err := server.Run(); err != nil {
 		log.Fatalf("Failed to start server: %v", err)
 	}
 }
+
+// verifyChain walks the audit hash chain, prints the result and reports whether the chain is intact. Run it with
+// "go run . verify-chain"; the exit status is 1 when a link is broken.
+func verifyChain(db *database.DB) bool {
+	verification, err := auditRepository.NewAuditRepository(db).VerifyChain()
+	if err != nil {
+		log.Fatalf("Failed to verify audit chain: %v", err)
+	}
+
+	result, err := json.MarshalIndent(verification, "", "  ")
+	if err != nil {
+		log.Fatalf("Failed to print audit chain verification: %v", err)
+	}
+
+	fmt.Println(string(result))
+	return verification.Valid
+}
@@ -0,0 +1,5 @@
+-- Record the cashier on every transaction and speed up the transaction history listing.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS user_id INTEGER REFERENCES users (id);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_created_at ON transactions (created_at);
+CREATE INDEX IF NOT EXISTS idx_transactions_user_id ON transactions (user_id);
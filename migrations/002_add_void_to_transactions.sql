@@ -0,0 +1,7 @@
+-- Allow a completed transaction to be voided (fully refunded) and keep track of who voided it and why.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'completed';
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS void_reason TEXT;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS voided_by INTEGER REFERENCES users (id);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS voided_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_transactions_status ON transactions (status);
@@ -0,0 +1,17 @@
+-- Partial returns recorded against a single transaction line.
+CREATE TABLE IF NOT EXISTS transaction_returns (
+    id                    SERIAL PRIMARY KEY,
+    transaction_id        INTEGER   NOT NULL REFERENCES transactions (id),
+    transaction_detail_id INTEGER   NOT NULL REFERENCES transaction_details (id),
+    product_id            INTEGER   NOT NULL,
+    quantity              INTEGER   NOT NULL CHECK (quantity > 0),
+    refund_amount         INTEGER   NOT NULL DEFAULT 0,
+    reason                TEXT,
+    user_id               INTEGER REFERENCES users (id),
+    created_at            TIMESTAMP NOT NULL DEFAULT now(),
+    updated_at            TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_transaction_returns_transaction_id ON transaction_returns (transaction_id);
+CREATE INDEX IF NOT EXISTS idx_transaction_returns_transaction_detail_id ON transaction_returns (transaction_detail_id);
+CREATE INDEX IF NOT EXISTS idx_transaction_returns_created_at ON transaction_returns (created_at);
@@ -0,0 +1,15 @@
+-- Record how every transaction was paid (split tenders) and the change given back in cash.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS paid_amount INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS change_amount INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS transaction_payments (
+    id             SERIAL PRIMARY KEY,
+    transaction_id INTEGER     NOT NULL REFERENCES transactions (id),
+    method         VARCHAR(20) NOT NULL CHECK (method IN ('cash', 'debit', 'qris', 'e-wallet', 'voucher')),
+    amount         INTEGER     NOT NULL CHECK (amount > 0),
+    reference      VARCHAR(100),
+    created_at     TIMESTAMP   NOT NULL DEFAULT now(),
+    updated_at     TIMESTAMP   NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_transaction_payments_transaction_id ON transaction_payments (transaction_id);
@@ -0,0 +1,40 @@
+-- Promotions evaluated at checkout and the discounts they produced on every transaction line.
+CREATE TABLE IF NOT EXISTS promotions (
+    id                SERIAL PRIMARY KEY,
+    name              VARCHAR(150) NOT NULL,
+    type              VARCHAR(30)  NOT NULL CHECK (type IN ('percentage', 'fixed', 'buy_x_get_y', 'bundle', 'min_basket_percentage', 'min_basket_fixed')),
+    product_id        INTEGER REFERENCES products (id) ON DELETE CASCADE,
+    category_id       INTEGER REFERENCES categories (id) ON DELETE CASCADE,
+    value             INTEGER      NOT NULL DEFAULT 0,
+    buy_quantity      INTEGER      NOT NULL DEFAULT 0,
+    get_quantity      INTEGER      NOT NULL DEFAULT 0,
+    bundle_quantity   INTEGER      NOT NULL DEFAULT 0,
+    bundle_price      INTEGER      NOT NULL DEFAULT 0,
+    min_basket_amount INTEGER      NOT NULL DEFAULT 0,
+    start_at          TIMESTAMPTZ,
+    end_at            TIMESTAMPTZ,
+    start_time        VARCHAR(5),
+    end_time          VARCHAR(5),
+    is_active         BOOLEAN      NOT NULL DEFAULT true,
+    created_at        TIMESTAMP    NOT NULL DEFAULT now(),
+    updated_at        TIMESTAMP    NOT NULL DEFAULT now(),
+    deleted_at        TIMESTAMP
+);
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS subtotal_amount INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS discount_amount INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS discount_amount INTEGER NOT NULL DEFAULT 0;
+
+UPDATE transactions SET subtotal_amount = total_amount WHERE subtotal_amount = 0;
+
+CREATE TABLE IF NOT EXISTS transaction_promotions (
+    id                    SERIAL PRIMARY KEY,
+    transaction_id        INTEGER      NOT NULL REFERENCES transactions (id),
+    transaction_detail_id INTEGER      NOT NULL REFERENCES transaction_details (id),
+    promotion_id          INTEGER      NOT NULL,
+    promotion_name        VARCHAR(150) NOT NULL,
+    discount_amount       INTEGER      NOT NULL,
+    created_at            TIMESTAMP    NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_transaction_promotions_transaction_id ON transaction_promotions (transaction_id);
@@ -0,0 +1,15 @@
+-- PPN (tax) rules per category and the net, tax and service charge amounts stored on every sale.
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS tax_rate NUMERIC(5, 2);
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS tax_exempt BOOLEAN NOT NULL DEFAULT false;
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS net_amount INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS tax_amount INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS service_charge_amount INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS net_amount INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS tax_rate NUMERIC(5, 2) NOT NULL DEFAULT 0;
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS tax_amount INTEGER NOT NULL DEFAULT 0;
+
+-- Sales recorded before tax was tracked are treated as untaxed.
+UPDATE transactions SET net_amount = total_amount WHERE net_amount = 0;
+UPDATE transaction_details SET net_amount = subtotal WHERE net_amount = 0;
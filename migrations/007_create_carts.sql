@@ -0,0 +1,23 @@
+-- Carts a cashier can hold (park) and resume before turning them into a transaction.
+CREATE TABLE IF NOT EXISTS carts (
+    id             SERIAL PRIMARY KEY,
+    user_id        INTEGER     NOT NULL REFERENCES users (id),
+    status         VARCHAR(20) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'held', 'checked_out', 'expired')),
+    note           VARCHAR(255),
+    transaction_id INTEGER REFERENCES transactions (id),
+    held_at        TIMESTAMP,
+    created_at     TIMESTAMP   NOT NULL DEFAULT now(),
+    updated_at     TIMESTAMP   NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_carts_user_id_status ON carts (user_id, status);
+
+CREATE TABLE IF NOT EXISTS cart_items (
+    id         SERIAL PRIMARY KEY,
+    cart_id    INTEGER   NOT NULL REFERENCES carts (id) ON DELETE CASCADE,
+    product_id INTEGER   NOT NULL REFERENCES products (id),
+    quantity   INTEGER   NOT NULL CHECK (quantity > 0),
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    updated_at TIMESTAMP NOT NULL DEFAULT now(),
+    UNIQUE (cart_id, product_id)
+);
@@ -0,0 +1,14 @@
+-- Idempotency keys sent with checkout requests so retried requests replay the original sale instead of creating a new one.
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    id              SERIAL PRIMARY KEY,
+    user_id         INTEGER      NOT NULL,
+    idempotency_key VARCHAR(255) NOT NULL,
+    request_hash    CHAR(64)     NOT NULL,
+    status          VARCHAR(20)  NOT NULL DEFAULT 'processing' CHECK (status IN ('processing', 'completed')),
+    response        TEXT,
+    created_at      TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    expires_at      TIMESTAMPTZ  NOT NULL,
+    UNIQUE (user_id, idempotency_key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_at ON idempotency_keys (expires_at);
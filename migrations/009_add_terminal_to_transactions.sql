@@ -0,0 +1,4 @@
+-- Terminal (register) a sale was rung up on, sent by the client in the X-Terminal-ID header.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS terminal_id VARCHAR(50);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_user_id_created_at ON transactions (user_id, created_at);
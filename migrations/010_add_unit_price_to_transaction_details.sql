@@ -0,0 +1,5 @@
+-- Unit price a line was sold at, printed on receipts.
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS unit_price INTEGER NOT NULL DEFAULT 0;
+
+-- Lines sold before the unit price was recorded fall back to the current product price.
+UPDATE transaction_details SET unit_price = products.price FROM products WHERE transaction_details.product_id = products.id AND transaction_details.unit_price = 0;
@@ -0,0 +1,11 @@
+-- Gap-free daily invoice numbers such as INV/20261017/0001, one counter per invoice prefix (store) per day.
+CREATE TABLE IF NOT EXISTS invoice_sequences (
+    prefix        VARCHAR(20) NOT NULL,
+    sequence_date DATE        NOT NULL,
+    last_number   INTEGER     NOT NULL,
+    PRIMARY KEY (prefix, sequence_date)
+);
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS invoice_number VARCHAR(50);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transactions_invoice_number ON transactions (invoice_number);
@@ -0,0 +1,29 @@
+-- Customers and their loyalty points. points_balance is the running total of customer_points_ledger.
+CREATE TABLE IF NOT EXISTS customers (
+    id             SERIAL PRIMARY KEY,
+    name           VARCHAR(150) NOT NULL,
+    phone          VARCHAR(30) UNIQUE,
+    email          VARCHAR(150) UNIQUE,
+    points_balance INTEGER      NOT NULL DEFAULT 0,
+    created_at     TIMESTAMP    NOT NULL DEFAULT now(),
+    updated_at     TIMESTAMP    NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS customer_points_ledger (
+    id             SERIAL PRIMARY KEY,
+    customer_id    INTEGER     NOT NULL REFERENCES customers (id) ON DELETE CASCADE,
+    transaction_id INTEGER REFERENCES transactions (id),
+    type           VARCHAR(20) NOT NULL CHECK (type IN ('earn', 'redeem', 'void')),
+    points         INTEGER     NOT NULL,
+    balance_after  INTEGER     NOT NULL,
+    created_at     TIMESTAMP   NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_customer_points_ledger_customer_id ON customer_points_ledger (customer_id);
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS customer_id INTEGER REFERENCES customers (id) ON DELETE SET NULL;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS points_earned INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS points_redeemed INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS points_discount INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_transactions_customer_id ON transactions (customer_id);
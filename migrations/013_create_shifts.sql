@@ -0,0 +1,37 @@
+-- Cash drawer shifts: a cashier opens a shift with an opening float, sales are attached to it and closing records
+-- the counted cash against the expected cash.
+CREATE TABLE IF NOT EXISTS shifts (
+    id            SERIAL PRIMARY KEY,
+    user_id       INTEGER     NOT NULL REFERENCES users (id),
+    terminal_id   VARCHAR(50),
+    status        VARCHAR(20) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'closed')),
+    opening_float INTEGER     NOT NULL CHECK (opening_float >= 0),
+    opening_note  VARCHAR(255),
+    counted_cash  INTEGER,
+    expected_cash INTEGER,
+    difference    INTEGER,
+    closing_note  VARCHAR(255),
+    opened_at     TIMESTAMP   NOT NULL DEFAULT now(),
+    closed_at     TIMESTAMP,
+    created_at    TIMESTAMP   NOT NULL DEFAULT now(),
+    updated_at    TIMESTAMP   NOT NULL DEFAULT now()
+);
+
+-- A cashier can only have one open shift at a time.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_shifts_user_id_open ON shifts (user_id) WHERE status = 'open';
+
+CREATE TABLE IF NOT EXISTS shift_cash_movements (
+    id         SERIAL PRIMARY KEY,
+    shift_id   INTEGER      NOT NULL REFERENCES shifts (id),
+    user_id    INTEGER      NOT NULL REFERENCES users (id),
+    type       VARCHAR(10)  NOT NULL CHECK (type IN ('in', 'out')),
+    amount     INTEGER      NOT NULL CHECK (amount > 0),
+    reason     VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP    NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_shift_cash_movements_shift_id ON shift_cash_movements (shift_id);
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS shift_id INTEGER REFERENCES shifts (id);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_shift_id ON transactions (shift_id);
@@ -0,0 +1,7 @@
+-- Sales captured offline carry the UUID the terminal generated for them, so a batch uploaded twice records every
+-- sale once. Offline sales may take stock below zero and are then flagged for review.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS client_id UUID;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS needs_review BOOLEAN NOT NULL DEFAULT false;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transactions_client_id ON transactions (client_id);
+CREATE INDEX IF NOT EXISTS idx_transactions_needs_review ON transactions (needs_review) WHERE needs_review;
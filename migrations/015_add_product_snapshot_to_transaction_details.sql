@@ -0,0 +1,14 @@
+-- Product details as they were at the time of sale, so renaming, repricing or deleting a product never rewrites history.
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS product_name VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS product_sku VARCHAR(64);
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS category_id INTEGER;
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS category_name VARCHAR(255) NOT NULL DEFAULT '';
+
+-- Lines sold before the snapshot existed take the current product and category.
+UPDATE transaction_details SET product_name = products.name, category_id = categories.id, category_name = categories.name FROM products JOIN categories ON products.category_id = categories.id WHERE transaction_details.product_id = products.id AND transaction_details.product_name = '';
+
+-- Deleting a product keeps its sold lines and returns; they only lose the link back to the catalog.
+ALTER TABLE transaction_details ALTER COLUMN product_id DROP NOT NULL;
+ALTER TABLE transaction_details DROP CONSTRAINT IF EXISTS transaction_details_product_id_fkey;
+ALTER TABLE transaction_details ADD CONSTRAINT transaction_details_product_id_fkey FOREIGN KEY (product_id) REFERENCES products (id) ON DELETE SET NULL;
+ALTER TABLE transaction_returns ALTER COLUMN product_id DROP NOT NULL;
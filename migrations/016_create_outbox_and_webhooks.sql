@@ -0,0 +1,41 @@
+-- Domain events written in the same database transaction as the change they describe.
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id            BIGSERIAL PRIMARY KEY,
+    event_type    VARCHAR(50) NOT NULL,
+    payload       JSONB       NOT NULL,
+    created_at    TIMESTAMP   NOT NULL DEFAULT now(),
+    dispatched_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_events_undispatched ON outbox_events (id) WHERE dispatched_at IS NULL;
+
+-- Endpoints that receive the events. An empty event_types list subscribes to every event.
+CREATE TABLE IF NOT EXISTS webhooks (
+    id          SERIAL PRIMARY KEY,
+    url         TEXT         NOT NULL,
+    secret      VARCHAR(128) NOT NULL,
+    event_types TEXT[]       NOT NULL DEFAULT '{}',
+    description VARCHAR(255),
+    is_active   BOOLEAN      NOT NULL DEFAULT true,
+    created_at  TIMESTAMP    NOT NULL DEFAULT now(),
+    updated_at  TIMESTAMP    NOT NULL DEFAULT now()
+);
+
+-- One row per event per webhook. Failed deliveries are retried with exponential backoff until they are dead-lettered.
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id               BIGSERIAL PRIMARY KEY,
+    webhook_id       INTEGER     NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+    event_id         BIGINT      NOT NULL REFERENCES outbox_events (id),
+    status           VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'delivered', 'dead')),
+    attempts         INTEGER     NOT NULL DEFAULT 0,
+    next_attempt_at  TIMESTAMP   NOT NULL DEFAULT now(),
+    last_status_code INTEGER,
+    last_error       TEXT,
+    delivered_at     TIMESTAMP,
+    created_at       TIMESTAMP   NOT NULL DEFAULT now(),
+    updated_at       TIMESTAMP   NOT NULL DEFAULT now(),
+    UNIQUE (webhook_id, event_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_pending ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook_id ON webhook_deliveries (webhook_id, status);
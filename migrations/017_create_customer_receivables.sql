@@ -0,0 +1,25 @@
+-- Credit sales (kasbon). receivable_balance is what the customer still owes and is the running total of
+-- customer_receivables_ledger; credit_limit caps it, a limit of 0 means the customer cannot buy on credit.
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS credit_limit INTEGER NOT NULL DEFAULT 0 CHECK (credit_limit >= 0);
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS receivable_balance INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE transaction_payments DROP CONSTRAINT IF EXISTS transaction_payments_method_check;
+ALTER TABLE transaction_payments ADD CONSTRAINT transaction_payments_method_check CHECK (method IN ('cash', 'debit', 'qris', 'e-wallet', 'voucher', 'credit'));
+
+CREATE TABLE IF NOT EXISTS customer_receivables_ledger (
+    id             SERIAL PRIMARY KEY,
+    customer_id    INTEGER     NOT NULL REFERENCES customers (id) ON DELETE CASCADE,
+    transaction_id INTEGER REFERENCES transactions (id),
+    shift_id       INTEGER REFERENCES shifts (id),
+    user_id        INTEGER,
+    type           VARCHAR(20) NOT NULL CHECK (type IN ('charge', 'repayment', 'void')),
+    method         VARCHAR(20),
+    amount         INTEGER     NOT NULL,
+    balance_after  INTEGER     NOT NULL,
+    reference      VARCHAR(100),
+    note           TEXT,
+    created_at     TIMESTAMP   NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_customer_receivables_ledger_customer_id ON customer_receivables_ledger (customer_id);
+CREATE INDEX IF NOT EXISTS idx_customer_receivables_ledger_shift_id ON customer_receivables_ledger (shift_id) WHERE shift_id IS NOT NULL;
@@ -0,0 +1,16 @@
+-- Tamper-evident hash chain over sales, voids and returns. Every record hashes its canonical contents together with
+-- the hash of the record before it, so editing or deleting a chained record breaks every link after it. Sales
+-- recorded before this migration are not chained.
+CREATE TABLE IF NOT EXISTS audit_chain (
+    seq            BIGSERIAL PRIMARY KEY,
+    record_type    VARCHAR(20) NOT NULL CHECK (record_type IN ('sale', 'void', 'return')),
+    transaction_id INTEGER     NOT NULL REFERENCES transactions (id),
+    return_id      INTEGER REFERENCES transaction_returns (id),
+    prev_hash      CHAR(64)    NOT NULL,
+    hash           CHAR(64)    NOT NULL UNIQUE,
+    created_at     TIMESTAMP   NOT NULL DEFAULT now()
+);
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS chain_hash CHAR(64);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS void_chain_hash CHAR(64);
+ALTER TABLE transaction_returns ADD COLUMN IF NOT EXISTS chain_hash CHAR(64);
@@ -0,0 +1,6 @@
+-- Indexes for the paginated product listing: every sort column and the category filter.
+CREATE INDEX IF NOT EXISTS idx_products_name ON products (name, id);
+CREATE INDEX IF NOT EXISTS idx_products_price ON products (price, id);
+CREATE INDEX IF NOT EXISTS idx_products_stock ON products (stock, id);
+CREATE INDEX IF NOT EXISTS idx_products_updated_at ON products (updated_at, id);
+CREATE INDEX IF NOT EXISTS idx_products_category_id ON products (category_id);
@@ -0,0 +1,13 @@
+-- Unique SKU per product and any number of scannable barcodes. Barcodes are stored as validated EAN-13 or EAN-8
+-- codes; UPC-A codes are stored as the equivalent EAN-13 with a leading zero.
+ALTER TABLE products ADD COLUMN IF NOT EXISTS sku VARCHAR(64);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_products_sku ON products (sku) WHERE sku IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS product_barcodes (
+    id         SERIAL PRIMARY KEY,
+    product_id INTEGER     NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    code       VARCHAR(13) NOT NULL UNIQUE,
+    created_at TIMESTAMP   NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_barcodes_product_id ON product_barcodes (product_id);
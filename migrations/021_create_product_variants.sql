@@ -0,0 +1,25 @@
+-- Variants of a product (sizes, flavors, colors) with their own sku, price and stock. A product lists its option
+-- axes, e.g. ["size", "color"], and every variant has one value per axis. Once a product has variants it is sold
+-- per variant and products.stock is kept as the total stock of its variants.
+ALTER TABLE products ADD COLUMN IF NOT EXISTS variant_axes JSONB NOT NULL DEFAULT '[]';
+
+CREATE TABLE IF NOT EXISTS product_variants (
+    id         SERIAL PRIMARY KEY,
+    product_id INTEGER      NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    name       VARCHAR(255) NOT NULL,
+    sku        VARCHAR(64) UNIQUE,
+    options    JSONB        NOT NULL,
+    price      INTEGER      NOT NULL CHECK (price >= 0),
+    stock      INTEGER      NOT NULL DEFAULT 0,
+    created_at TIMESTAMP    NOT NULL DEFAULT now(),
+    updated_at TIMESTAMP    NOT NULL DEFAULT now(),
+    UNIQUE (product_id, options)
+);
+
+-- The variant sold on a line, so voids and returns put the stock back on the right variant.
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS variant_id INTEGER REFERENCES product_variants (id) ON DELETE SET NULL;
+
+-- A cart holds one line per product and variant.
+ALTER TABLE cart_items ADD COLUMN IF NOT EXISTS variant_id INTEGER REFERENCES product_variants (id) ON DELETE CASCADE;
+ALTER TABLE cart_items DROP CONSTRAINT IF EXISTS cart_items_cart_id_product_id_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_cart_items_line ON cart_items (cart_id, product_id, COALESCE(variant_id, 0));
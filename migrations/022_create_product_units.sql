@@ -0,0 +1,24 @@
+-- Units a product is sold in besides its base unit, e.g. 1 dus = 40 pcs, each with its own price. Stock is always
+-- kept in the base unit; a sale in another unit takes the quantity times its factor off the stock.
+ALTER TABLE products ADD COLUMN IF NOT EXISTS base_unit VARCHAR(20) NOT NULL DEFAULT 'pcs';
+
+CREATE TABLE IF NOT EXISTS product_units (
+    id         SERIAL PRIMARY KEY,
+    product_id INTEGER     NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    name       VARCHAR(20) NOT NULL,
+    factor     INTEGER     NOT NULL CHECK (factor > 1),
+    price      INTEGER     NOT NULL CHECK (price >= 0),
+    created_at TIMESTAMP   NOT NULL DEFAULT now(),
+    updated_at TIMESTAMP   NOT NULL DEFAULT now(),
+    UNIQUE (product_id, name)
+);
+
+-- The unit a line was sold in and how many base units one of it holds, at the time of sale. Lines sold before
+-- units existed have no unit and a factor of one.
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS unit VARCHAR(20) NOT NULL DEFAULT '';
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS unit_factor INTEGER NOT NULL DEFAULT 1;
+
+-- A cart holds one line per product, variant and unit; an empty unit is the base unit.
+ALTER TABLE cart_items ADD COLUMN IF NOT EXISTS unit VARCHAR(20) NOT NULL DEFAULT '';
+DROP INDEX IF EXISTS idx_cart_items_line;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_cart_items_line ON cart_items (cart_id, product_id, COALESCE(variant_id, 0), unit);
@@ -0,0 +1,3 @@
+-- The sale an idempotency key produced. It is set in the same database transaction as the sale, so a key can never
+-- be released or left processing once its sale has been recorded.
+ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS transaction_id INTEGER REFERENCES transactions (id);
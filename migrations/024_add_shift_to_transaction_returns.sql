@@ -0,0 +1,7 @@
+-- The shift whose drawer paid a return out, so the shift report can take cash refunds off the expected cash.
+ALTER TABLE transaction_returns ADD COLUMN IF NOT EXISTS shift_id INTEGER REFERENCES shifts (id);
+
+CREATE INDEX IF NOT EXISTS idx_transaction_returns_shift_id ON transaction_returns (shift_id);
+
+-- Returns recorded before are given the shift their cashier had open at the time.
+UPDATE transaction_returns SET shift_id = shifts.id FROM shifts WHERE transaction_returns.shift_id IS NULL AND shifts.user_id = transaction_returns.user_id AND transaction_returns.created_at >= shifts.opened_at AND (shifts.closed_at IS NULL OR transaction_returns.created_at <= shifts.closed_at);
@@ -0,0 +1,6 @@
+-- The part of a refund that was taken off the customer's receivable balance instead of being paid out in cash,
+-- for returns of sales paid on credit (kasbon). Only refund_amount - credit_amount leaves the drawer.
+ALTER TABLE transaction_returns ADD COLUMN IF NOT EXISTS credit_amount INTEGER NOT NULL DEFAULT 0 CHECK (credit_amount >= 0);
+
+ALTER TABLE customer_receivables_ledger DROP CONSTRAINT IF EXISTS customer_receivables_ledger_type_check;
+ALTER TABLE customer_receivables_ledger ADD CONSTRAINT customer_receivables_ledger_type_check CHECK (type IN ('charge', 'repayment', 'void', 'return'));
@@ -0,0 +1,5 @@
+-- Barcodes of a variant, e.g. a different EAN per size or flavor, so a scan resolves to the variant. Barcodes with no
+-- variant belong to the product itself; codes stay unique across products and variants.
+ALTER TABLE product_barcodes ADD COLUMN IF NOT EXISTS variant_id INTEGER REFERENCES product_variants (id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_product_barcodes_variant_id ON product_barcodes (variant_id) WHERE variant_id IS NOT NULL;
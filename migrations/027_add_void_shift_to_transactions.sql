@@ -0,0 +1,9 @@
+-- The shift whose drawer handed the cash of a voided sale back, so the shift report can take it off the expected cash
+-- of that shift rather than of the shift that made the sale.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS void_shift_id INTEGER REFERENCES shifts (id);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_void_shift_id ON transactions (void_shift_id);
+
+-- Voids recorded before were left out of the report of the shift that made the sale, as if their cash had gone back
+-- out of the same drawer, so that shift is kept.
+UPDATE transactions SET void_shift_id = shift_id WHERE void_shift_id IS NULL AND status = 'voided' AND shift_id IS NOT NULL;
@@ -0,0 +1,13 @@
+-- Records chained from now on hash the customer and review flag of a sale, the product and variant of each line, the
+-- credit and shift of a return and the shift of a void. The version of a record picks the contents it was hashed
+-- with, so records chained before still verify.
+ALTER TABLE audit_chain ADD COLUMN IF NOT EXISTS version SMALLINT NOT NULL DEFAULT 1;
+
+-- Reviewing an offline sale clears needs_review, so the time it was reviewed is kept to hash the flag it was sold with.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS reviewed_at TIMESTAMP;
+
+-- Hashed ids must not change when the customer, product or variant is deleted, so they are kept as plain ids like
+-- the category of a line and the product of a return instead of being set to NULL.
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_customer_id_fkey;
+ALTER TABLE transaction_details DROP CONSTRAINT IF EXISTS transaction_details_product_id_fkey;
+ALTER TABLE transaction_details DROP CONSTRAINT IF EXISTS transaction_details_variant_id_fkey;
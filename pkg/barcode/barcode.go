@@ -0,0 +1,55 @@
+package barcode
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrInvalidBarcode is returned for codes of the wrong length, with non-digits or with a wrong check digit.
+var ErrInvalidBarcode = errors.New("barcode must be a valid EAN-13, UPC-A or EAN-8 code")
+
+// Normalize validates the check digit of an EAN-13, UPC-A or EAN-8 barcode and returns it as stored. UPC-A codes
+// are stored as the equivalent EAN-13 (with a leading zero), since scanners send them either way.
+func Normalize(code string) (string, error) {
+	code = strings.TrimSpace(code)
+
+	switch len(code) {
+	case 8, 13:
+	case 12:
+		code = "0" + code
+	default:
+		return "", ErrInvalidBarcode
+	}
+
+	if !validCheckDigit(code) {
+		return "", ErrInvalidBarcode
+	}
+
+	return code, nil
+}
+
+// validCheckDigit checks the GS1 check digit: counting from the right, the digits before it are weighted 3, 1,
+// 3, 1, ... and the check digit brings the weighted sum up to a multiple of ten.
+func validCheckDigit(code string) bool {
+	sum := 0
+	for i := len(code) - 2; i >= 0; i-- {
+		digit := code[i]
+		if digit < '0' || digit > '9' {
+			return false
+		}
+
+		weight := 1
+		if (len(code)-2-i)%2 == 0 {
+			weight = 3
+		}
+
+		sum += int(digit-'0') * weight
+	}
+
+	check := code[len(code)-1]
+	if check < '0' || check > '9' {
+		return false
+	}
+
+	return (10-sum%10)%10 == int(check-'0')
+}
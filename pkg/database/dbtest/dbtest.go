@@ -0,0 +1,95 @@
+// Package dbtest gives repository tests a real Postgres database. Each test gets its own schema with the base
+// schema and every migration applied, and the schema is dropped when the test ends. Tests are skipped when
+// TEST_DATABASE_URL is not set.
+package dbtest
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/pandusatrianura/kasir_api_service/pkg/database"
+)
+
+// Open connects to the Postgres in TEST_DATABASE_URL and builds the schema in a fresh Postgres schema.
+func Open(t *testing.T) *database.DB {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	admin, err := database.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	admin.Logging = false
+	t.Cleanup(func() { _ = admin.Close() })
+
+	schema := fmt.Sprintf("kasir_test_%d", time.Now().UnixNano())
+	if _, err = admin.Exec("CREATE SCHEMA " + schema); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if _, err := admin.Exec("DROP SCHEMA " + schema + " CASCADE"); err != nil {
+			t.Logf("Failed to drop schema %s: %v", schema, err)
+		}
+	})
+
+	u, err := url.Parse(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	query := u.Query()
+	query.Set("search_path", schema)
+	u.RawQuery = query.Encode()
+
+	db, err := database.Open("postgres", u.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Logging = false
+	t.Cleanup(func() { _ = db.Close() })
+
+	_, file, _, _ := runtime.Caller(0)
+	dir := filepath.Dir(file)
+
+	files, err := filepath.Glob(filepath.Join(dir, "..", "..", "..", "migrations", "*.sql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(files)
+
+	for _, file := range append([]string{filepath.Join(dir, "base_schema.sql")}, files...) {
+		body, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err = db.Exec(string(body)); err != nil {
+			t.Fatalf("%s: %v", file, err)
+		}
+	}
+
+	return db
+}
+
+// QueryInt runs a query that returns a single integer, failing the test on error.
+func QueryInt(t *testing.T, db *database.DB, query string, args ...interface{}) int {
+	t.Helper()
+
+	var value int
+	if err := db.QueryRow(query, args...).Scan(&value); err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+
+	return value
+}
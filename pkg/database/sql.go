@@ -9,6 +9,14 @@ import (
 	"time"
 )
 
+// UniqueViolation is the Postgres error code raised when a write breaks a unique constraint.
+const UniqueViolation = "23505"
+
+// Querier runs statements on the database or inside a database transaction.
+type Querier interface {
+	WithStmt(query string, fn func(stmt *Stmt) error) error
+}
+
 type DB struct {
 	*sql.DB
 	Logging bool
@@ -75,9 +83,8 @@ func (db *DB) WithTx(fn func(tx *Tx) error) error {
 	}
 
 	if err = fn(&Tx{Tx: tx}); err != nil {
-		err := tx.Rollback()
-		if err != nil {
-			return err
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return rbErr
 		}
 		return err
 	}
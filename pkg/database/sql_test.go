@@ -0,0 +1,91 @@
+package database
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// stubDriver hands out connections whose transactions only record how they ended.
+type stubDriver struct {
+	rollbackErr error
+	commits     int
+	rollbacks   int
+}
+
+type stubConn struct{ driver *stubDriver }
+
+type stubTx struct{ driver *stubDriver }
+
+func (d *stubDriver) Open(name string) (driver.Conn, error) { return &stubConn{driver: d}, nil }
+
+func (c *stubConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+
+func (c *stubConn) Close() error { return nil }
+
+func (c *stubConn) Begin() (driver.Tx, error) { return &stubTx{driver: c.driver}, nil }
+
+func (t *stubTx) Commit() error {
+	t.driver.commits++
+	return nil
+}
+
+func (t *stubTx) Rollback() error {
+	t.driver.rollbacks++
+	return t.driver.rollbackErr
+}
+
+func openStub(t *testing.T, name string, stub *stubDriver) *DB {
+	t.Helper()
+
+	sql.Register(name, stub)
+
+	db, err := Open(name, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	return db
+}
+
+func TestWithTx(t *testing.T) {
+	errRejected := errors.New("stock not enough")
+	errRollback := errors.New("connection lost")
+
+	tests := []struct {
+		name          string
+		fnErr         error
+		rollbackErr   error
+		want          error
+		wantCommits   int
+		wantRollbacks int
+	}{
+		{name: "commits when fn succeeds", wantCommits: 1},
+		{name: "returns the error of fn after rolling back", fnErr: errRejected, want: errRejected, wantRollbacks: 1},
+		{name: "returns the rollback error when the rollback fails", fnErr: errRejected, rollbackErr: errRollback, want: errRollback, wantRollbacks: 1},
+	}
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stub := &stubDriver{rollbackErr: test.rollbackErr}
+			db := openStub(t, fmt.Sprintf("stub-withtx-%d", i), stub)
+
+			err := db.WithTx(func(tx *Tx) error {
+				return test.fnErr
+			})
+
+			if !errors.Is(err, test.want) || (test.want == nil && err != nil) {
+				t.Fatalf("WithTx() = %v, want %v", err, test.want)
+			}
+
+			if stub.commits != test.wantCommits || stub.rollbacks != test.wantRollbacks {
+				t.Fatalf("%d commits and %d rollbacks, want %d and %d", stub.commits, stub.rollbacks, test.wantCommits, test.wantRollbacks)
+			}
+		})
+	}
+}
@@ -0,0 +1,23 @@
+// Package outbox records domain events in the same database transaction as the change they describe, so an event
+// exists if and only if the change was committed. The webhooks dispatcher delivers them afterwards.
+package outbox
+
+import (
+	"encoding/json"
+
+	"github.com/pandusatrianura/kasir_api_service/pkg/database"
+)
+
+// Write adds an event with its payload encoded as JSON to the outbox.
+func Write(q database.Querier, eventType string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	query := "INSERT INTO outbox_events (event_type, payload, created_at) VALUES ($1, $2, $3)"
+	return q.WithStmt(query, func(stmt *database.Stmt) error {
+		_, err := stmt.Exec(eventType, string(body), "now()")
+		return err
+	})
+}
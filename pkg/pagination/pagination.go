@@ -0,0 +1,58 @@
+package pagination
+
+import (
+	"net/http"
+	"strconv"
+)
+
+const (
+	DefaultPage  = 1
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+type Meta struct {
+	Page       int   `json:"page"`
+	Limit      int   `json:"limit"`
+	TotalItems int64 `json:"total_items"`
+	TotalPages int   `json:"total_pages"`
+}
+
+// Parse reads the page and limit query parameters, falling back to the defaults when missing or invalid.
+func Parse(r *http.Request) (int, int) {
+	page, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || page < 1 {
+		page = DefaultPage
+	}
+
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit < 1 {
+		limit = DefaultLimit
+	}
+
+	if limit > MaxLimit {
+		limit = MaxLimit
+	}
+
+	return page, limit
+}
+
+// Offset returns the number of rows to skip for the given page and limit.
+func Offset(page int, limit int) int {
+	return (page - 1) * limit
+}
+
+// NewMeta builds the pagination metadata returned alongside a paginated listing.
+func NewMeta(page int, limit int, totalItems int64) Meta {
+	totalPages := 0
+	if limit > 0 {
+		totalPages = int((totalItems + int64(limit) - 1) / int64(limit))
+	}
+
+	return Meta{
+		Page:       page,
+		Limit:      limit,
+		TotalItems: totalItems,
+		TotalPages: totalPages,
+	}
+}
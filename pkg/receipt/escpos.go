@@ -0,0 +1,44 @@
+package receipt
+
+import "bytes"
+
+var (
+	escInit        = []byte{0x1b, '@'}
+	escAlignLeft   = []byte{0x1b, 'a', 0}
+	escAlignCenter = []byte{0x1b, 'a', 1}
+	escBoldOn      = []byte{0x1b, 'E', 1}
+	escBoldOff     = []byte{0x1b, 'E', 0}
+	escFeed        = []byte{0x1b, 'd', 4}
+	escPartialCut  = []byte{0x1d, 'V', 66, 0}
+)
+
+// ESCPOS renders the receipt as raw ESC/POS commands that can be sent to a thermal printer as is.
+func ESCPOS(r Receipt, width int) []byte {
+	var b bytes.Buffer
+
+	b.Write(escInit)
+
+	for _, line := range layout(r, width) {
+		if line.center {
+			b.Write(escAlignCenter)
+		}
+		if line.bold {
+			b.Write(escBoldOn)
+		}
+
+		b.WriteString(line.text)
+		b.WriteByte('\n')
+
+		if line.bold {
+			b.Write(escBoldOff)
+		}
+		if line.center {
+			b.Write(escAlignLeft)
+		}
+	}
+
+	b.Write(escFeed)
+	b.Write(escPartialCut)
+
+	return b.Bytes()
+}
@@ -0,0 +1,78 @@
+package receipt
+
+import (
+	"bytes"
+	"html/template"
+)
+
+var htmlTemplate = template.Must(template.New("receipt").Funcs(template.FuncMap{
+	"amount": FormatAmount,
+	"neg":    func(amount int) int { return -amount },
+	"mul":    func(a int, b int) int { return a * b },
+}).Parse(`<!DOCTYPE html>
+<html lang="id">
+<head>
+<meta charset="utf-8">
+<title>Struk {{.Number}}</title>
+<style>
+body { font-family: "Courier New", monospace; font-size: 12px; width: 300px; margin: 0 auto; }
+h1 { font-size: 14px; margin: 0; }
+.center { text-align: center; }
+.right { text-align: right; }
+table { width: 100%; border-collapse: collapse; }
+td { padding: 1px 0; vertical-align: top; }
+hr { border: none; border-top: 1px dashed #000; }
+.total td { font-weight: bold; }
+.void { font-weight: bold; }
+</style>
+</head>
+<body>
+<div class="center">
+<h1>{{.Store.Name}}</h1>
+{{if .Store.Address}}<div>{{.Store.Address}}</div>{{end}}
+{{if .Store.Phone}}<div>{{.Store.Phone}}</div>{{end}}
+</div>
+<hr>
+<table>
+<tr><td>No</td><td class="right">{{.Number}}</td></tr>
+<tr><td>Tanggal</td><td class="right">{{.Date}}</td></tr>
+{{if .Cashier}}<tr><td>Kasir</td><td class="right">{{.Cashier}}</td></tr>{{end}}
+{{if .Terminal}}<tr><td>Terminal</td><td class="right">{{.Terminal}}</td></tr>{{end}}
+{{if .Customer}}<tr><td>Pelanggan</td><td class="right">{{.Customer}}</td></tr>{{end}}
+</table>
+{{if .Voided}}<div class="center void">*** DIBATALKAN ***</div>{{end}}
+<hr>
+<table>
+{{range .Lines}}<tr><td colspan="2">{{.Name}}</td></tr>
+<tr><td>&nbsp;&nbsp;{{.Quantity}}{{if .Unit}} {{.Unit}}{{end}} x {{amount .UnitPrice}}</td><td class="right">{{amount (mul .Quantity .UnitPrice)}}</td></tr>
+{{range .Discounts}}<tr><td>&nbsp;&nbsp;{{.Name}}</td><td class="right">{{amount (neg .Amount)}}</td></tr>
+{{end}}{{end}}</table>
+<hr>
+<table>
+<tr><td>Subtotal</td><td class="right">{{amount .Subtotal}}</td></tr>
+{{if .Discount}}<tr><td>Diskon</td><td class="right">{{amount (neg .Discount)}}</td></tr>{{end}}
+{{if .PointsDiscount}}<tr><td>Tukar Poin</td><td class="right">{{amount (neg .PointsDiscount)}}</td></tr>{{end}}
+{{if .Tax}}<tr><td>DPP</td><td class="right">{{amount .NetAmount}}</td></tr>
+<tr><td>PPN</td><td class="right">{{amount .Tax}}</td></tr>{{end}}
+{{if .ServiceCharge}}<tr><td>Service</td><td class="right">{{amount .ServiceCharge}}</td></tr>{{end}}
+<tr class="total"><td>TOTAL</td><td class="right">{{amount .Total}}</td></tr>
+{{range .Payments}}<tr><td>{{.Method}}</td><td class="right">{{amount .Amount}}</td></tr>
+{{end}}<tr><td>Kembali</td><td class="right">{{amount .Change}}</td></tr>
+{{if .PointsEarned}}<tr><td>Poin Didapat</td><td class="right">{{.PointsEarned}}</td></tr>{{end}}
+</table>
+{{if .Store.Footer}}<hr>
+<div class="center">{{.Store.Footer}}</div>{{end}}
+</body>
+</html>
+`))
+
+// HTML renders the receipt as a printable HTML page.
+func HTML(r Receipt) (string, error) {
+	var b bytes.Buffer
+
+	if err := htmlTemplate.Execute(&b, r); err != nil {
+		return "", err
+	}
+
+	return b.String(), nil
+}
@@ -0,0 +1,60 @@
+package receipt
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+const (
+	pdfFontSize   = 8.0
+	pdfCharWidth  = pdfFontSize * 0.6 // Courier glyphs are 600/1000 em wide.
+	pdfLineHeight = 10.0
+	pdfMargin     = 14.0
+)
+
+// PDF renders the fixed width receipt on a single page sized to the receipt, using the built-in Courier font so
+// no font has to be embedded.
+func PDF(r Receipt, width int) []byte {
+	lines := strings.Split(strings.TrimRight(Text(r, width), "\n"), "\n")
+
+	pageWidth := float64(width)*pdfCharWidth + 2*pdfMargin
+	pageHeight := float64(len(lines))*pdfLineHeight + 2*pdfMargin
+
+	var content bytes.Buffer
+	fmt.Fprintf(&content, "BT\n/F1 %.1f Tf\n%.1f TL\n%.1f %.1f Td\n", pdfFontSize, pdfLineHeight, pdfMargin, pageHeight-pdfMargin-pdfFontSize)
+	for _, line := range lines {
+		fmt.Fprintf(&content, "(%s) Tj T*\n", escapePDF(line))
+	}
+	content.WriteString("ET\n")
+
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.1f %.1f] /Resources << /Font << /F1 4 0 R >> >> /Contents 5 0 R >>", pageWidth, pageHeight),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()),
+	}
+
+	var b bytes.Buffer
+	offsets := make([]int, len(objects))
+
+	b.WriteString("%PDF-1.4\n")
+	for i, object := range objects {
+		offsets[i] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	return b.Bytes()
+}
+
+func escapePDF(text string) string {
+	return strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`).Replace(text)
+}
@@ -0,0 +1,255 @@
+// Package receipt renders sales receipts for thermal printers (plain text and ESC/POS), browsers (HTML) and PDF.
+package receipt
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	FormatText   = "text"
+	FormatESCPOS = "escpos"
+	FormatHTML   = "html"
+	FormatPDF    = "pdf"
+
+	// Width58mm and Width80mm are the number of characters per line on 58mm and 80mm thermal paper.
+	Width58mm = 32
+	Width80mm = 48
+)
+
+type Store struct {
+	Name    string
+	Address string
+	Phone   string
+	Footer  string
+}
+
+type Receipt struct {
+	Store          Store
+	Number         string
+	Date           string
+	Cashier        string
+	Terminal       string
+	Customer       string
+	Voided         bool
+	Lines          []Line
+	Subtotal       int
+	Discount       int
+	PointsDiscount int
+	NetAmount      int
+	Tax            int
+	ServiceCharge  int
+	Total          int
+	Payments       []Payment
+	Paid           int
+	Change         int
+	PointsEarned   int
+}
+
+type Line struct {
+	Name      string
+	Quantity  int
+	Unit      string
+	UnitPrice int
+	Amount    int
+	Discounts []Discount
+}
+
+type Discount struct {
+	Name   string
+	Amount int
+}
+
+type Payment struct {
+	Method string
+	Amount int
+}
+
+// row is one printed line of a receipt, alignment and emphasis are applied by each renderer.
+type row struct {
+	text   string
+	center bool
+	bold   bool
+}
+
+// IsFormat reports whether format is one of the supported receipt formats.
+func IsFormat(format string) bool {
+	switch format {
+	case FormatText, FormatESCPOS, FormatHTML, FormatPDF:
+		return true
+	default:
+		return false
+	}
+}
+
+// Text renders the receipt as fixed width plain text.
+func Text(r Receipt, width int) string {
+	var b strings.Builder
+
+	for _, line := range layout(r, width) {
+		if line.center {
+			b.WriteString(center(line.text, width))
+		} else {
+			b.WriteString(line.text)
+		}
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+// FormatAmount formats an amount in rupiah with dots as thousands separators, e.g. 12500 becomes "12.500".
+func FormatAmount(amount int) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	digits := strconv.Itoa(amount)
+	for i := len(digits) - 3; i > 0; i -= 3 {
+		digits = digits[:i] + "." + digits[i:]
+	}
+
+	return sign + digits
+}
+
+func layout(r Receipt, width int) []row {
+	var rows []row
+
+	separator := row{text: strings.Repeat("-", width)}
+
+	rows = append(rows, row{text: r.Store.Name, center: true, bold: true})
+	for _, text := range []string{r.Store.Address, r.Store.Phone} {
+		for _, wrapped := range wrap(text, width) {
+			rows = append(rows, row{text: wrapped, center: true})
+		}
+	}
+
+	rows = append(rows, separator)
+	rows = append(rows, row{text: columns("No", r.Number, width)})
+	rows = append(rows, row{text: columns("Tanggal", r.Date, width)})
+	if r.Cashier != "" {
+		rows = append(rows, row{text: columns("Kasir", r.Cashier, width)})
+	}
+	if r.Terminal != "" {
+		rows = append(rows, row{text: columns("Terminal", r.Terminal, width)})
+	}
+	if r.Customer != "" {
+		rows = append(rows, row{text: columns("Pelanggan", r.Customer, width)})
+	}
+
+	if r.Voided {
+		rows = append(rows, row{text: "*** DIBATALKAN ***", center: true, bold: true})
+	}
+
+	rows = append(rows, separator)
+
+	for _, line := range r.Lines {
+		for _, wrapped := range wrap(line.Name, width) {
+			rows = append(rows, row{text: wrapped})
+		}
+
+		quantity := fmt.Sprintf("  %d x %s", line.Quantity, FormatAmount(line.UnitPrice))
+		if line.Unit != "" {
+			quantity = fmt.Sprintf("  %d %s x %s", line.Quantity, line.Unit, FormatAmount(line.UnitPrice))
+		}
+		rows = append(rows, row{text: columns(quantity, FormatAmount(line.Quantity*line.UnitPrice), width)})
+
+		for _, discount := range line.Discounts {
+			rows = append(rows, row{text: columns("  "+discount.Name, FormatAmount(-discount.Amount), width)})
+		}
+	}
+
+	rows = append(rows, separator)
+	rows = append(rows, row{text: columns("Subtotal", FormatAmount(r.Subtotal), width)})
+	if r.Discount > 0 {
+		rows = append(rows, row{text: columns("Diskon", FormatAmount(-r.Discount), width)})
+	}
+	if r.PointsDiscount > 0 {
+		rows = append(rows, row{text: columns("Tukar Poin", FormatAmount(-r.PointsDiscount), width)})
+	}
+	if r.Tax > 0 {
+		rows = append(rows, row{text: columns("DPP", FormatAmount(r.NetAmount), width)})
+		rows = append(rows, row{text: columns("PPN", FormatAmount(r.Tax), width)})
+	}
+	if r.ServiceCharge > 0 {
+		rows = append(rows, row{text: columns("Service", FormatAmount(r.ServiceCharge), width)})
+	}
+	rows = append(rows, row{text: columns("TOTAL", FormatAmount(r.Total), width), bold: true})
+
+	for _, payment := range r.Payments {
+		rows = append(rows, row{text: columns(payment.Method, FormatAmount(payment.Amount), width)})
+	}
+	rows = append(rows, row{text: columns("Kembali", FormatAmount(r.Change), width)})
+	if r.PointsEarned > 0 {
+		rows = append(rows, row{text: columns("Poin Didapat", strconv.Itoa(r.PointsEarned), width)})
+	}
+
+	if r.Store.Footer != "" {
+		rows = append(rows, separator)
+		for _, wrapped := range wrap(r.Store.Footer, width) {
+			rows = append(rows, row{text: wrapped, center: true})
+		}
+	}
+
+	return rows
+}
+
+// columns prints label on the left and value on the right, shortening the label when both do not fit.
+func columns(label string, value string, width int) string {
+	space := width - len(value) - 1
+	if space < 0 {
+		return value
+	}
+
+	if len(label) > space {
+		label = label[:space]
+	}
+
+	return label + strings.Repeat(" ", width-len(label)-len(value)) + value
+}
+
+func center(text string, width int) string {
+	if len(text) >= width {
+		return text
+	}
+
+	return strings.Repeat(" ", (width-len(text))/2) + text
+}
+
+// wrap breaks text into lines of at most width characters, splitting on spaces where possible.
+func wrap(text string, width int) []string {
+	var (
+		lines   []string
+		current string
+	)
+
+	for _, word := range strings.Fields(text) {
+		for len(word) > width {
+			if current != "" {
+				lines = append(lines, current)
+				current = ""
+			}
+			lines = append(lines, word[:width])
+			word = word[width:]
+		}
+
+		switch {
+		case current == "":
+			current = word
+		case len(current)+1+len(word) <= width:
+			current += " " + word
+		default:
+			lines = append(lines, current)
+			current = word
+		}
+	}
+
+	if current != "" {
+		lines = append(lines, current)
+	}
+
+	return lines
+}
@@ -11,6 +11,7 @@ type APIResponse struct {
 	Code    string      `json:"code"`
 	Message interface{} `json:"message"`
 	Data    interface{} `json:"data,omitempty"`
+	Meta    interface{} `json:"meta,omitempty"`
 }
 
 func WriteJSONResponse(w http.ResponseWriter, status int, v any) {
@@ -34,6 +35,15 @@ func Success(w http.ResponseWriter, status int, code int, message string, v any)
 	})
 }
 
+func SuccessWithMeta(w http.ResponseWriter, status int, code int, message string, v any, meta any) {
+	WriteJSONResponse(w, status, APIResponse{
+		Code:    strconv.Itoa(code),
+		Message: message,
+		Data:    v,
+		Meta:    meta,
+	})
+}
+
 func Error(w http.ResponseWriter, status int, code int, message string, err error) {
 	var e interface{}
 	var msg string